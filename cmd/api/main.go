@@ -16,11 +16,15 @@ func main() {
 		log.Fatalf("failed to connect database: %v", err)
 	}
 
-	// init Fiber app
-	app := fiber.New()
+	// init Fiber app; bodies over the default limit are streamed rather
+	// than buffered, and middleware.LimitBody decides per route whether
+	// to accept them
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
 
 	// register routes
-	httpDelivery.RegisterRoutes(app, db)
+	if err := httpDelivery.RegisterRoutes(app, db); err != nil {
+		log.Fatalf("failed to register routes: %v", err)
+	}
 
 	// start server
 	if err := app.Listen(":8080"); err != nil {
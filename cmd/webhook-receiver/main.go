@@ -0,0 +1,63 @@
+// Command webhook-receiver is a local HTTP endpoint for testing toko
+// webhooks. It verifies the signature of every request and prints the
+// payload. Use -status to answer with an error code and exercise retries.
+// The API refuses loopback endpoints unless it runs with
+// WEBHOOK_ALLOW_PRIVATE_TARGETS=true.
+//
+//	WEBHOOK_SECRET=whsec_... go run ./cmd/webhook-receiver -addr :9090
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	status := flag.Int("status", http.StatusOK, "status code to answer verified requests with")
+	flag.Parse()
+
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("WEBHOOK_SECRET is empty, signatures will not be verified")
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if secret != "" {
+			sig := r.Header.Get(helper.WebhookSignatureHeader)
+			if err := helper.VerifyWebhookSignature(secret, sig, body, 5*time.Minute); err != nil {
+				log.Printf("rejected delivery %s: %v", r.Header.Get("X-Evermos-Delivery"), err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err != nil {
+			pretty.Write(body)
+		}
+		log.Printf("delivery %s event %s (answering %d)\n%s",
+			r.Header.Get("X-Evermos-Delivery"), r.Header.Get("X-Evermos-Event"), *status, pretty.String())
+
+		w.WriteHeader(*status)
+	})
+
+	log.Printf("webhook receiver listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Fatalf("failed to start receiver: %v", err)
+	}
+}
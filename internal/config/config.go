@@ -1,7 +1,383 @@
 package config
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
 // AppConfig holds application-wide configuration values.
 type AppConfig struct {
 	JWTSecret string
 	UploadDir string
+	BaseURL   string
+}
+
+// LoadAppConfig returns app config, overridable by environment variables.
+func LoadAppConfig() AppConfig {
+	cfg := AppConfig{
+		JWTSecret: os.Getenv("JWT_SECRET"),
+		UploadDir: "uploads",
+		BaseURL:   "http://localhost:8080",
+	}
+
+	if v := os.Getenv("UPLOAD_DIR"); v != "" {
+		cfg.UploadDir = v
+	}
+	if v := os.Getenv("APP_BASE_URL"); v != "" {
+		cfg.BaseURL = v
+	}
+
+	return cfg
+}
+
+// NotifierConfig selects where outbound user messages (OTP, links) go.
+type NotifierConfig struct {
+	Sink     string
+	FilePath string
+}
+
+// LoadNotifierConfig returns notifier config, overridable by environment variables.
+func LoadNotifierConfig() NotifierConfig {
+	cfg := NotifierConfig{
+		Sink:     "console",
+		FilePath: "notifications.log",
+	}
+
+	if v := os.Getenv("NOTIFIER_SINK"); v != "" {
+		cfg.Sink = v
+	}
+	if v := os.Getenv("NOTIFIER_FILE"); v != "" {
+		cfg.FilePath = v
+	}
+
+	return cfg
+}
+
+// VerificationPolicy lists which contact channels must be verified before
+// an action is allowed: "none", "email", "phone", "any" or "all".
+type VerificationPolicy struct {
+	Checkout      string
+	ProductCreate string
+}
+
+// verificationRequirements lists the accepted VerificationPolicy values.
+var verificationRequirements = map[string]bool{"none": true, "email": true, "phone": true, "any": true, "all": true}
+
+// LoadVerificationPolicy returns verification policy, overridable by
+// environment variables. An unknown value is an error rather than silently
+// turning the requirement off.
+func LoadVerificationPolicy() (VerificationPolicy, error) {
+	cfg := VerificationPolicy{
+		Checkout:      "none",
+		ProductCreate: "none",
+	}
+
+	if v := os.Getenv("VERIFY_REQUIRED_CHECKOUT"); v != "" {
+		cfg.Checkout = v
+	}
+	if v := os.Getenv("VERIFY_REQUIRED_PRODUCT"); v != "" {
+		cfg.ProductCreate = v
+	}
+
+	for env, v := range map[string]string{"VERIFY_REQUIRED_CHECKOUT": cfg.Checkout, "VERIFY_REQUIRED_PRODUCT": cfg.ProductCreate} {
+		if !verificationRequirements[v] {
+			return cfg, fmt.Errorf("%s: unknown value %q, want none, email, phone, any or all", env, v)
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoginAttemptStore selects where failed login attempts are tracked:
+// "db" (shared across replicas) or "memory" (single instance).
+func LoginAttemptStore() string {
+	if v := os.Getenv("LOGIN_ATTEMPT_STORE"); v != "" {
+		return v
+	}
+	return "db"
+}
+
+// SearchEngine selects the product search index: "db" (MySQL FULLTEXT,
+// shared across replicas) or "memory" (single instance, rebuilt on start).
+func SearchEngine() string {
+	if v := os.Getenv("SEARCH_ENGINE"); v != "" {
+		return v
+	}
+	return "db"
+}
+
+// ProductSlugScope selects where product slugs must be unique: "toko"
+// (within each toko) or "global" (across all tokos).
+func ProductSlugScope() string {
+	if v := os.Getenv("PRODUCT_SLUG_SCOPE"); v != "" {
+		return v
+	}
+	return "toko"
+}
+
+// TwoFactorConfig holds TOTP two-factor authentication settings.
+type TwoFactorConfig struct {
+	Issuer string
+	// RequiredForAdmin blocks admin endpoints until the admin logs in with 2FA.
+	RequiredForAdmin bool
+}
+
+// LoadTwoFactorConfig returns 2FA config, overridable by environment variables.
+func LoadTwoFactorConfig() TwoFactorConfig {
+	cfg := TwoFactorConfig{
+		Issuer:           "Evermos",
+		RequiredForAdmin: false,
+	}
+
+	if v := os.Getenv("TOTP_ISSUER"); v != "" {
+		cfg.Issuer = v
+	}
+	if v := os.Getenv("TOTP_REQUIRED_FOR_ADMIN"); v != "" {
+		cfg.RequiredForAdmin = v == "true" || v == "1"
+	}
+
+	return cfg
+}
+
+// AuditConfig holds audit log settings.
+type AuditConfig struct {
+	// RetentionDays is how long audit entries are kept; 0 keeps them forever.
+	RetentionDays int
+}
+
+// LoadAuditConfig returns audit config, overridable by environment variables.
+func LoadAuditConfig() AuditConfig {
+	cfg := AuditConfig{
+		RetentionDays: 365,
+	}
+
+	if v := os.Getenv("AUDIT_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days >= 0 {
+			cfg.RetentionDays = days
+		}
+	}
+
+	return cfg
+}
+
+// WebhookConfig holds outbound webhook dispatcher settings.
+type WebhookConfig struct {
+	// PollInterval is how often pending deliveries are checked.
+	PollInterval time.Duration
+	// AllowPrivateTargets lets endpoints point at loopback and private
+	// addresses, for testing with a local receiver. Keep it off in production.
+	AllowPrivateTargets bool
+}
+
+// LoadWebhookConfig returns webhook config, overridable by environment variables.
+func LoadWebhookConfig() WebhookConfig {
+	cfg := WebhookConfig{
+		PollInterval:        5 * time.Second,
+		AllowPrivateTargets: false,
+	}
+
+	if v := os.Getenv("WEBHOOK_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.PollInterval = time.Duration(secs) * time.Second
+		}
+	}
+	if v := os.Getenv("WEBHOOK_ALLOW_PRIVATE_TARGETS"); v != "" {
+		cfg.AllowPrivateTargets = v == "true" || v == "1"
+	}
+
+	return cfg
+}
+
+// OutboxConfig holds domain event dispatcher settings.
+type OutboxConfig struct {
+	// PollInterval is how often undispatched events are checked.
+	PollInterval time.Duration
+	// RetentionDays is how long dispatched events are kept; 0 keeps them forever.
+	RetentionDays int
+}
+
+// LoadOutboxConfig returns outbox config, overridable by environment variables.
+func LoadOutboxConfig() OutboxConfig {
+	cfg := OutboxConfig{
+		PollInterval:  time.Second,
+		RetentionDays: 7,
+	}
+
+	if v := os.Getenv("OUTBOX_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.PollInterval = time.Duration(secs) * time.Second
+		}
+	}
+	if v := os.Getenv("OUTBOX_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days >= 0 {
+			cfg.RetentionDays = days
+		}
+	}
+
+	return cfg
+}
+
+// NotificationConfig holds notification center settings.
+type NotificationConfig struct {
+	// LowStockThreshold is the stock level at which sellers are notified.
+	LowStockThreshold int
+	// StreamPollInterval is how often open streams check for notifications
+	// created by other instances.
+	StreamPollInterval time.Duration
+}
+
+// LoadNotificationConfig returns notification config, overridable by environment variables.
+func LoadNotificationConfig() NotificationConfig {
+	cfg := NotificationConfig{
+		LowStockThreshold:  5,
+		StreamPollInterval: 5 * time.Second,
+	}
+
+	if v := os.Getenv("NOTIF_LOW_STOCK_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.LowStockThreshold = n
+		}
+	}
+	if v := os.Getenv("NOTIF_STREAM_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.StreamPollInterval = time.Duration(secs) * time.Second
+		}
+	}
+
+	return cfg
+}
+
+// ChatConfig holds live chat settings.
+type ChatConfig struct {
+	// PollInterval is how often live connections check for messages sent
+	// through other instances.
+	PollInterval time.Duration
+}
+
+// LoadChatConfig returns chat config, overridable by environment variables.
+func LoadChatConfig() ChatConfig {
+	cfg := ChatConfig{
+		PollInterval: 5 * time.Second,
+	}
+
+	if v := os.Getenv("CHAT_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.PollInterval = time.Duration(secs) * time.Second
+		}
+	}
+
+	return cfg
+}
+
+// QAConfig holds product Q&A moderation settings.
+type QAConfig struct {
+	// ReportHideThreshold is how many user reports hide a question or
+	// answer until a moderator looks at it; 0 disables automatic hiding.
+	ReportHideThreshold int
+}
+
+// LoadQAConfig returns Q&A config, overridable by environment variables.
+func LoadQAConfig() QAConfig {
+	cfg := QAConfig{
+		ReportHideThreshold: 3,
+	}
+
+	if v := os.Getenv("QA_REPORT_HIDE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ReportHideThreshold = n
+		}
+	}
+
+	return cfg
+}
+
+// ImportConfig holds bulk product import settings.
+type ImportConfig struct {
+	// PollInterval is how often pending import jobs are checked.
+	PollInterval time.Duration
+	// MaxRows is the most product rows one import file may contain.
+	MaxRows int
+	// MaxUploadBytes caps the POST /product/import body, which must fit an
+	// import file together with its zip of images. Other routes keep the
+	// default body limit.
+	MaxUploadBytes int
+}
+
+// LoadImportConfig returns import config, overridable by environment variables.
+func LoadImportConfig() ImportConfig {
+	cfg := ImportConfig{
+		PollInterval:   2 * time.Second,
+		MaxRows:        5000,
+		MaxUploadBytes: 32 << 20,
+	}
+
+	if v := os.Getenv("IMPORT_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.PollInterval = time.Duration(secs) * time.Second
+		}
+	}
+	if v := os.Getenv("IMPORT_MAX_ROWS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxRows = n
+		}
+	}
+	if v := os.Getenv("IMPORT_MAX_UPLOAD_MB"); v != "" {
+		if mb, err := strconv.Atoi(v); err == nil && mb > 0 {
+			cfg.MaxUploadBytes = mb << 20
+		}
+	}
+
+	return cfg
+}
+
+// ProductPublishConfig holds product publication settings.
+type ProductPublishConfig struct {
+	// RequireModeration sends products a seller publishes to the moderation
+	// queue instead of making them public right away.
+	RequireModeration bool
+	// PollInterval is how often scheduled products are checked for publishing.
+	PollInterval time.Duration
+}
+
+// LoadProductPublishConfig returns publication config, overridable by environment variables.
+func LoadProductPublishConfig() ProductPublishConfig {
+	cfg := ProductPublishConfig{
+		RequireModeration: true,
+		PollInterval:      30 * time.Second,
+	}
+
+	if v := os.Getenv("PRODUCT_REQUIRE_MODERATION"); v != "" {
+		cfg.RequireModeration = v == "true" || v == "1"
+	}
+	if v := os.Getenv("PRODUCT_PUBLISH_POLL_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.PollInterval = time.Duration(secs) * time.Second
+		}
+	}
+
+	return cfg
+}
+
+// SuggestConfig holds search suggestion settings.
+type SuggestConfig struct {
+	// RefreshInterval is how long suggestions are served before being
+	// reloaded from the DB; product changes reload them sooner.
+	RefreshInterval time.Duration
+}
+
+// LoadSuggestConfig returns suggestion config, overridable by environment variables.
+func LoadSuggestConfig() SuggestConfig {
+	cfg := SuggestConfig{
+		RefreshInterval: 5 * time.Minute,
+	}
+
+	if v := os.Getenv("SUGGEST_REFRESH_SECONDS"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			cfg.RefreshInterval = time.Duration(secs) * time.Second
+		}
+	}
+
+	return cfg
 }
@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestLoadVerificationPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkout string
+		product  string
+		want     VerificationPolicy
+		wantErr  bool
+	}{
+		{"defaults", "", "", VerificationPolicy{Checkout: "none", ProductCreate: "none"}, false},
+		{"valid values", "all", "email", VerificationPolicy{Checkout: "all", ProductCreate: "email"}, false},
+		{"unknown checkout value", "emial", "", VerificationPolicy{}, true},
+		{"unknown product value", "", "Phone", VerificationPolicy{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VERIFY_REQUIRED_CHECKOUT", tt.checkout)
+			t.Setenv("VERIFY_REQUIRED_PRODUCT", tt.product)
+
+			got, err := LoadVerificationPolicy()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadVerificationPolicy() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadVerificationPolicy(): %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadVerificationPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
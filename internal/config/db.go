@@ -61,20 +61,87 @@ func NewDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// Products deleted before their slugs were freed on delete would block
+	// the unique slug index
+	if db.Migrator().HasTable(&domain.Produk{}) {
+		if err := db.Exec("UPDATE produk SET slug = CONCAT(slug, '~', id) WHERE deleted_at IS NOT NULL AND slug NOT LIKE '%~%'").Error; err != nil {
+			return nil, err
+		}
+	}
+
 	// Auto-migrate all domain models
 	if err := db.AutoMigrate(
+		&domain.Permission{},
+		&domain.Role{},
+		&domain.DataMigration{},
 		&domain.User{},
 		&domain.Toko{},
+		&domain.TokoMember{},
+		&domain.TokoInvitation{},
 		&domain.Alamat{},
 		&domain.Category{},
 		&domain.Produk{},
+		&domain.ProdukSlugHistory{},
+		&domain.ProdukHistory{},
+		&domain.Promotion{},
+		&domain.PromotionUsage{},
 		&domain.FotoProduk{},
+		&domain.ProdukOption{},
+		&domain.ProdukOptionValue{},
+		&domain.ProdukVariant{},
 		&domain.Trx{},
 		&domain.LogProduk{},
 		&domain.DetailTrx{},
+		&domain.UserOTP{},
+		&domain.RecoveryCode{},
+		&domain.LoginAttempt{},
+		&domain.SecurityEvent{},
+		&domain.AuditLog{},
+		&domain.APIKey{},
+		&domain.WebhookEndpoint{},
+		&domain.WebhookDelivery{},
+		&domain.OutboxEvent{},
+		&domain.OutboxAck{},
+		&domain.Notification{},
+		&domain.NotificationPreference{},
+		&domain.Conversation{},
+		&domain.ChatMessage{},
+		&domain.Review{},
+		&domain.ProductQuestion{},
+		&domain.ProductAnswer{},
+		&domain.QAVote{},
+		&domain.QAReport{},
+		&domain.ImportJob{},
+		&domain.ImportJobError{},
+		&domain.ProdukSearch{},
+		&domain.SearchTerm{},
 	); err != nil {
 		return nil, err
 	}
+	if err := migrateSlugScope(db); err != nil {
+		return nil, err
+	}
+	// superseded by idx_notification_recipient, which also keys on the toko
+	if db.Migrator().HasIndex(&domain.Notification{}, "idx_notification_event") {
+		if err := db.Migrator().DropIndex(&domain.Notification{}, "idx_notification_event"); err != nil {
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
+
+// migrateSlugScope adds a unique index on product slugs alone when
+// ProductSlugScope is "global", and drops it otherwise; the (id_toko, slug)
+// index of domain.Produk covers slugs unique per toko.
+func migrateSlugScope(db *gorm.DB) error {
+	migrator := db.Migrator()
+	exists := migrator.HasIndex(&domain.Produk{}, domain.ProdukGlobalSlugIndex)
+	switch global := ProductSlugScope() == "global"; {
+	case global && !exists:
+		return db.Exec("CREATE UNIQUE INDEX " + domain.ProdukGlobalSlugIndex + " ON produk (slug)").Error
+	case !global && exists:
+		return migrator.DropIndex(&domain.Produk{}, domain.ProdukGlobalSlugIndex)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// requestActor builds the audit actor from JWT locals and request metadata.
+// ok is false when the token carried no user id.
+func requestActor(c *fiber.Ctx) (usecase.Actor, bool) {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return usecase.Actor{}, false
+	}
+	actor := anonymousActor(c)
+	actor.UserID = userID
+	actor.Roles, _ = c.Locals("roles").([]string)
+	actor.APIKeyID, _ = c.Locals("api_key_id").(uint)
+	actor.TokoID, _ = c.Locals("api_toko_id").(uint)
+	return actor, true
+}
+
+// anonymousActor carries request metadata for calls made before login.
+func anonymousActor(c *fiber.Ctx) usecase.Actor {
+	requestID, _ := c.Locals("requestid").(string)
+	return usecase.Actor{
+		IP:        c.IP(),
+		RequestID: requestID,
+	}
+}
@@ -32,10 +32,15 @@ func (h *AlamatHandler) GetMyAlamat(c *fiber.Ctx) error {
 	}
 
 	filterJudul := c.Query("judul_alamat")
+	limit, _ := strconv.Atoi(c.Query("limit", "0"))
 
-	list, err := h.alamatUC.GetMyAlamat(userID, filterJudul)
+	result, err := h.alamatUC.GetMyAlamat(userID, filterJudul, limit, c.Query("cursor"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to GET data",
 			"errors":  []string{err.Error()},
@@ -43,8 +48,8 @@ func (h *AlamatHandler) GetMyAlamat(c *fiber.Ctx) error {
 		})
 	}
 
-	data := make([]fiber.Map, 0, len(list))
-	for _, a := range list {
+	data := make([]fiber.Map, 0, len(result.Data))
+	for _, a := range result.Data {
 		data = append(data, fiber.Map{
 			"id":            a.ID,
 			"judul_alamat":  a.JudulAlamat,
@@ -54,11 +59,26 @@ func (h *AlamatHandler) GetMyAlamat(c *fiber.Ctx) error {
 		})
 	}
 
+	// Without limit or cursor the whole list is returned as a plain array,
+	// as before pagination was added
+	if result.Limit == 0 {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  true,
+			"message": "Succeed to GET data",
+			"errors":  nil,
+			"data":    data,
+		})
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  true,
 		"message": "Succeed to GET data",
 		"errors":  nil,
-		"data":    data,
+		"data": fiber.Map{
+			"data":        data,
+			"limit":       result.Limit,
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
+		},
 	})
 }
 
@@ -121,7 +141,7 @@ func (h *AlamatHandler) GetAlamatByID(c *fiber.Ctx) error {
 
 // CreateAlamat handles POST /user/alamat.
 func (h *AlamatHandler) CreateAlamat(c *fiber.Ctx) error {
-	userID, ok := c.Locals("user_id").(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -141,7 +161,7 @@ func (h *AlamatHandler) CreateAlamat(c *fiber.Ctx) error {
 		})
 	}
 
-	alamat, err := h.alamatUC.Create(userID, in)
+	alamat, err := h.alamatUC.Create(actor, in)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
@@ -162,7 +182,7 @@ func (h *AlamatHandler) CreateAlamat(c *fiber.Ctx) error {
 
 // UpdateAlamat handles PUT /user/alamat/:id.
 func (h *AlamatHandler) UpdateAlamat(c *fiber.Ctx) error {
-	userID, ok := c.Locals("user_id").(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -193,7 +213,7 @@ func (h *AlamatHandler) UpdateAlamat(c *fiber.Ctx) error {
 		})
 	}
 
-	alamat, err := h.alamatUC.Update(userID, uint(id), in)
+	alamat, err := h.alamatUC.Update(actor, uint(id), in)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
@@ -229,7 +249,7 @@ func (h *AlamatHandler) UpdateAlamat(c *fiber.Ctx) error {
 
 // DeleteAlamat handles DELETE /user/alamat/:id.
 func (h *AlamatHandler) DeleteAlamat(c *fiber.Ctx) error {
-	userID, ok := c.Locals("user_id").(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -250,7 +270,7 @@ func (h *AlamatHandler) DeleteAlamat(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.alamatUC.Delete(userID, uint(id)); err != nil {
+	if err := h.alamatUC.Delete(actor, uint(id)); err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
 
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// APIKeyHandler handles HTTP requests for personal API keys.
+type APIKeyHandler struct {
+	apiKeyUC usecase.APIKeyUsecase
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeyUC usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyUC: apiKeyUC}
+}
+
+// GetMyAPIKeys handles GET /user/api-keys.
+func (h *APIKeyHandler) GetMyAPIKeys(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	keys, err := h.apiKeyUC.GetAll(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(keys))
+	for i := range keys {
+		data = append(data, buildAPIKeyResponse(&keys[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// CreateAPIKey handles POST /user/api-keys. The key itself is only
+// returned in this response.
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.CreateAPIKeyInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	created, err := h.apiKeyUC.Create(actor, in)
+	if err != nil {
+		statusCode := fiber.StatusBadRequest
+		if errors.Is(err, usecase.ErrTokoNotFound) {
+			statusCode = fiber.StatusNotFound
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := buildAPIKeyResponse(created.Key)
+	data["key"] = created.Token
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// RevokeAPIKey handles DELETE /user/api-keys/:id.
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.apiKeyUC.Revoke(actor, uint(id)); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+			statusCode = fiber.StatusNotFound
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to DELETE data",
+		"errors":  nil,
+		"data":    "",
+	})
+}
+
+// buildAPIKeyResponse maps an API key to JSON without its hash.
+func buildAPIKeyResponse(k *domain.APIKey) fiber.Map {
+	var toko interface{}
+	if k.Toko != nil {
+		toko = fiber.Map{
+			"id":        k.Toko.ID,
+			"nama_toko": k.Toko.NamaToko,
+		}
+	} else if k.TokoID != nil {
+		toko = fiber.Map{"id": *k.TokoID}
+	}
+
+	return fiber.Map{
+		"id":           k.ID,
+		"name":         k.Name,
+		"prefix":       k.Prefix,
+		"scopes":       k.ScopeList(),
+		"toko":         toko,
+		"last_used_at": k.LastUsedAt,
+		"expires_at":   k.ExpiresAt,
+		"revoked_at":   k.RevokedAt,
+		"created_at":   k.CreatedAt,
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// AuditHandler handles admin queries on the audit log.
+type AuditHandler struct {
+	auditUC usecase.AuditUsecase
+}
+
+// NewAuditHandler creates a new AuditHandler.
+func NewAuditHandler(auditUC usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{auditUC: auditUC}
+}
+
+// GetAuditLogs handles GET /admin/audit. Supported filters: id_user, action,
+// entity_type, entity_id, request_id, from and to (YYYY-MM-DD or RFC3339).
+func (h *AuditHandler) GetAuditLogs(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	userID, _ := strconv.Atoi(c.Query("id_user"))
+	entityID, _ := strconv.Atoi(c.Query("entity_id"))
+
+	filter := repository.AuditLogFilter{
+		UserID:     uint(userID),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   uint(entityID),
+		RequestID:  c.Query("request_id"),
+	}
+
+	for _, q := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(q.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseAuditTime(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{"invalid " + q.name},
+				"data":    nil,
+			})
+		}
+		*q.dst = &t
+	}
+
+	result, err := h.auditUC.GetAll(limit, page, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		list = append(list, buildAuditResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"page":  result.Page,
+			"limit": result.Limit,
+			"data":  list,
+		},
+	})
+}
+
+// parseAuditTime accepts a date (start of day, local time) or an RFC3339 timestamp.
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// buildAuditResponse maps an audit entry to JSON; stored JSON columns are
+// embedded as raw JSON rather than strings.
+func buildAuditResponse(e *domain.AuditLog) fiber.Map {
+	rawJSON := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return json.RawMessage(s)
+	}
+
+	return fiber.Map{
+		"id":          e.ID,
+		"id_user":     e.UserID,
+		"roles":       e.ActorRoles,
+		"action":      e.Action,
+		"entity_type": e.EntityType,
+		"entity_id":   e.EntityID,
+		"before":      rawJSON(e.BeforeData),
+		"after":       rawJSON(e.AfterData),
+		"diff":        rawJSON(e.Diff),
+		"ip":          e.IP,
+		"request_id":  e.RequestID,
+		"created_at":  e.CreatedAt,
+	}
+}
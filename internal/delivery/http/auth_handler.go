@@ -2,6 +2,8 @@ package http
 
 import (
 	"errors"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -30,7 +32,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.authUC.Register(in); err != nil {
+	if err := h.authUC.Register(anonymousActor(c), in); err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
 
@@ -69,14 +71,22 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
+	in.IP = c.IP()
+
 	res, err := h.authUC.Login(in)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
+		var lockedErr *usecase.LoginLockedError
 
 		if errors.Is(err, usecase.ErrInvalidCredentials) {
 			statusCode = fiber.StatusUnauthorized
 			errs = append(errs, "No Telp atau kata sandi salah")
+		} else if errors.As(err, &lockedErr) {
+			statusCode = fiber.StatusTooManyRequests
+			retryAfter := int(time.Until(lockedErr.Until).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+			errs = append(errs, err.Error())
 		} else {
 			errs = append(errs, err.Error())
 		}
@@ -89,21 +99,235 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// response: token + sebagian data user
+	// password ok, but the second factor is still needed
+	if res.TwoFactorRequired {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  true,
+			"message": "Succeed to POST data",
+			"errors":  nil,
+			"data": fiber.Map{
+				"two_factor_required": true,
+				"challenge_token":     res.ChallengeToken,
+			},
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildLoginResponse(res),
+	})
+}
+
+// LoginTwoFactor handles POST /auth/login/2fa.
+func (h *AuthHandler) LoginTwoFactor(c *fiber.Ctx) error {
+	var in usecase.TwoFactorLoginInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	in.IP = c.IP()
+
+	res, err := h.authUC.LoginTwoFactor(in)
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		var lockedErr *usecase.LoginLockedError
+
+		if errors.Is(err, usecase.ErrInvalidChallenge) || errors.Is(err, usecase.ErrInvalidTOTP) {
+			statusCode = fiber.StatusUnauthorized
+		} else if errors.As(err, &lockedErr) {
+			statusCode = fiber.StatusTooManyRequests
+			retryAfter := int(time.Until(lockedErr.Until).Seconds()) + 1
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildLoginResponse(res),
+	})
+}
+
+// buildLoginResponse maps a completed login into token + sebagian data user.
+func buildLoginResponse(res *usecase.LoginResult) fiber.Map {
+	data := fiber.Map{
+		"nama":          res.User.Nama,
+		"no_telp":       res.User.NoTelp,
+		"tanggal_Lahir": res.User.TanggalLahir,
+		"tentang":       res.User.Tentang,
+		"pekerjaan":     res.User.Pekerjaan,
+		"email":         res.User.Email,
+		"id_provinsi":   res.User.IDProvinsi,
+		"id_kota":       res.User.IDKota,
+		"token":         res.Token,
+	}
+	if res.EnrollmentRequired {
+		data["two_factor_enrollment_required"] = true
+	}
+	return data
+}
+
+// ForgotPassword handles POST /auth/forgot-password.
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var in usecase.ForgotPasswordInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	in.IP = c.IP()
+
+	if err := h.authUC.ForgotPassword(in); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrOTPRateLimited) {
+			statusCode = fiber.StatusTooManyRequests
+		} else if errors.Is(err, usecase.ErrInvalidChannel) {
+			statusCode = fiber.StatusBadRequest
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  true,
 		"message": "Succeed to POST data",
 		"errors":  nil,
-		"data": fiber.Map{
-			"nama":          res.User.Nama,
-			"no_telp":       res.User.NoTelp,
-			"tanggal_Lahir": res.User.TanggalLahir,
-			"tentang":       res.User.Tentang,
-			"pekerjaan":     res.User.Pekerjaan,
-			"email":         res.User.Email,
-			"id_provinsi":   res.User.IDProvinsi,
-			"id_kota":       res.User.IDKota,
-			"token":         res.Token,
-		},
+		"data":    "Jika akun terdaftar, kode OTP telah dikirim",
 	})
-}
\ No newline at end of file
+}
+
+// ResetPassword handles POST /auth/reset-password.
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var in usecase.ResetPasswordInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.authUC.ResetPassword(anonymousActor(c), in); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidOTP) {
+			statusCode = fiber.StatusBadRequest
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    "Reset Password Succeed",
+	})
+}
+
+// VerifyContact handles POST /auth/verify and the emailed GET /auth/verify link.
+func (h *AuthHandler) VerifyContact(c *fiber.Ctx) error {
+	var in usecase.VerifyContactInput
+	var err error
+	if c.Method() == fiber.MethodGet {
+		err = c.QueryParser(&in)
+	} else {
+		err = c.BodyParser(&in)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.authUC.VerifyContact(anonymousActor(c), in); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidOTP) || errors.Is(err, usecase.ErrInvalidChannel) {
+			statusCode = fiber.StatusBadRequest
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    "Verification Succeed",
+	})
+}
+
+// ResendVerification handles POST /auth/verify/resend.
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var in usecase.ResendVerificationInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	in.IP = c.IP()
+
+	if err := h.authUC.ResendVerification(in); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrOTPRateLimited) {
+			statusCode = fiber.StatusTooManyRequests
+		} else if errors.Is(err, usecase.ErrInvalidChannel) || errors.Is(err, usecase.ErrAlreadyVerified) {
+			statusCode = fiber.StatusBadRequest
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    "Jika akun terdaftar, kode verifikasi telah dikirim",
+	})
+}
@@ -78,6 +78,16 @@ func (h *CategoryHandler) GetByID(c *fiber.Ctx) error {
 }
 
 func (h *CategoryHandler) Create(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
 	var req categoryRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -88,7 +98,7 @@ func (h *CategoryHandler) Create(c *fiber.Ctx) error {
 		})
 	}
 
-	category, err := h.uc.Create(req.Nama)
+	category, err := h.uc.Create(actor, req.Nama)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
@@ -107,6 +117,16 @@ func (h *CategoryHandler) Create(c *fiber.Ctx) error {
 }
 
 func (h *CategoryHandler) Update(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
 	idParam := c.Params("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -128,7 +148,7 @@ func (h *CategoryHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
-	category, err := h.uc.Update(uint(id), req.Nama)
+	category, err := h.uc.Update(actor, uint(id), req.Nama)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
@@ -155,6 +175,16 @@ func (h *CategoryHandler) Update(c *fiber.Ctx) error {
 }
 
 func (h *CategoryHandler) Delete(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
 	idParam := c.Params("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -166,7 +196,7 @@ func (h *CategoryHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.uc.Delete(uint(id)); err != nil {
+	if err := h.uc.Delete(actor, uint(id)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to DELETE data",
@@ -0,0 +1,534 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/middleware"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// ChatHandler handles HTTP and WebSocket requests for buyer–toko chat.
+type ChatHandler struct {
+	chatUC       usecase.ChatUsecase
+	pollInterval time.Duration
+}
+
+// NewChatHandler creates a new ChatHandler. Live connections check for
+// new messages every pollInterval besides being woken up on send.
+func NewChatHandler(chatUC usecase.ChatUsecase, pollInterval time.Duration) *ChatHandler {
+	return &ChatHandler{chatUC: chatUC, pollInterval: pollInterval}
+}
+
+// StartConversation handles POST /chat/conversations. It accepts JSON or a
+// multipart form with optional "images" attachments for the first message.
+func (h *ChatHandler) StartConversation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.StartConversationInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	attachments, err := saveUploadedFiles(c, "images", imageExtensions)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	view, message, err := h.chatUC.StartConversation(userID, in, attachments)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := buildConversationResponse(view)
+	if message != nil {
+		data["message"] = buildChatMessageResponse(message)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// GetConversations handles GET /chat/conversations?toko_id=&page=&limit=.
+// Without toko_id it lists the user's own conversations as a buyer.
+func (h *ChatHandler) GetConversations(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	views, err := h.chatUC.GetConversations(userID, uint(tokoID), limit, page)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(views))
+	for i := range views {
+		list = append(list, buildConversationResponse(&views[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"data":  list,
+		},
+	})
+}
+
+// GetConversation handles GET /chat/conversations/:id.
+func (h *ChatHandler) GetConversation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	view, err := h.chatUC.GetConversation(userID, uint(id))
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    buildConversationResponse(view),
+	})
+}
+
+// GetMessages handles GET /chat/conversations/:id/messages?before_id=&limit=,
+// returning history newest first. Pass the smallest ID seen as before_id
+// to load older messages.
+func (h *ChatHandler) GetMessages(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	beforeID, _ := strconv.Atoi(c.Query("before_id", "0"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	if beforeID < 0 {
+		beforeID = 0
+	}
+
+	messages, err := h.chatUC.GetMessages(userID, uint(id), uint(beforeID), limit)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(messages))
+	for i := range messages {
+		list = append(list, buildChatMessageResponse(&messages[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    list,
+	})
+}
+
+// SendMessage handles POST /chat/conversations/:id/messages with a "body"
+// field and optional "images" attachments.
+func (h *ChatHandler) SendMessage(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Body string `json:"body" form:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	attachments, err := saveUploadedFiles(c, "images", imageExtensions)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	message, err := h.chatUC.SendMessage(userID, uint(id), req.Body, attachments)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildChatMessageResponse(message),
+	})
+}
+
+// MarkRead handles PUT /chat/conversations/:id/read with an optional
+// message_id; without it every message from the other side is marked read.
+func (h *ChatHandler) MarkRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		MessageID uint `json:"message_id"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to PUT data",
+				"errors":  []string{"invalid request body"},
+				"data":    nil,
+			})
+		}
+	}
+
+	receipt, err := h.chatUC.MarkRead(userID, uint(id), req.MessageID)
+	if err != nil {
+		return c.Status(chatErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    receipt,
+	})
+}
+
+// RequireWebSocket rejects plain HTTP requests to the live chat endpoint.
+func (h *ChatHandler) RequireWebSocket(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"websocket upgrade required"},
+			"data":    nil,
+		})
+	}
+	return c.Next()
+}
+
+// chatClientFrame is a frame sent by a live chat client: "message" sends
+// Body to the conversation, "read" marks it read up to MessageID.
+type chatClientFrame struct {
+	Type           string `json:"type"`
+	ConversationID uint   `json:"conversation_id"`
+	Body           string `json:"body"`
+	MessageID      uint   `json:"message_id"`
+}
+
+// chatServerFrame is a frame sent to a live chat client: "message",
+// "read" (a receipt from the other side) or "error".
+type chatServerFrame struct {
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// Live handles GET /chat/ws. New messages in any of the user's
+// conversations are pushed as they arrive; ?after_id= resumes after the
+// last message the client has seen. The login session is re-checked on
+// every poll and the connection is closed once it has expired or been
+// revoked.
+func (h *ChatHandler) Live(conn *websocket.Conn) {
+	userID, ok := conn.Locals("user_id").(uint)
+	valid, validOK := conn.Locals("session_valid").(middleware.SessionValidator)
+	if !ok || !validOK {
+		return
+	}
+
+	var writeMu sync.Mutex
+	write := func(frame chatServerFrame) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(frame)
+	}
+
+	var lastID uint
+	if v, err := strconv.Atoi(conn.Query("after_id")); err == nil && v > 0 {
+		lastID = uint(v)
+	} else {
+		latest, err := h.chatUC.LatestMessageID(userID)
+		if err != nil {
+			_ = write(chatServerFrame{Type: "error", Error: err.Error()})
+			return
+		}
+		lastID = latest
+	}
+
+	signals, stop := h.chatUC.Listen(userID)
+	defer stop()
+
+	// Frames from the client are handled on their own goroutine; replies to
+	// them arrive through the same signals as messages from others.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var frame chatClientFrame
+			if err := conn.ReadJSON(&frame); err != nil {
+				return
+			}
+
+			var err error
+			switch frame.Type {
+			case "message":
+				_, err = h.chatUC.SendMessage(userID, frame.ConversationID, frame.Body, nil)
+			case "read":
+				_, err = h.chatUC.MarkRead(userID, frame.ConversationID, frame.MessageID)
+			default:
+				err = errors.New("unknown frame type")
+			}
+			if err != nil {
+				if werr := write(chatServerFrame{Type: "error", Error: err.Error()}); werr != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case sig := <-signals:
+			if sig.Receipt != nil {
+				if err := write(chatServerFrame{Type: "read", Data: sig.Receipt}); err != nil {
+					return
+				}
+				continue
+			}
+		case <-ticker.C:
+			if valid() != nil {
+				_ = write(chatServerFrame{Type: "error", Error: middleware.ErrSessionEnded.Error()})
+				return
+			}
+		}
+
+		messages, err := h.chatUC.GetNewMessages(userID, lastID)
+		if err != nil {
+			_ = write(chatServerFrame{Type: "error", Error: err.Error()})
+			return
+		}
+		for i := range messages {
+			if err := write(chatServerFrame{Type: "message", Data: buildChatMessageResponse(&messages[i])}); err != nil {
+				return
+			}
+			lastID = messages[i].ID
+		}
+	}
+}
+
+// chatErrorStatus maps chat usecase errors to HTTP status codes.
+func chatErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrConversationNotFound),
+		errors.Is(err, usecase.ErrTokoNotFound),
+		errors.Is(err, usecase.ErrProductNotFound),
+		errors.Is(err, usecase.ErrTrxNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrChatOwnToko),
+		errors.Is(err, usecase.ErrInvalidChatMessage),
+		errors.Is(err, errInvalidUpload):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildConversationResponse maps a conversation for one participant to JSON.
+func buildConversationResponse(v *usecase.ConversationView) fiber.Map {
+	conv := v.Conversation
+	return fiber.Map{
+		"id":   conv.ID,
+		"side": v.Side,
+		"user": fiber.Map{
+			"id":   conv.User.ID,
+			"nama": conv.User.Nama,
+		},
+		"toko": fiber.Map{
+			"id":        conv.Toko.ID,
+			"nama_toko": conv.Toko.NamaToko,
+			"url_foto":  conv.Toko.UrlFoto,
+		},
+		"product_id":      conv.ProdukID,
+		"trx_id":          conv.TrxID,
+		"last_message":    conv.LastMessage,
+		"last_message_at": conv.LastMessageAt,
+		"unread_count":    v.UnreadCount,
+		"created_at":      conv.CreatedAt,
+	}
+}
+
+// buildChatMessageResponse maps a chat message to JSON.
+func buildChatMessageResponse(m *domain.ChatMessage) fiber.Map {
+	attachments := make([]fiber.Map, 0)
+	for _, filename := range m.AttachmentList() {
+		attachments = append(attachments, fiber.Map{"url": filename})
+	}
+	return fiber.Map{
+		"id":              m.ID,
+		"conversation_id": m.ConversationID,
+		"sender_id":       m.SenderID,
+		"sender_side":     m.SenderSide,
+		"body":            m.Body,
+		"attachments":     attachments,
+		"read":            m.ReadAt != nil,
+		"read_at":         m.ReadAt,
+		"created_at":      m.CreatedAt,
+	}
+}
@@ -0,0 +1,359 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/middleware"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// NotificationHandler handles HTTP requests for the notification center.
+type NotificationHandler struct {
+	notificationUC usecase.NotificationUsecase
+	pollInterval   time.Duration
+}
+
+// NewNotificationHandler creates a new NotificationHandler. Open streams
+// check for new notifications every pollInterval.
+func NewNotificationHandler(notificationUC usecase.NotificationUsecase, pollInterval time.Duration) *NotificationHandler {
+	return &NotificationHandler{notificationUC: notificationUC, pollInterval: pollInterval}
+}
+
+// GetNotifications handles GET /notifications?page=&limit=&unread=true.
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	unreadOnly := c.QueryBool("unread", false)
+
+	result, err := h.notificationUC.GetAll(userID, limit, page, unreadOnly)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		list = append(list, buildNotificationResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"page":         result.Page,
+			"limit":        result.Limit,
+			"unread_count": result.UnreadCount,
+			"data":         list,
+		},
+	})
+}
+
+// GetUnreadCount handles GET /notifications/unread-count.
+func (h *NotificationHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	count, err := h.notificationUC.CountUnread(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    fiber.Map{"unread_count": count},
+	})
+}
+
+// MarkRead handles PUT /notifications/:id/read.
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.notificationUC.MarkRead(userID, uint(id)); err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrNotificationNotFound) {
+			statusCode = fiber.StatusNotFound
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    "",
+	})
+}
+
+// MarkAllRead handles PUT /notifications/read-all.
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	updated, err := h.notificationUC.MarkAllRead(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    fiber.Map{"updated": updated},
+	})
+}
+
+// GetPreferences handles GET /notifications/preferences.
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	settings, err := h.notificationUC.GetPreferences(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    settings,
+	})
+}
+
+// UpdatePreferences handles PUT /notifications/preferences with a list of
+// {type, channel, enabled}; types and channels not listed keep their value.
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var settings []usecase.NotificationSetting
+	if err := c.BodyParser(&settings); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	updated, err := h.notificationUC.UpdatePreferences(userID, settings)
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidNotificationSetting) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    updated,
+	})
+}
+
+// Stream handles GET /notifications/stream as Server-Sent Events. Each new
+// notification is sent as a `notification` event followed by an `unread`
+// event with the unread count. Reconnecting clients resume after the
+// Last-Event-ID header; new clients only get notifications from now on.
+// The login session is re-checked on every poll; once it has expired or
+// been revoked an `expired` event is sent and the stream is closed.
+func (h *NotificationHandler) Stream(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	valid, validOK := c.Locals("session_valid").(middleware.SessionValidator)
+	if !ok || !validOK {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var lastID uint
+	if v, err := strconv.Atoi(c.Get("Last-Event-ID")); err == nil && v > 0 {
+		lastID = uint(v)
+	} else {
+		latest, err := h.notificationUC.GetAll(userID, 1, 1, false)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+		if len(latest.Data) > 0 {
+			lastID = latest.Data[0].ID
+		}
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	wake, stop := h.notificationUC.Listen(userID)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer stop()
+		ticker := time.NewTicker(h.pollInterval)
+		defer ticker.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", h.pollInterval.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case <-wake:
+			case <-ticker.C:
+				if valid() != nil {
+					fmt.Fprint(w, "event: expired\ndata: {}\n\n")
+					_ = w.Flush()
+					return
+				}
+			}
+
+			notifications, err := h.notificationUC.GetSince(userID, lastID)
+			if err != nil {
+				return
+			}
+			for i := range notifications {
+				raw, _ := json.Marshal(buildNotificationResponse(&notifications[i]))
+				fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", notifications[i].ID, raw)
+				lastID = notifications[i].ID
+			}
+			if len(notifications) > 0 {
+				if count, err := h.notificationUC.CountUnread(userID); err == nil {
+					fmt.Fprintf(w, "event: unread\ndata: {\"unread_count\":%d}\n\n", count)
+				}
+			} else {
+				// comment line keeps proxies from closing an idle stream
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			// a failed flush means the client has gone away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// buildNotificationResponse maps a notification to JSON.
+func buildNotificationResponse(n *domain.Notification) fiber.Map {
+	var data interface{}
+	if n.Data != "" {
+		data = json.RawMessage(n.Data)
+	}
+	return fiber.Map{
+		"id":         n.ID,
+		"type":       n.Type,
+		"title":      n.Title,
+		"body":       n.Body,
+		"data":       data,
+		"read":       n.ReadAt != nil,
+		"read_at":    n.ReadAt,
+		"created_at": n.CreatedAt,
+	}
+}
@@ -2,10 +2,10 @@ package http
 
 import (
 	"errors"
-	"fmt"
-	"os"
-	"path/filepath"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,17 +24,25 @@ func NewProductHandler(productUC usecase.ProductUsecase) *ProductHandler {
 	return &ProductHandler{productUC: productUC}
 }
 
-// GetAllProduct handles GET /product.
+// GetAllProduct handles GET /product. q runs a full-text search ranked by
+// relevance, with the matching words of each product highlighted.
+// category_id takes a comma-separated list; in_stock=true hides sold-out
+// products and kota_id keeps tokos of one city. The response carries the
+// total count and category and price facets.
 func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 
 	filter := usecase.ProductFilter{}
 	filter.NamaProduk = c.Query("nama_produk")
+	filter.Query = c.Query("q")
+	filter.Cursor = c.Query("cursor")
 
 	if v := c.Query("category_id"); v != "" {
-		if id, err := strconv.Atoi(v); err == nil {
-			filter.CategoryID = uint(id)
+		for _, part := range strings.Split(v, ",") {
+			if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id > 0 {
+				filter.CategoryIDs = append(filter.CategoryIDs, uint(id))
+			}
 		}
 	}
 	if v := c.Query("toko_id"); v != "" {
@@ -52,10 +60,27 @@ func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 			filter.MaxHarga = n
 		}
 	}
+	filter.InStock = c.QueryBool("in_stock")
+	filter.KotaID = c.Query("kota_id")
+	if v := c.Query("sort"); v != "" {
+		if !slices.Contains(usecase.ProductSorts, v) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{"invalid sort"},
+				"data":    nil,
+			})
+		}
+		filter.Sort = v
+	}
 
 	result, err := h.productUC.GetAll(limit, page, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to GET data",
 			"errors":  []string{err.Error()},
@@ -65,7 +90,11 @@ func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 
 	products := make([]fiber.Map, 0, len(result.Data))
 	for i := range result.Data {
-		products = append(products, buildProductResponse(&result.Data[i]))
+		product := buildProductResponse(&result.Data[i])
+		if highlight, ok := result.Highlights[result.Data[i].ID]; ok {
+			product["highlight"] = highlight
+		}
+		products = append(products, product)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -73,9 +102,15 @@ func (h *ProductHandler) GetAllProduct(c *fiber.Ctx) error {
 		"message": "Succeed to GET data",
 		"errors":  nil,
 		"data": fiber.Map{
-			"data":  products,
-			"page":  result.Page,
-			"limit": result.Limit,
+			"data":              products,
+			"page":              result.Page,
+			"limit":             result.Limit,
+			"total":             result.Total,
+			"total_approximate": result.TotalApproximate,
+			"total_pages":       totalPages(result.Total, result.Limit),
+			"facets":            buildProductFacetsResponse(result.Facets),
+			"next_cursor":       result.NextCursor,
+			"prev_cursor":       result.PrevCursor,
 		},
 	})
 }
@@ -119,10 +154,74 @@ func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 	})
 }
 
+// GetProductBySlug handles GET /product/slug/:slug. A slug from before a
+// rename redirects to the current one; a slug several tokos use gives 409,
+// and GET /toko/:id/product/:slug must be used instead.
+func (h *ProductHandler) GetProductBySlug(c *fiber.Ctx) error {
+	return h.getProductBySlug(c, 0, func(slug string) string {
+		return "/product/slug/" + slug
+	})
+}
+
+// GetTokoProductBySlug handles GET /toko/:id/product/:slug. A slug from
+// before a rename redirects to the current one.
+func (h *ProductHandler) GetTokoProductBySlug(c *fiber.Ctx) error {
+	tokoID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || tokoID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	return h.getProductBySlug(c, uint(tokoID), func(slug string) string {
+		return "/toko/" + strconv.Itoa(tokoID) + "/product/" + slug
+	})
+}
+
+func (h *ProductHandler) getProductBySlug(c *fiber.Ctx, tokoID uint, location func(slug string) string) error {
+	slug := c.Params("slug")
+	product, err := h.productUC.GetBySlug(tokoID, slug)
+	if err != nil {
+		if errors.Is(err, usecase.ErrProductNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{"No Data Product"},
+				"data":    nil,
+			})
+		}
+		if errors.Is(err, usecase.ErrProductSlugAmbiguous) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	if product.Slug != slug {
+		return c.Redirect(location(product.Slug), fiber.StatusMovedPermanently)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    buildProductResponse(product),
+	})
+}
+
 // CreateProduct handles POST /product.
 func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
-	userID, ok := userIDVal.(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -185,6 +284,34 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	// toko_id is optional for staff of several tokos; default is the user's own toko
+	var tokoID int
+	if tokoIDStr := c.FormValue("toko_id"); tokoIDStr != "" {
+		if tokoID, err = strconv.Atoi(tokoIDStr); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{"invalid toko_id"},
+				"data":    nil,
+			})
+		}
+	}
+
+	// status=draft keeps the product unpublished; publish_at schedules it
+	var publishAt *time.Time
+	if publishAtStr := c.FormValue("publish_at"); publishAtStr != "" {
+		t, err := time.Parse(time.RFC3339, publishAtStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{"invalid publish_at"},
+				"data":    nil,
+			})
+		}
+		publishAt = &t
+	}
+
 	photoFilenames, err := saveProductPhotos(c)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -196,16 +323,35 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	}
 
 	in := usecase.CreateProductInput{
+		TokoID:        uint(tokoID),
 		NamaProduk:    namaProduk,
 		CategoryID:    uint(categoryIDInt),
 		HargaReseller: hargaReseller,
 		HargaKonsumen: hargaKonsumen,
 		Stok:          stok,
 		Deskripsi:     deskripsi,
+		Status:        c.FormValue("status"),
+		PublishAt:     publishAt,
 	}
 
-	product, err := h.productUC.Create(userID, in, photoFilenames)
+	product, err := h.productUC.Create(actor, in, photoFilenames)
 	if err != nil {
+		if errors.Is(err, usecase.ErrAccountNotVerified) || errors.Is(err, usecase.ErrTokoAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+		if errors.Is(err, usecase.ErrInvalidProductStatus) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to POST data",
@@ -224,8 +370,7 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 
 // UpdateProduct handles PUT /product/:id.
 func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
-	userID, ok := userIDVal.(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -286,7 +431,7 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	product, err := h.productUC.Update(userID, uint(id), in, photoFilenames)
+	product, err := h.productUC.Update(actor, uint(id), in, photoFilenames)
 	if err != nil {
 		if errors.Is(err, usecase.ErrProductNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -296,6 +441,14 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 				"data":    nil,
 			})
 		}
+		if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to PUT data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to PUT data",
@@ -316,8 +469,7 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 
 // DeleteProduct handles DELETE /product/:id.
 func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
-	userID, ok := userIDVal.(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -338,7 +490,7 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.productUC.Delete(userID, uint(id)); err != nil {
+	if err := h.productUC.Delete(actor, uint(id)); err != nil {
 		if errors.Is(err, usecase.ErrProductNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"status":  false,
@@ -347,6 +499,14 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 				"data":    nil,
 			})
 		}
+		if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to DELETE data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to DELETE data",
@@ -363,38 +523,486 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	})
 }
 
-// saveProductPhotos saves uploaded files under the "photos" field
-// and returns their stored filenames.
-func saveProductPhotos(c *fiber.Ctx) ([]string, error) {
-	form, err := c.MultipartForm()
+// UpdateProductStock handles PUT /product/:id/stock.
+func (h *ProductHandler) UpdateProductStock(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Stok *int `json:"stok"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Stok == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"stok wajib diisi"},
+			"data":    nil,
+		})
+	}
+
+	product, err := h.productUC.UpdateStock(actor, uint(id), *req.Stok)
+	if err != nil {
+		statusCode := fiber.StatusBadRequest
+		if errors.Is(err, usecase.ErrProductNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":   product.ID,
+			"stok": product.Stok,
+		},
+	})
+}
+
+// ArchiveProduct handles PUT /product/:id/archive, taking the product off
+// sale.
+func (h *ProductHandler) ArchiveProduct(c *fiber.Ctx) error {
+	return h.setArchived(c, h.productUC.Archive)
+}
+
+// UnarchiveProduct handles PUT /product/:id/unarchive, putting the product
+// back on sale.
+func (h *ProductHandler) UnarchiveProduct(c *fiber.Ctx) error {
+	return h.setArchived(c, h.productUC.Unarchive)
+}
+
+func (h *ProductHandler) setArchived(c *fiber.Ctx, set func(actor usecase.Actor, productID uint) (*domain.Produk, error)) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	product, err := set(actor, uint(id))
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrProductNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildProductResponse(product),
+	})
+}
+
+// PublishProduct handles PUT /product/:id/publish. The product goes live,
+// or waits for moderation when it is required; publish_at in the body
+// schedules it for later.
+func (h *ProductHandler) PublishProduct(c *fiber.Ctx) error {
+	var req struct {
+		PublishAt *time.Time `json:"publish_at"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to PUT data",
+				"errors":  []string{"invalid publish_at"},
+				"data":    nil,
+			})
+		}
+	}
+	return h.changeStatus(c, func(actor usecase.Actor, productID uint) (*domain.Produk, error) {
+		return h.productUC.Publish(actor, productID, req.PublishAt)
+	})
+}
+
+// UnpublishProduct handles PUT /product/:id/unpublish, taking the product
+// back to draft.
+func (h *ProductHandler) UnpublishProduct(c *fiber.Ctx) error {
+	return h.changeStatus(c, h.productUC.Unpublish)
+}
+
+// ApproveProduct handles PUT /admin/products/:id/approve, publishing a
+// product waiting for moderation.
+func (h *ProductHandler) ApproveProduct(c *fiber.Ctx) error {
+	return h.changeStatus(c, h.productUC.Approve)
+}
+
+// RejectProduct handles PUT /admin/products/:id/reject with the reason in
+// the body.
+func (h *ProductHandler) RejectProduct(c *fiber.Ctx) error {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{usecase.ErrRejectionReasonRequired.Error()},
+			"data":    nil,
+		})
+	}
+	return h.changeStatus(c, func(actor usecase.Actor, productID uint) (*domain.Produk, error) {
+		return h.productUC.Reject(actor, productID, req.Reason)
+	})
+}
+
+func (h *ProductHandler) changeStatus(c *fiber.Ctx, change func(actor usecase.Actor, productID uint) (*domain.Produk, error)) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	product, err := change(actor, uint(id))
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrProductNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
+		} else if errors.Is(err, usecase.ErrProductStatusConflict) {
+			statusCode = fiber.StatusConflict
+		} else if errors.Is(err, usecase.ErrRejectionReasonRequired) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildProductResponse(product),
+	})
+}
+
+// GetModerationQueue handles GET /admin/products/moderation, listing the
+// products waiting for approval, oldest first.
+func (h *ProductHandler) GetModerationQueue(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	result, err := h.productUC.GetModerationQueue(limit, page, c.Query("cursor"))
 	if err != nil {
-		// Not a multipart request or no files - treat as no photos.
-		return []string{}, nil
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return h.productListResponse(c, result)
+}
+
+// SetProductVariants handles PUT /product/:id/variants, replacing the
+// product's options and variants with the ones in the body.
+func (h *ProductHandler) SetProductVariants(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
 	}
 
-	files := form.File["photos"]
-	if len(files) == 0 {
-		return []string{}, nil
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
 	}
 
-	if err := os.MkdirAll("uploads", os.ModePerm); err != nil {
-		return nil, err
+	var in usecase.SetVariantsInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
 	}
 
-	var filenames []string
-	for _, fh := range files {
-		if fh == nil {
-			continue
+	product, err := h.productUC.SetVariants(actor, uint(id), in)
+	if err != nil {
+		statusCode := fiber.StatusBadRequest
+		if errors.Is(err, usecase.ErrProductNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
 		}
-		filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(fh.Filename))
-		fullpath := filepath.Join("uploads", filename)
-		if err := c.SaveFile(fh, fullpath); err != nil {
-			return nil, err
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildProductResponse(product),
+	})
+}
+
+// UpdateVariantStock handles PUT /product/variants/:id/stock.
+func (h *ProductHandler) UpdateVariantStock(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Stok *int `json:"stok"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Stok == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"stok wajib diisi"},
+			"data":    nil,
+		})
+	}
+
+	product, err := h.productUC.UpdateVariantStock(actor, uint(id), *req.Stok)
+	if err != nil {
+		statusCode := fiber.StatusBadRequest
+		if errors.Is(err, usecase.ErrVariantNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
 		}
-		filenames = append(filenames, filename)
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
 	}
 
-	return filenames, nil
+	variant := fiber.Map{"id": uint(id), "stok": *req.Stok}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":      product.ID,
+			"stok":    product.Stok,
+			"variant": variant,
+		},
+	})
+}
+
+// GetTokoProducts handles GET /toko/products?toko_id= listing the products
+// of a toko the user (or API key) works for, in every status unless status
+// picks one.
+func (h *ProductHandler) GetTokoProducts(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+
+	status := c.Query("status")
+	if status != "" && !slices.Contains(domain.ProductStatuses, status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid status"},
+			"data":    nil,
+		})
+	}
+
+	result, err := h.productUC.GetTokoProducts(actor, uint(tokoID), limit, page, c.Query("cursor"), status)
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		} else if errors.Is(err, usecase.ErrTokoNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return h.productListResponse(c, result)
+}
+
+// productListResponse writes a page of products with its paging details.
+func (h *ProductHandler) productListResponse(c *fiber.Ctx, result *usecase.ProductListResult) error {
+	products := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		products = append(products, buildProductResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"data":        products,
+			"page":        result.Page,
+			"limit":       result.Limit,
+			"total":       result.Total,
+			"total_pages": totalPages(result.Total, result.Limit),
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
+		},
+	})
+}
+
+// totalPages is how many pages of limit items total items fill.
+func totalPages(total int64, limit int) int64 {
+	if limit <= 0 {
+		return 0
+	}
+	return (total + int64(limit) - 1) / int64(limit)
+}
+
+// buildProductFacetsResponse maps product facet counts into JSON.
+func buildProductFacetsResponse(f *usecase.ProductFacets) fiber.Map {
+	categories := make([]fiber.Map, 0, len(f.Categories))
+	for _, c := range f.Categories {
+		categories = append(categories, fiber.Map{
+			"category_id":   c.CategoryID,
+			"nama_category": c.Nama,
+			"count":         c.Count,
+		})
+	}
+	// Bucket bounds are given as min_harga/max_harga filter values, both inclusive
+	prices := make([]fiber.Map, 0, len(f.PriceBuckets))
+	for _, b := range f.PriceBuckets {
+		bucket := fiber.Map{"min_harga": b.Min, "max_harga": nil, "count": b.Count}
+		if b.Max > 0 {
+			bucket["max_harga"] = b.Max - 1
+		}
+		prices = append(prices, bucket)
+	}
+	return fiber.Map{
+		"categories": categories,
+		"price":      prices,
+	}
+}
+
+// saveProductPhotos saves uploaded files under the "photos" field
+// and returns their stored filenames.
+func saveProductPhotos(c *fiber.Ctx) ([]string, error) {
+	return saveUploadedFiles(c, "photos", nil)
 }
 
 // buildProductResponse maps domain.Produk into the JSON shape used by Postman.
@@ -411,14 +1019,66 @@ func buildProductResponse(p *domain.Produk) fiber.Map {
 		})
 	}
 
+	options := make([]fiber.Map, 0, len(p.Options))
+	for _, o := range p.Options {
+		values := make([]string, 0, len(o.Values))
+		for _, v := range o.Values {
+			values = append(values, v.Nilai)
+		}
+		options = append(options, fiber.Map{
+			"id":     o.ID,
+			"nama":   o.Nama,
+			"values": values,
+		})
+	}
+
+	variants := make([]fiber.Map, 0, len(p.Variants))
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		variantReseller, variantKonsumen := v.Prices(p)
+		vReseller, _ := strconv.Atoi(variantReseller)
+		vKonsumen, _ := strconv.Atoi(variantKonsumen)
+		var vPromo interface{}
+		if p.Promotion != nil {
+			vPromo = p.Promotion.Price(vKonsumen)
+		}
+		variants = append(variants, fiber.Map{
+			"id":             v.ID,
+			"sku":            v.SKU,
+			"attributes":     v.AttributeMap(),
+			"harga_reseler":  vReseller,
+			"harga_konsumen": vKonsumen,
+			"harga_promo":    vPromo,
+			"stok":           v.Stok,
+			"foto":           v.FotoURL,
+		})
+	}
+
+	// harga_konsumen stays the normal price, shown struck through next to
+	// harga_promo while a promotion runs
+	var hargaPromo interface{}
+	var promo fiber.Map
+	if p.Promotion != nil {
+		hargaPromo = p.Promotion.Price(hargaKonsumen)
+		promo = buildPromotionResponse(p.Promotion)
+	}
+
 	return fiber.Map{
-		"id":             p.ID,
-		"nama_produk":    p.NamaProduk,
-		"slug":           p.Slug,
-		"harga_reseler":  hargaReseller,
-		"harga_konsumen": hargaKonsumen,
-		"stok":           p.Stok,
-		"deskripsi":      p.Deskripsi,
+		"id":               p.ID,
+		"nama_produk":      p.NamaProduk,
+		"slug":             p.Slug,
+		"harga_reseler":    hargaReseller,
+		"harga_konsumen":   hargaKonsumen,
+		"harga_promo":      hargaPromo,
+		"promo":            promo,
+		"stok":             p.Stok,
+		"deskripsi":        p.Deskripsi,
+		"rating_avg":       math.Round(p.RatingAvg*10) / 10,
+		"rating_count":     p.RatingCount,
+		"archived_at":      p.ArchivedAt,
+		"status":           p.Status,
+		"publish_at":       p.PublishAt,
+		"rejection_reason": p.RejectionReason,
 		"toko": fiber.Map{
 			"id":        p.Toko.ID,
 			"nama_toko": p.Toko.NamaToko,
@@ -428,6 +1088,8 @@ func buildProductResponse(p *domain.Produk) fiber.Map {
 			"id":            p.Category.ID,
 			"nama_category": p.Category.Nama,
 		},
-		"photos": photos,
+		"photos":   photos,
+		"options":  options,
+		"variants": variants,
 	}
 }
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// GetProductHistory handles GET /product/:id/history for members of the
+// product's toko and GET /admin/products/:id/history for moderators,
+// listing the versions of the product's name, prices and stock, newest
+// first.
+func (h *ProductHandler) GetProductHistory(moderator bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.getProductHistory(c, moderator)
+	}
+}
+
+// GetProductPriceSeries handles GET /product/:id/history/daily?from=&to=
+// and its /admin/products counterpart, with dates as YYYY-MM-DD, giving
+// the product's prices and stock at the end of each day for charts. The
+// range defaults to the last 30 days.
+func (h *ProductHandler) GetProductPriceSeries(moderator bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.getProductPriceSeries(c, moderator)
+	}
+}
+
+func (h *ProductHandler) getProductHistory(c *fiber.Ctx, moderator bool) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	result, err := h.productUC.GetHistory(actor, uint(id), moderator, limit, c.Query("cursor"))
+	if err != nil {
+		return productHistoryError(c, err)
+	}
+
+	versions := make([]fiber.Map, 0, len(result.Data))
+	for _, v := range result.Data {
+		versions = append(versions, fiber.Map{
+			"version":        v.Version,
+			"nama_produk":    v.NamaProduk,
+			"harga_reseler":  v.HargaReseller,
+			"harga_konsumen": v.HargaKonsumen,
+			"stok":           v.Stok,
+			"source":         v.Source,
+			"created_at":     v.CreatedAt,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"data":        versions,
+			"limit":       result.Limit,
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
+		},
+	})
+}
+
+func (h *ProductHandler) getProductPriceSeries(c *fiber.Ctx, moderator bool) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{"invalid from"},
+				"data":    nil,
+			})
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to GET data",
+				"errors":  []string{"invalid to"},
+				"data":    nil,
+			})
+		}
+	}
+
+	series, err := h.productUC.GetPriceSeries(actor, uint(id), moderator, from, to)
+	if err != nil {
+		return productHistoryError(c, err)
+	}
+
+	points := make([]fiber.Map, 0, len(series))
+	for _, p := range series {
+		points = append(points, fiber.Map{
+			"date":               p.Date.Format("2006-01-02"),
+			"harga_reseler":      p.HargaReseller,
+			"harga_konsumen":     p.HargaKonsumen,
+			"min_harga_konsumen": p.MinHargaKonsumen,
+			"max_harga_konsumen": p.MaxHargaKonsumen,
+			"stok":               p.Stok,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    points,
+	})
+}
+
+func productHistoryError(c *fiber.Ctx, err error) error {
+	statusCode := fiber.StatusInternalServerError
+	if errors.Is(err, usecase.ErrInvalidCursor) || errors.Is(err, usecase.ErrInvalidDateRange) {
+		statusCode = fiber.StatusBadRequest
+	} else if errors.Is(err, usecase.ErrProductNotFound) {
+		statusCode = fiber.StatusNotFound
+	} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+		statusCode = fiber.StatusForbidden
+	}
+	return c.Status(statusCode).JSON(fiber.Map{
+		"status":  false,
+		"message": "Failed to GET data",
+		"errors":  []string{err.Error()},
+		"data":    nil,
+	})
+}
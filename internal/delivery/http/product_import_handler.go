@@ -0,0 +1,266 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// ProductImportHandler handles HTTP requests for bulk product import and export.
+type ProductImportHandler struct {
+	importUC usecase.ProductImportUsecase
+}
+
+// NewProductImportHandler creates a new ProductImportHandler.
+func NewProductImportHandler(importUC usecase.ProductImportUsecase) *ProductImportHandler {
+	return &ProductImportHandler{importUC: importUC}
+}
+
+// Import handles POST /product/import: a multipart "file" (CSV or XLSX),
+// an optional "images" zip and optional toko_id. The rows are imported in
+// the background; the response is the job to poll.
+func (h *ProductImportHandler) Import(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var tokoID int
+	if v := c.FormValue("toko_id"); v != "" {
+		var err error
+		if tokoID, err = strconv.Atoi(v); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{"invalid toko_id"},
+				"data":    nil,
+			})
+		}
+	}
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"file wajib diisi"},
+			"data":    nil,
+		})
+	}
+	file, err := readFormFile(fh)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	var images *usecase.ImportFile
+	if fh, err := c.FormFile("images"); err == nil {
+		if images, err = readFormFile(fh); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+	}
+
+	job, err := h.importUC.Submit(actor, uint(tokoID), *file, images)
+	if err != nil {
+		return c.Status(importErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildImportJobResponse(job),
+	})
+}
+
+// GetImportJob handles GET /product/import/:id.
+func (h *ProductImportHandler) GetImportJob(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	job, err := h.importUC.GetJob(actor, uint(id))
+	if err != nil {
+		return c.Status(importErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    buildImportJobResponse(job),
+	})
+}
+
+// GetImportErrors handles GET /product/import/:id/errors, downloading the
+// rows that failed as CSV.
+func (h *ProductImportHandler) GetImportErrors(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	report, err := h.importUC.ErrorReport(actor, uint(id))
+	if err != nil {
+		return c.Status(importErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	c.Attachment(fmt.Sprintf("import-%d-errors.csv", id))
+	c.Set(fiber.HeaderContentType, "text/csv")
+	return c.Send(report)
+}
+
+// Export handles GET /product/export?toko_id=&format=csv|xlsx.
+func (h *ProductImportHandler) Export(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+
+	file, err := h.importUC.Export(actor, uint(tokoID), c.Query("format", domain.ImportFormatCSV))
+	if err != nil {
+		return c.Status(importErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	c.Attachment(file.Filename)
+	c.Set(fiber.HeaderContentType, file.ContentType)
+	return c.Send(file.Data)
+}
+
+// readFormFile reads an uploaded multipart file into memory.
+func readFormFile(fh *multipart.FileHeader) (*usecase.ImportFile, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return &usecase.ImportFile{Filename: fh.Filename, Data: data}, nil
+}
+
+func importErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrImportJobNotFound),
+		errors.Is(err, usecase.ErrTokoNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrImportTooManyRows):
+		return fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, usecase.ErrInvalidImportFormat),
+		errors.Is(err, usecase.ErrInvalidImportFile):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildImportJobResponse maps domain.ImportJob into JSON.
+func buildImportJobResponse(j *domain.ImportJob) fiber.Map {
+	return fiber.Map{
+		"id":             j.ID,
+		"toko_id":        j.TokoID,
+		"format":         j.Format,
+		"filename":       j.Filename,
+		"status":         j.Status,
+		"total_rows":     j.TotalRows,
+		"processed_rows": j.ProcessedRows,
+		"success_rows":   j.SuccessRows,
+		"failed_rows":    j.FailedRows,
+		"last_error":     j.LastError,
+		"errors_url":     fmt.Sprintf("/product/import/%d/errors", j.ID),
+		"started_at":     j.StartedAt,
+		"finished_at":    j.FinishedAt,
+		"created_at":     j.CreatedAt,
+	}
+}
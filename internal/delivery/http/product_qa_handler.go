@@ -0,0 +1,465 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// ProductQAHandler handles HTTP requests for product questions and answers.
+type ProductQAHandler struct {
+	qaUC usecase.ProductQAUsecase
+}
+
+// NewProductQAHandler creates a new ProductQAHandler.
+func NewProductQAHandler(qaUC usecase.ProductQAUsecase) *ProductQAHandler {
+	return &ProductQAHandler{qaUC: qaUC}
+}
+
+type qaBodyRequest struct {
+	Body string `json:"body"`
+}
+
+// GetQuestions handles GET /product/:id/questions?page=&limit=. Login is
+// optional; members of the product's toko also see questions on products
+// that are not published.
+func (h *ProductQAHandler) GetQuestions(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		actor = anonymousActor(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	result, err := h.qaUC.GetQuestions(actor, uint(id), limit, page)
+	if err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	questions := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		questions = append(questions, buildQuestionResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"data":  questions,
+			"page":  result.Page,
+			"limit": result.Limit,
+		},
+	})
+}
+
+// AskQuestion handles POST /product/:id/questions with body {body}.
+func (h *ProductQAHandler) AskQuestion(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req qaBodyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	question, err := h.qaUC.Ask(actor, uint(id), req.Body)
+	if err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildQuestionResponse(question),
+	})
+}
+
+// AnswerQuestion handles POST /product/questions/:id/answers with body {body}.
+func (h *ProductQAHandler) AnswerQuestion(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req qaBodyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	answer, err := h.qaUC.Answer(actor, uint(id), req.Body)
+	if err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildAnswerResponse(answer),
+	})
+}
+
+// Upvote handles POST /product/{questions,answers}/:id/upvote for targetType.
+func (h *ProductQAHandler) Upvote(targetType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.vote(c, targetType, true)
+	}
+}
+
+// RemoveUpvote handles DELETE /product/{questions,answers}/:id/upvote for targetType.
+func (h *ProductQAHandler) RemoveUpvote(targetType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.vote(c, targetType, false)
+	}
+}
+
+func (h *ProductQAHandler) vote(c *fiber.Ctx, targetType string, up bool) error {
+	message := "POST"
+	if !up {
+		message = "DELETE"
+	}
+
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to " + message + " data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var upvotes int
+	if up {
+		upvotes, err = h.qaUC.Upvote(actor, targetType, uint(id))
+	} else {
+		upvotes, err = h.qaUC.RemoveUpvote(actor, targetType, uint(id))
+	}
+	if err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to " + message + " data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to " + message + " data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":      id,
+			"upvoted": up,
+			"upvotes": upvotes,
+		},
+	})
+}
+
+// Report handles POST /product/{questions,answers}/:id/report with body {reason}.
+func (h *ProductQAHandler) Report(targetType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		actor, ok := requestActor(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  false,
+				"message": "Unauthorized",
+				"errors":  []string{"invalid user id in token"},
+				"data":    nil,
+			})
+		}
+
+		id, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{"invalid id"},
+				"data":    nil,
+			})
+		}
+
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  false,
+					"message": "Failed to POST data",
+					"errors":  []string{"invalid request body"},
+					"data":    nil,
+				})
+			}
+		}
+
+		if err := h.qaUC.Report(actor, targetType, uint(id), req.Reason); err != nil {
+			return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to POST data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  true,
+			"message": "Succeed to POST data",
+			"errors":  nil,
+			"data":    "laporan diterima",
+		})
+	}
+}
+
+// Hide handles PUT /toko/{questions,answers}/:id/hide with body {hidden}
+// for members of the product's toko.
+func (h *ProductQAHandler) Hide(targetType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.setHidden(c, targetType, h.qaUC.Hide)
+	}
+}
+
+// Moderate handles PUT /admin/qa/{questions,answers}/:id/hide with body
+// {hidden} for staff moderators.
+func (h *ProductQAHandler) Moderate(targetType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return h.setHidden(c, targetType, h.qaUC.Moderate)
+	}
+}
+
+func (h *ProductQAHandler) setHidden(c *fiber.Ctx, targetType string, apply func(usecase.Actor, string, uint, bool) error) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Hidden *bool `json:"hidden"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Hidden == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"hidden wajib diisi"},
+			"data":    nil,
+		})
+	}
+
+	if err := apply(actor, targetType, uint(id), *req.Hidden); err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":     id,
+			"target": targetType,
+			"hidden": *req.Hidden,
+		},
+	})
+}
+
+// GetReported handles GET /admin/qa/reports?target=question|answer&page=&limit=.
+func (h *ProductQAHandler) GetReported(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	result, err := h.qaUC.GetReported(c.Query("target", domain.QATargetQuestion), limit, page)
+	if err != nil {
+		return c.Status(qaErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	items := make([]fiber.Map, 0, len(result.Questions)+len(result.Answers))
+	for i := range result.Questions {
+		q := &result.Questions[i]
+		item := buildQuestionResponse(q)
+		item["report_count"] = q.ReportCount
+		item["hidden"] = q.HiddenAt != nil
+		items = append(items, item)
+	}
+	for i := range result.Answers {
+		a := &result.Answers[i]
+		item := buildAnswerResponse(a)
+		item["report_count"] = a.ReportCount
+		item["hidden"] = a.HiddenAt != nil
+		items = append(items, item)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"target": result.TargetType,
+			"data":   items,
+			"page":   result.Page,
+			"limit":  result.Limit,
+		},
+	})
+}
+
+// qaErrorStatus maps product Q&A usecase errors to HTTP status codes.
+func qaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrQuestionNotFound),
+		errors.Is(err, usecase.ErrAnswerNotFound),
+		errors.Is(err, usecase.ErrProductNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrAnswerNotAllowed),
+		errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrInvalidQABody),
+		errors.Is(err, usecase.ErrInvalidQATarget):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildQuestionResponse maps domain.ProductQuestion and its loaded answers into JSON.
+func buildQuestionResponse(q *domain.ProductQuestion) fiber.Map {
+	answers := make([]fiber.Map, 0, len(q.Answers))
+	for i := range q.Answers {
+		answers = append(answers, buildAnswerResponse(&q.Answers[i]))
+	}
+
+	return fiber.Map{
+		"id":         q.ID,
+		"product_id": q.ProdukID,
+		"body":       q.Body,
+		"user": fiber.Map{
+			"id":   q.User.ID,
+			"nama": q.User.Nama,
+		},
+		"upvotes":    q.Upvotes,
+		"answers":    answers,
+		"created_at": q.CreatedAt,
+	}
+}
+
+// buildAnswerResponse maps domain.ProductAnswer into JSON.
+func buildAnswerResponse(a *domain.ProductAnswer) fiber.Map {
+	return fiber.Map{
+		"id":          a.ID,
+		"question_id": a.QuestionID,
+		"body":        a.Body,
+		"user": fiber.Map{
+			"id":   a.User.ID,
+			"nama": a.User.Nama,
+		},
+		"answered_as":    a.AnsweredAs,
+		"verified_buyer": a.AnsweredAs == domain.AnsweredAsBuyer,
+		"upvotes":        a.Upvotes,
+		"created_at":     a.CreatedAt,
+	}
+}
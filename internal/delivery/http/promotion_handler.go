@@ -0,0 +1,223 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// PromotionHandler handles HTTP requests for promotions and flash sales.
+type PromotionHandler struct {
+	promotionUC usecase.PromotionUsecase
+}
+
+// NewPromotionHandler creates a new PromotionHandler.
+func NewPromotionHandler(promotionUC usecase.PromotionUsecase) *PromotionHandler {
+	return &PromotionHandler{promotionUC: promotionUC}
+}
+
+// GetPromotions handles GET /toko/promotions?toko_id=.
+func (h *PromotionHandler) GetPromotions(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+
+	promotions, err := h.promotionUC.GetAll(actor, uint(tokoID))
+	if err != nil {
+		return c.Status(promotionErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(promotions))
+	for i := range promotions {
+		data = append(data, buildPromotionResponse(&promotions[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// CreatePromotion handles POST /toko/promotions. start_at and end_at are
+// RFC3339 timestamps.
+func (h *PromotionHandler) CreatePromotion(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.CreatePromotionInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	promotion, err := h.promotionUC.Create(actor, in)
+	if err != nil {
+		return c.Status(promotionErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildPromotionResponse(promotion),
+	})
+}
+
+// EndPromotion handles PUT /toko/promotions/:id/end, stopping a running
+// promotion or cancelling a scheduled one.
+func (h *PromotionHandler) EndPromotion(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	promotion, err := h.promotionUC.End(actor, uint(id))
+	if err != nil {
+		return c.Status(promotionErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildPromotionResponse(promotion),
+	})
+}
+
+// GetFlashSales handles GET /product/flash-sale, listing the products of
+// running flash sales, ending soonest first.
+func (h *PromotionHandler) GetFlashSales(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	result, err := h.promotionUC.GetFlashSales(limit, page)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	products := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		products = append(products, buildProductResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"data":  products,
+			"page":  result.Page,
+			"limit": result.Limit,
+		},
+	})
+}
+
+func promotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrPromotionNotFound), errors.Is(err, usecase.ErrTokoNotFound),
+		errors.Is(err, usecase.ErrProductNotFound), errors.Is(err, usecase.ErrCategoryNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrPromotionNameRequired), errors.Is(err, usecase.ErrInvalidPromotionTarget),
+		errors.Is(err, usecase.ErrInvalidPromotionDiscount), errors.Is(err, usecase.ErrInvalidPromotionPeriod),
+		errors.Is(err, usecase.ErrInvalidPromotionLimit), errors.Is(err, usecase.ErrFlashSaleNeedsProduct),
+		errors.Is(err, usecase.ErrPromotionPriceOnVariants):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildPromotionResponse maps a promotion to JSON. sale_stock_left is nil
+// when the sale stock is unlimited.
+func buildPromotionResponse(p *domain.Promotion) fiber.Map {
+	var saleStockLeft interface{}
+	if p.SaleStock > 0 {
+		saleStockLeft = max(p.SaleStock-p.Sold, 0)
+	}
+	return fiber.Map{
+		"id":              p.ID,
+		"toko_id":         p.TokoID,
+		"product_id":      p.ProdukID,
+		"category_id":     p.CategoryID,
+		"nama":            p.Nama,
+		"type":            p.Type,
+		"value":           p.Value,
+		"start_at":        p.StartAt,
+		"end_at":          p.EndAt,
+		"flash_sale":      p.FlashSale,
+		"max_per_user":    p.MaxPerUser,
+		"sale_stock":      p.SaleStock,
+		"sold":            p.Sold,
+		"sale_stock_left": saleStockLeft,
+	}
+}
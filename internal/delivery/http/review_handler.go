@@ -0,0 +1,251 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// ReviewHandler handles HTTP requests for product reviews.
+type ReviewHandler struct {
+	reviewUC usecase.ReviewUsecase
+}
+
+// NewReviewHandler creates a new ReviewHandler.
+func NewReviewHandler(reviewUC usecase.ReviewUsecase) *ReviewHandler {
+	return &ReviewHandler{reviewUC: reviewUC}
+}
+
+// GetProductReviews handles GET /product/:id/reviews?rating=&page=&limit=.
+// Reviews of an unpublished product are only listed to its toko.
+func (h *ReviewHandler) GetProductReviews(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		actor = anonymousActor(c)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	rating, err := strconv.Atoi(c.Query("rating", "0"))
+	if err != nil || rating < 0 || rating > 5 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid rating"},
+			"data":    nil,
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	result, err := h.reviewUC.GetByProduct(actor, uint(id), rating, limit, page)
+	if err != nil {
+		return c.Status(reviewErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	reviews := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		reviews = append(reviews, buildReviewResponse(&result.Data[i]))
+	}
+	distribution := fiber.Map{}
+	for r := 1; r <= 5; r++ {
+		distribution[strconv.Itoa(r)] = result.Distribution[r]
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"rating_avg":          math.Round(result.RatingAvg*10) / 10,
+			"rating_count":        result.RatingCount,
+			"rating_distribution": distribution,
+			"data":                reviews,
+			"page":                result.Page,
+			"limit":               result.Limit,
+		},
+	})
+}
+
+// CreateReview handles POST /trx/details/:id/review with multipart fields
+// "rating", "comment" and optional "photos".
+func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Rating  int    `json:"rating" form:"rating"`
+		Comment string `json:"comment" form:"comment"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	photos, err := saveUploadedFiles(c, "photos", imageExtensions)
+	if err != nil {
+		return c.Status(reviewErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	review, err := h.reviewUC.Create(actor, uint(id), usecase.CreateReviewInput{Rating: req.Rating, Comment: req.Comment}, photos)
+	if err != nil {
+		return c.Status(reviewErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildReviewResponse(review),
+	})
+}
+
+// ReplyReview handles PUT /toko/reviews/:id/reply with body {reply}.
+func (h *ReviewHandler) ReplyReview(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Reply string `json:"reply"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	review, err := h.reviewUC.Reply(actor, uint(id), req.Reply)
+	if err != nil {
+		return c.Status(reviewErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildReviewResponse(review),
+	})
+}
+
+// reviewErrorStatus maps review usecase errors to HTTP status codes.
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrReviewNotFound),
+		errors.Is(err, usecase.ErrDetailTrxNotFound),
+		errors.Is(err, usecase.ErrProductNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrReviewExists):
+		return fiber.StatusConflict
+	case errors.Is(err, usecase.ErrReviewNotAllowed):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusBadRequest
+	}
+}
+
+// buildReviewResponse maps domain.Review into JSON.
+func buildReviewResponse(r *domain.Review) fiber.Map {
+	photos := make([]fiber.Map, 0)
+	for _, filename := range r.PhotoList() {
+		photos = append(photos, fiber.Map{"url": filename})
+	}
+
+	var reply fiber.Map
+	if r.RepliedAt != nil {
+		reply = fiber.Map{
+			"body":       r.Reply,
+			"replied_at": r.RepliedAt,
+		}
+	}
+
+	return fiber.Map{
+		"id":            r.ID,
+		"product_id":    r.ProdukID,
+		"toko_id":       r.TokoID,
+		"detail_trx_id": r.DetailTrxID,
+		"user": fiber.Map{
+			"id":   r.User.ID,
+			"nama": r.User.Nama,
+		},
+		"rating":     r.Rating,
+		"comment":    r.Comment,
+		"photos":     photos,
+		"reply":      reply,
+		"created_at": r.CreatedAt,
+	}
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// RoleHandler handles admin endpoints for roles and role assignment.
+type RoleHandler struct {
+	roleUC usecase.RoleUsecase
+}
+
+// NewRoleHandler creates a new RoleHandler.
+func NewRoleHandler(roleUC usecase.RoleUsecase) *RoleHandler {
+	return &RoleHandler{roleUC: roleUC}
+}
+
+// GetAllRoles handles GET /admin/roles.
+func (h *RoleHandler) GetAllRoles(c *fiber.Ctx) error {
+	roles, err := h.roleUC.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(roles))
+	for i := range roles {
+		data = append(data, buildRoleResponse(&roles[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// GetUserRoles handles GET /admin/users/:id/roles.
+func (h *RoleHandler) GetUserRoles(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	user, err := h.roleUC.GetUserRoles(uint(id))
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			statusCode = fiber.StatusNotFound
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    buildUserRolesResponse(user),
+	})
+}
+
+// AssignUserRoles handles PUT /admin/users/:id/roles.
+func (h *RoleHandler) AssignUserRoles(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+	permissions, _ := c.Locals("permissions").([]string)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.AssignRolesInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	user, err := h.roleUC.AssignRoles(actor, permissions, uint(id), in)
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrUnknownRole) {
+			statusCode = fiber.StatusBadRequest
+		} else if errors.Is(err, usecase.ErrRoleNotAssignable) {
+			statusCode = fiber.StatusForbidden
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildUserRolesResponse(user),
+	})
+}
+
+// buildRoleResponse maps domain.Role with its permission names.
+func buildRoleResponse(role *domain.Role) fiber.Map {
+	perms := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		perms = append(perms, p.Name)
+	}
+	return fiber.Map{
+		"id":          role.ID,
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": perms,
+	}
+}
+
+// buildUserRolesResponse maps a user's roles and effective permissions.
+func buildUserRolesResponse(user *domain.User) fiber.Map {
+	return fiber.Map{
+		"id":          user.ID,
+		"nama":        user.Nama,
+		"roles":       user.RoleNames(),
+		"permissions": user.PermissionNames(),
+	}
+}
@@ -1,16 +1,32 @@
 package http
 
 import (
+	"log"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"gorm.io/gorm"
 
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/config"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/middleware"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/notifier"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
 )
 
 // RegisterRoutes registers all HTTP routes for the application.
-func RegisterRoutes(app *fiber.App, db *gorm.DB) {
+func RegisterRoutes(app *fiber.App, db *gorm.DB) error {
+	// Request IDs (X-Request-ID) tie audit entries to a single API call
+	app.Use(requestid.New())
+
+	// Bodies keep Fiber's default size limit everywhere except product
+	// imports, which get the larger import upload limit on their route
+	app.Use(middleware.LimitBody(fiber.DefaultBodyLimit, "POST /product/import"))
+
 	// Health check route
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -23,39 +39,222 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
 	tokoRepo := repository.NewTokoRepository(db)
 	alamatRepo := repository.NewAlamatRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
-	productRepo := repository.NewProductRepository(db)
+	productRepo := repository.NewProductRepository(db, config.ProductSlugScope())
 	fotoProdukRepo := repository.NewFotoProdukRepository(db)
 	trxRepo := repository.NewTrxRepository(db)
+	otpRepo := repository.NewOTPRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	securityEventRepo := repository.NewSecurityEventRepository(db)
+	tokoMemberRepo := repository.NewTokoMemberRepository(db)
+	tokoInvitationRepo := repository.NewTokoInvitationRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	webhookEndpointRepo := repository.NewWebhookEndpointRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationPrefRepo := repository.NewNotificationPreferenceRepository(db)
+	conversationRepo := repository.NewConversationRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	productQARepo := repository.NewProductQARepository(db)
+	importJobRepo := repository.NewImportJobRepository(db)
+	suggestionRepo := repository.NewSuggestionRepository(db)
+	chatMessageRepo := repository.NewChatMessageRepository(db)
+	productHistoryRepo := repository.NewProductHistoryRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+
+	var loginAttemptRepo repository.LoginAttemptRepository
+	if config.LoginAttemptStore() == "memory" {
+		loginAttemptRepo = repository.NewMemoryLoginAttemptRepository()
+	} else {
+		loginAttemptRepo = repository.NewLoginAttemptRepository(db)
+	}
+
+	var productSearchRepo repository.ProductSearchRepository
+	if config.SearchEngine() == "memory" {
+		productSearchRepo = repository.NewMemoryProductSearchRepository()
+	} else {
+		productSearchRepo = repository.NewProductSearchRepository(db)
+	}
+
+	appCfg := config.LoadAppConfig()
+	verifyPolicy, err := config.LoadVerificationPolicy()
+	if err != nil {
+		return err
+	}
+	twoFactorCfg := config.LoadTwoFactorConfig()
+	auditCfg := config.LoadAuditConfig()
+	webhookCfg := config.LoadWebhookConfig()
+	outboxCfg := config.LoadOutboxConfig()
+	notificationCfg := config.LoadNotificationConfig()
+	chatCfg := config.LoadChatConfig()
+	qaCfg := config.LoadQAConfig()
+	importCfg := config.LoadImportConfig()
+	publishCfg := config.LoadProductPublishConfig()
+	suggestCfg := config.LoadSuggestConfig()
+
+	// Outbound messages (OTP codes, links)
+	notifierCfg := config.LoadNotifierConfig()
+	userNotifier := notifier.New(notifierCfg.Sink, notifierCfg.FilePath)
 
 	// Initialize usecases
-	authUC := usecase.NewAuthUsecase(userRepo, tokoRepo)
-	userUC := usecase.NewUserUsecase(userRepo)
-	alamatUC := usecase.NewAlamatUsecase(alamatRepo)
-	tokoUC := usecase.NewTokoUsecase(tokoRepo)
-	categoryUC := usecase.NewCategoryUsecase(categoryRepo)
-	productUC := usecase.NewProductUsecase(productRepo, fotoProdukRepo, tokoRepo)
-	trxUC := usecase.NewTrxUsecase(trxRepo, alamatRepo, productRepo)
+	webhookUC := usecase.NewWebhookUsecase(webhookEndpointRepo, webhookDeliveryRepo, tokoMemberRepo, webhookCfg.AllowPrivateTargets)
+	authUC := usecase.NewAuthUsecase(userRepo, tokoRepo, tokoMemberRepo, roleRepo, otpRepo, userNotifier, loginAttemptRepo, securityEventRepo, recoveryCodeRepo, apiKeyRepo, auditLogRepo, appCfg.BaseURL, twoFactorCfg.RequiredForAdmin)
+	userUC := usecase.NewUserUsecase(userRepo, apiKeyRepo, auditLogRepo)
+	twoFactorUC := usecase.NewTwoFactorUsecase(userRepo, recoveryCodeRepo, auditLogRepo, twoFactorCfg.Issuer)
+	alamatUC := usecase.NewAlamatUsecase(alamatRepo, auditLogRepo)
+	tokoUC := usecase.NewTokoUsecase(tokoRepo, tokoMemberRepo, auditLogRepo)
+	categoryUC := usecase.NewCategoryUsecase(categoryRepo, auditLogRepo)
+	productUC := usecase.NewProductUsecase(productRepo, fotoProdukRepo, tokoMemberRepo, userRepo, auditLogRepo, productSearchRepo, productHistoryRepo, promotionRepo, usecase.VerificationRequirement(verifyPolicy.ProductCreate), publishCfg.RequireModeration)
+	trxUC := usecase.NewTrxUsecase(trxRepo, alamatRepo, productRepo, userRepo, promotionRepo, tokoMemberRepo, auditLogRepo, usecase.VerificationRequirement(verifyPolicy.Checkout))
 	provinceCityUC := usecase.NewProvinceCityUsecase()
+	roleUC := usecase.NewRoleUsecase(roleRepo, userRepo, auditLogRepo)
+	tokoMemberUC := usecase.NewTokoMemberUsecase(tokoMemberRepo, tokoInvitationRepo, userRepo, userNotifier)
+	auditUC := usecase.NewAuditUsecase(auditLogRepo, auditCfg.RetentionDays)
+	apiKeyUC := usecase.NewAPIKeyUsecase(apiKeyRepo, tokoMemberRepo, auditLogRepo)
+	reviewUC := usecase.NewReviewUsecase(reviewRepo, trxRepo, productRepo, tokoMemberRepo, auditLogRepo)
+	productQAUC := usecase.NewProductQAUsecase(productQARepo, productRepo, trxRepo, tokoMemberRepo, auditLogRepo, qaCfg.ReportHideThreshold)
+	promotionUC := usecase.NewPromotionUsecase(promotionRepo, productRepo, categoryRepo, tokoMemberRepo, auditLogRepo)
+	productImportUC := usecase.NewProductImportUsecase(importJobRepo, productUC, productRepo, tokoMemberRepo, uploadImageStore{}, importCfg.MaxRows)
+	suggestUC := usecase.NewSuggestUsecase(suggestionRepo, suggestCfg.RefreshInterval)
+	chatUC := usecase.NewChatUsecase(conversationRepo, chatMessageRepo, tokoRepo, productRepo, trxRepo, tokoMemberRepo)
+	notificationUC := usecase.NewNotificationUsecase(notificationRepo, notificationPrefRepo, tokoMemberRepo, userRepo, userNotifier, notificationCfg.LowStockThreshold)
+
+	// Seed default roles and grant them to users created before RBAC
+	if err := roleUC.EnsureDefaults(); err != nil {
+		return err
+	}
+
+	// Tokos created before membership get their owner as a member
+	if err := tokoMemberUC.EnsureOwners(); err != nil {
+		return err
+	}
+
+	// Prune audit entries past the retention period once a day
+	go func() {
+		for ; ; time.Sleep(24 * time.Hour) {
+			if _, err := auditUC.PruneExpired(); err != nil {
+				log.Printf("audit: prune failed: %v", err)
+			}
+		}
+	}()
+
+	// Domain events written to the outbox are dispatched to these subscribers
+	bus := event.NewBus()
+	bus.Subscribe("webhooks", webhookUC.HandleEvent, domain.EventTrxCreated, domain.EventOrderShipped, domain.EventStockChanged)
+	bus.Subscribe("notifications", notificationUC.HandleEvent, domain.EventTrxCreated, domain.EventOrderShipped, domain.EventStockChanged, domain.EventProductApproved, domain.EventProductRejected)
+	bus.Subscribe("search", productUC.HandleEvent, domain.EventProductCreated, domain.EventProductUpdated, domain.EventProductDeleted)
+	bus.Subscribe("suggest", suggestUC.HandleEvent, domain.EventProductCreated, domain.EventProductUpdated, domain.EventProductDeleted)
+	dispatcher := event.NewDispatcher(outboxRepo, bus)
+
+	// Index products changed before the search subscriber saw them
+	go func() {
+		if _, err := productUC.RebuildSearchIndex(); err != nil {
+			log.Printf("search: rebuild failed: %v", err)
+		}
+	}()
+
+	// Start the history of products last changed before it was kept
+	go func() {
+		if _, err := productUC.BackfillHistory(); err != nil {
+			log.Printf("product: history backfill failed: %v", err)
+		}
+	}()
+
+	go func() {
+		for ; ; time.Sleep(outboxCfg.PollInterval) {
+			if _, err := dispatcher.DispatchDue(); err != nil {
+				log.Printf("event: dispatch failed: %v", err)
+			}
+		}
+	}()
+
+	// Prune dispatched events once a day
+	go func() {
+		for ; ; time.Sleep(24 * time.Hour) {
+			if _, err := dispatcher.Prune(outboxCfg.RetentionDays); err != nil {
+				log.Printf("event: prune failed: %v", err)
+			}
+		}
+	}()
+
+	// Deliver queued webhooks, retrying failed ones with backoff
+	go func() {
+		for ; ; time.Sleep(webhookCfg.PollInterval) {
+			if _, err := webhookUC.DispatchDue(); err != nil {
+				log.Printf("webhook: dispatch failed: %v", err)
+			}
+		}
+	}()
+
+	// Import uploaded product files in the background
+	go func() {
+		for ; ; time.Sleep(importCfg.PollInterval) {
+			if _, err := productImportUC.ProcessDue(); err != nil {
+				log.Printf("import: process failed: %v", err)
+			}
+		}
+	}()
+
+	// Publish scheduled products once their time comes
+	go func() {
+		for ; ; time.Sleep(publishCfg.PollInterval) {
+			if _, err := productUC.PublishDue(); err != nil {
+				log.Printf("product: publish failed: %v", err)
+			}
+		}
+	}()
 
 	// Initialize handlers
 	authHandler := NewAuthHandler(authUC)
 	userHandler := NewUserHandler(userUC)
+	twoFactorHandler := NewTwoFactorHandler(twoFactorUC)
 	alamatHandler := NewAlamatHandler(alamatUC)
 	tokoHandler := NewTokoHandler(tokoUC)
 	categoryHandler := NewCategoryHandler(categoryUC)
 	productHandler := NewProductHandler(productUC)
+	promotionHandler := NewPromotionHandler(promotionUC)
 	trxHandler := NewTrxHandler(trxUC)
 	provinceCityHandler := NewProvinceCityHandler(provinceCityUC)
+	roleHandler := NewRoleHandler(roleUC)
+	tokoMemberHandler := NewTokoMemberHandler(tokoMemberUC)
+	auditHandler := NewAuditHandler(auditUC)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyUC)
+	webhookHandler := NewWebhookHandler(webhookUC)
+	notificationHandler := NewNotificationHandler(notificationUC, notificationCfg.StreamPollInterval)
+	reviewHandler := NewReviewHandler(reviewUC)
+	productQAHandler := NewProductQAHandler(productQAUC)
+	productImportHandler := NewProductImportHandler(productImportUC)
+	suggestHandler := NewSuggestHandler(suggestUC)
+	chatHandler := NewChatHandler(chatUC, chatCfg.PollInterval)
+
+	jwtMiddleware := middleware.JWTMiddleware(userRepo, nil)
+	// integration routes also accept personal API keys (X-API-Key), limited by scope
+	apiAuth := middleware.JWTMiddleware(userRepo, apiKeyRepo)
 
 	// Auth routes based on Postman collection
 	authGroup := app.Group("/auth")
 	authGroup.Post("/register", authHandler.Register)
 	authGroup.Post("/login", authHandler.Login)
+	authGroup.Post("/login/2fa", authHandler.LoginTwoFactor)
+	authGroup.Post("/forgot-password", authHandler.ForgotPassword)
+	authGroup.Post("/reset-password", authHandler.ResetPassword)
+	authGroup.Get("/verify", authHandler.VerifyContact)
+	authGroup.Post("/verify", authHandler.VerifyContact)
+	authGroup.Post("/verify/resend", authHandler.ResendVerification)
 
 	// User routes (protected with JWT middleware)
-	userGroup := app.Group("/user", middleware.JWTMiddleware())
+	userGroup := app.Group("/user", jwtMiddleware)
 	userGroup.Get("/", userHandler.GetProfile)
 	userGroup.Put("/", userHandler.UpdateProfile)
+	userGroup.Put("/password", userHandler.ChangePassword)
+	userGroup.Post("/2fa/enroll", twoFactorHandler.Enroll)
+	userGroup.Post("/2fa/confirm", twoFactorHandler.Confirm)
+	userGroup.Post("/2fa/disable", twoFactorHandler.Disable)
+	userGroup.Get("/api-keys", apiKeyHandler.GetMyAPIKeys)
+	userGroup.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+	userGroup.Delete("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
 
 	alamatGroup := userGroup.Group("/alamat")
 	alamatGroup.Get("/", alamatHandler.GetMyAlamat)
@@ -66,14 +265,39 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
 
 	// Toko routes (public listing/detail, and update for logged-in user)
 	app.Get("/toko", tokoHandler.GetAllToko)
-	app.Get("/toko/my", middleware.JWTMiddleware(), tokoHandler.GetMyToko)
+	app.Get("/toko/my", jwtMiddleware, tokoHandler.GetMyToko)
+	app.Get("/toko/memberships", jwtMiddleware, tokoMemberHandler.GetMyMemberships)
+	app.Get("/toko/orders", apiAuth, middleware.RequireScope(domain.ScopeOrdersRead), trxHandler.GetTokoOrders)
+	app.Post("/toko/orders/:id/ship", jwtMiddleware, trxHandler.ShipTokoOrder)
+	app.Get("/toko/products", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productHandler.GetTokoProducts)
+	app.Get("/toko/promotions", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), promotionHandler.GetPromotions)
+	app.Post("/toko/promotions", apiAuth, middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), promotionHandler.CreatePromotion)
+	app.Put("/toko/promotions/:id/end", apiAuth, middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), promotionHandler.EndPromotion)
+	app.Post("/toko/invitations/accept", jwtMiddleware, tokoMemberHandler.AcceptInvitation)
+	app.Put("/toko/reviews/:id/reply", jwtMiddleware, reviewHandler.ReplyReview)
+	app.Put("/toko/questions/:id/hide", jwtMiddleware, productQAHandler.Hide(domain.QATargetQuestion))
+	app.Put("/toko/answers/:id/hide", jwtMiddleware, productQAHandler.Hide(domain.QATargetAnswer))
+	// Toko webhooks (owner/admin of the toko)
+	app.Get("/toko/webhooks", jwtMiddleware, webhookHandler.GetWebhooks)
+	app.Post("/toko/webhooks", jwtMiddleware, webhookHandler.CreateWebhook)
+	app.Put("/toko/webhooks/:id", jwtMiddleware, webhookHandler.UpdateWebhook)
+	app.Delete("/toko/webhooks/:id", jwtMiddleware, webhookHandler.DeleteWebhook)
+	app.Post("/toko/webhooks/:id/ping", jwtMiddleware, webhookHandler.PingWebhook)
+	app.Get("/toko/webhooks/:id/deliveries", jwtMiddleware, webhookHandler.GetWebhookDeliveries)
+	app.Post("/toko/webhooks/deliveries/:id/redeliver", jwtMiddleware, webhookHandler.RedeliverWebhook)
 	app.Get("/toko/:id", tokoHandler.GetTokoByID)
+	app.Get("/toko/:id/product/:slug", productHandler.GetTokoProductBySlug)
+	// Toko staff management (capabilities checked per toko role)
+	app.Get("/toko/:id/members", jwtMiddleware, tokoMemberHandler.GetMembers)
+	app.Post("/toko/:id/members/invite", jwtMiddleware, tokoMemberHandler.InviteMember)
+	app.Put("/toko/:id/members/:user_id", jwtMiddleware, tokoMemberHandler.UpdateMember)
+	app.Delete("/toko/:id/members/:user_id", jwtMiddleware, tokoMemberHandler.RemoveMember)
 	// Support both PUT /toko and PUT /toko/:id_toko (as in Postman collection)
-	app.Put("/toko", middleware.JWTMiddleware(), tokoHandler.UpdateMyToko)
-	app.Put("/toko/:id_toko", middleware.JWTMiddleware(), tokoHandler.UpdateMyToko)
+	app.Put("/toko", jwtMiddleware, middleware.Require(domain.PermTokoWrite), tokoHandler.UpdateMyToko)
+	app.Put("/toko/:id_toko", jwtMiddleware, middleware.Require(domain.PermTokoWrite), tokoHandler.UpdateMyToko)
 
-	// Category routes (admin only)
-	categoryGroup := app.Group("/category", middleware.JWTMiddleware(), middleware.AdminOnly())
+	// Category routes (category managers, back-office 2FA policy applies)
+	categoryGroup := app.Group("/category", jwtMiddleware, middleware.RequireMFA(twoFactorCfg.RequiredForAdmin), middleware.Require(domain.PermCategoryManage))
 	categoryGroup.Get("/", categoryHandler.GetAll)
 	categoryGroup.Get("/:id", categoryHandler.GetByID)
 	categoryGroup.Post("/", categoryHandler.Create)
@@ -82,17 +306,82 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
 
 	// Product routes
 	app.Get("/product", productHandler.GetAllProduct)
+	// Suggest, flash sale, import and export routes come before /product/:id so their paths are not read as an id
+	app.Get("/product/suggest", suggestHandler.Suggest)
+	app.Get("/product/slug/:slug", productHandler.GetProductBySlug)
+	app.Get("/product/flash-sale", promotionHandler.GetFlashSales)
+	app.Get("/product/export", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productImportHandler.Export)
+	app.Get("/product/import/:id", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productImportHandler.GetImportJob)
+	app.Get("/product/import/:id/errors", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productImportHandler.GetImportErrors)
 	app.Get("/product/:id", productHandler.GetProductByID)
-	productGroup := app.Group("/product", middleware.JWTMiddleware())
-	productGroup.Post("/", productHandler.CreateProduct)
-	productGroup.Put("/:id", productHandler.UpdateProduct)
-	productGroup.Delete("/:id", productHandler.DeleteProduct)
+	app.Get("/product/:id/reviews", middleware.OptionalAuth(apiAuth), reviewHandler.GetProductReviews)
+	app.Get("/product/:id/history", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productHandler.GetProductHistory(false))
+	app.Get("/product/:id/history/daily", apiAuth, middleware.RequireScope(domain.ScopeProductsRead), productHandler.GetProductPriceSeries(false))
+	app.Get("/product/:id/questions", middleware.OptionalAuth(apiAuth), productQAHandler.GetQuestions)
+	app.Post("/product/:id/questions", jwtMiddleware, productQAHandler.AskQuestion)
+	app.Post("/product/questions/:id/answers", jwtMiddleware, productQAHandler.AnswerQuestion)
+	app.Post("/product/questions/:id/upvote", jwtMiddleware, productQAHandler.Upvote(domain.QATargetQuestion))
+	app.Delete("/product/questions/:id/upvote", jwtMiddleware, productQAHandler.RemoveUpvote(domain.QATargetQuestion))
+	app.Post("/product/questions/:id/report", jwtMiddleware, productQAHandler.Report(domain.QATargetQuestion))
+	app.Post("/product/answers/:id/upvote", jwtMiddleware, productQAHandler.Upvote(domain.QATargetAnswer))
+	app.Delete("/product/answers/:id/upvote", jwtMiddleware, productQAHandler.RemoveUpvote(domain.QATargetAnswer))
+	app.Post("/product/answers/:id/report", jwtMiddleware, productQAHandler.Report(domain.QATargetAnswer))
+	productGroup := app.Group("/product", apiAuth)
+	productGroup.Post("/", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.CreateProduct)
+	productGroup.Post("/import", middleware.LimitBody(importCfg.MaxUploadBytes), middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productImportHandler.Import)
+	productGroup.Put("/:id", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.UpdateProduct)
+	productGroup.Put("/:id/stock", middleware.RequireScope(domain.ScopeStockWrite), middleware.Require(domain.PermProductWrite), productHandler.UpdateProductStock)
+	productGroup.Put("/:id/archive", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.ArchiveProduct)
+	productGroup.Put("/:id/unarchive", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.UnarchiveProduct)
+	productGroup.Put("/:id/publish", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.PublishProduct)
+	productGroup.Put("/:id/unpublish", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.UnpublishProduct)
+	productGroup.Put("/:id/variants", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.SetProductVariants)
+	productGroup.Put("/variants/:id/stock", middleware.RequireScope(domain.ScopeStockWrite), middleware.Require(domain.PermProductWrite), productHandler.UpdateVariantStock)
+	productGroup.Delete("/:id", middleware.RequireScope(domain.ScopeProductsWrite), middleware.Require(domain.PermProductWrite), productHandler.DeleteProduct)
 
 	// Trx routes (protected with JWT middleware)
-	trxGroup := app.Group("/trx", middleware.JWTMiddleware())
+	trxGroup := app.Group("/trx", jwtMiddleware)
 	trxGroup.Get("/", trxHandler.GetAllTrx)
 	trxGroup.Get("/:id", trxHandler.GetTrxByID)
-	trxGroup.Post("/", trxHandler.PostTrx)
+	trxGroup.Post("/", middleware.Require(domain.PermTrxCreate), trxHandler.PostTrx)
+	trxGroup.Post("/details/:id/review", reviewHandler.CreateReview)
+
+	// Notification center routes (protected with JWT middleware)
+	notificationGroup := app.Group("/notifications")
+	// EventSource cannot send headers, so the stream also takes ?token=
+	notificationGroup.Get("/stream", middleware.TokenFromQuery(), jwtMiddleware, notificationHandler.Stream)
+	notificationGroup.Get("/", jwtMiddleware, notificationHandler.GetNotifications)
+	notificationGroup.Get("/unread-count", jwtMiddleware, notificationHandler.GetUnreadCount)
+	notificationGroup.Get("/preferences", jwtMiddleware, notificationHandler.GetPreferences)
+	notificationGroup.Put("/preferences", jwtMiddleware, notificationHandler.UpdatePreferences)
+	notificationGroup.Put("/read-all", jwtMiddleware, notificationHandler.MarkAllRead)
+	notificationGroup.Put("/:id/read", jwtMiddleware, notificationHandler.MarkRead)
+
+	// Chat routes (protected with JWT middleware)
+	chatGroup := app.Group("/chat")
+	// browsers cannot set headers on a WebSocket handshake, so it also takes ?token=
+	chatGroup.Get("/ws", middleware.TokenFromQuery(), jwtMiddleware, chatHandler.RequireWebSocket, websocket.New(chatHandler.Live))
+	chatGroup.Post("/conversations", jwtMiddleware, chatHandler.StartConversation)
+	chatGroup.Get("/conversations", jwtMiddleware, chatHandler.GetConversations)
+	chatGroup.Get("/conversations/:id", jwtMiddleware, chatHandler.GetConversation)
+	chatGroup.Get("/conversations/:id/messages", jwtMiddleware, chatHandler.GetMessages)
+	chatGroup.Post("/conversations/:id/messages", jwtMiddleware, chatHandler.SendMessage)
+	chatGroup.Put("/conversations/:id/read", jwtMiddleware, chatHandler.MarkRead)
+
+	// Admin routes (back-office 2FA policy applies)
+	adminGroup := app.Group("/admin", jwtMiddleware, middleware.RequireMFA(twoFactorCfg.RequiredForAdmin))
+	adminGroup.Get("/roles", middleware.Require(domain.PermRoleAssign), roleHandler.GetAllRoles)
+	adminGroup.Get("/users/:id/roles", middleware.Require(domain.PermRoleAssign), roleHandler.GetUserRoles)
+	adminGroup.Put("/users/:id/roles", middleware.Require(domain.PermRoleAssign), roleHandler.AssignUserRoles)
+	adminGroup.Get("/audit", middleware.Require(domain.PermAuditRead), auditHandler.GetAuditLogs)
+	adminGroup.Get("/products/moderation", middleware.Require(domain.PermProductModerate), productHandler.GetModerationQueue)
+	adminGroup.Put("/products/:id/approve", middleware.Require(domain.PermProductModerate), productHandler.ApproveProduct)
+	adminGroup.Put("/products/:id/reject", middleware.Require(domain.PermProductModerate), productHandler.RejectProduct)
+	adminGroup.Get("/products/:id/history", middleware.Require(domain.PermProductModerate), productHandler.GetProductHistory(true))
+	adminGroup.Get("/products/:id/history/daily", middleware.Require(domain.PermProductModerate), productHandler.GetProductPriceSeries(true))
+	adminGroup.Get("/qa/reports", middleware.Require(domain.PermProductModerate), productQAHandler.GetReported)
+	adminGroup.Put("/qa/questions/:id/hide", middleware.Require(domain.PermProductModerate), productQAHandler.Moderate(domain.QATargetQuestion))
+	adminGroup.Put("/qa/answers/:id/hide", middleware.Require(domain.PermProductModerate), productQAHandler.Moderate(domain.QATargetAnswer))
 
 	// Province & City routes (public, proxy to EMSIFA API)
 	provCityGroup := app.Group("/provcity")
@@ -100,4 +389,6 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
 	provCityGroup.Get("/listcities/:prov_id", provinceCityHandler.GetListCities)
 
 	// TODO: register other feature routes (user, toko, alamat, kategori, produk, trx)
-}
\ No newline at end of file
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// SuggestHandler handles HTTP requests for search suggestions.
+type SuggestHandler struct {
+	suggestUC usecase.SuggestUsecase
+}
+
+// NewSuggestHandler creates a new SuggestHandler.
+func NewSuggestHandler(suggestUC usecase.SuggestUsecase) *SuggestHandler {
+	return &SuggestHandler{suggestUC: suggestUC}
+}
+
+// Suggest handles GET /product/suggest?q=&limit=.
+func (h *SuggestHandler) Suggest(c *fiber.Ctx) error {
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	suggestions, err := h.suggestUC.Suggest(c.Query("q"), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(suggestions))
+	for _, s := range suggestions {
+		item := fiber.Map{
+			"type": s.Type,
+			"text": s.Text,
+			"sold": s.Sold,
+		}
+		if s.ID != 0 {
+			item["id"] = s.ID
+		}
+		data = append(data, item)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,9 +25,13 @@ func (h *TokoHandler) GetAllToko(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	nama := c.Query("nama")
 
-	result, err := h.tokoUC.GetAll(limit, page, nama)
+	result, err := h.tokoUC.GetAll(limit, page, nama, c.Query("cursor"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to GET data",
 			"errors":  []string{err.Error()},
@@ -40,9 +45,11 @@ func (h *TokoHandler) GetAllToko(c *fiber.Ctx) error {
 		"message": "Succeed to GET data",
 		"errors":  nil,
 		"data": fiber.Map{
-			"page":  result.Page,
-			"limit": result.Limit,
-			"data":  result.Data,
+			"page":        result.Page,
+			"limit":       result.Limit,
+			"data":        result.Data,
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
 		},
 	})
 }
@@ -133,10 +140,10 @@ func (h *TokoHandler) GetMyToko(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateMyToko handles PUT /toko (update store owned by logged-in user).
+// UpdateMyToko handles PUT /toko (update store owned by logged-in user) and
+// PUT /toko/:id_toko (update a toko the user is an admin of).
 func (h *TokoHandler) UpdateMyToko(c *fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
-	userID, ok := userIDVal.(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -156,8 +163,29 @@ func (h *TokoHandler) UpdateMyToko(c *fiber.Ctx) error {
 		})
 	}
 
-	toko, err := h.tokoUC.UpdateMyStore(userID, in)
+	if idParam := c.Params("id_toko"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to PUT data",
+				"errors":  []string{"invalid id"},
+				"data":    nil,
+			})
+		}
+		in.TokoID = uint(id)
+	}
+
+	toko, err := h.tokoUC.UpdateMyStore(actor, in)
 	if err != nil {
+		if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  false,
+				"message": "Failed to PUT data",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to PUT data",
@@ -0,0 +1,347 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// TokoMemberHandler handles HTTP requests for toko staff and invitations.
+type TokoMemberHandler struct {
+	memberUC usecase.TokoMemberUsecase
+}
+
+// NewTokoMemberHandler creates a new TokoMemberHandler.
+func NewTokoMemberHandler(memberUC usecase.TokoMemberUsecase) *TokoMemberHandler {
+	return &TokoMemberHandler{memberUC: memberUC}
+}
+
+// GetMyMemberships handles GET /toko/memberships.
+func (h *TokoMemberHandler) GetMyMemberships(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	members, err := h.memberUC.GetMyMemberships(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(members))
+	for i := range members {
+		data = append(data, fiber.Map{
+			"role": members[i].Role,
+			"toko": fiber.Map{
+				"id":        members[i].Toko.ID,
+				"nama_toko": members[i].Toko.NamaToko,
+				"url_foto":  members[i].Toko.UrlFoto,
+			},
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// GetMembers handles GET /toko/:id/members.
+func (h *TokoMemberHandler) GetMembers(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	members, err := h.memberUC.GetMembers(userID, uint(tokoID))
+	if err != nil {
+		return c.Status(tokoMemberErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(members))
+	for i := range members {
+		data = append(data, buildTokoMemberResponse(&members[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// InviteMember handles POST /toko/:id/members/invite.
+func (h *TokoMemberHandler) InviteMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.InviteMemberInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	invitation, err := h.memberUC.Invite(userID, uint(tokoID), in)
+	if err != nil {
+		return c.Status(tokoMemberErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":         invitation.ID,
+			"channel":    invitation.Channel,
+			"target":     invitation.Target,
+			"role":       invitation.Role,
+			"expires_at": invitation.ExpiresAt,
+		},
+	})
+}
+
+// AcceptInvitation handles POST /toko/invitations/accept.
+func (h *TokoMemberHandler) AcceptInvitation(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.AcceptInvitationInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	member, err := h.memberUC.AcceptInvitation(userID, in)
+	if err != nil {
+		return c.Status(tokoMemberErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"role": member.Role,
+			"toko": fiber.Map{
+				"id":        member.Toko.ID,
+				"nama_toko": member.Toko.NamaToko,
+				"url_foto":  member.Toko.UrlFoto,
+			},
+		},
+	})
+}
+
+// UpdateMember handles PUT /toko/:id/members/:user_id.
+func (h *TokoMemberHandler) UpdateMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	memberUserID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid user_id"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.UpdateMemberInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	member, err := h.memberUC.UpdateMember(userID, uint(tokoID), uint(memberUserID), in)
+	if err != nil {
+		return c.Status(tokoMemberErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id_user": member.UserID,
+			"role":    member.Role,
+		},
+	})
+}
+
+// RemoveMember handles DELETE /toko/:id/members/:user_id.
+func (h *TokoMemberHandler) RemoveMember(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	memberUserID, err := strconv.Atoi(c.Params("user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{"invalid user_id"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.memberUC.RemoveMember(userID, uint(tokoID), uint(memberUserID)); err != nil {
+		return c.Status(tokoMemberErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to DELETE data",
+		"errors":  nil,
+		"data":    "",
+	})
+}
+
+// tokoMemberErrorStatus maps membership usecase errors to HTTP status codes.
+func tokoMemberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrTokoNotFound), errors.Is(err, usecase.ErrMemberNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied), errors.Is(err, usecase.ErrOwnerImmutable):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrInvalidTokoRole), errors.Is(err, usecase.ErrInviteTargetRequired), errors.Is(err, usecase.ErrInvalidInvitation):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildTokoMemberResponse maps a toko member with its user to JSON.
+func buildTokoMemberResponse(m *domain.TokoMember) fiber.Map {
+	return fiber.Map{
+		"id_user":    m.UserID,
+		"nama":       m.User.Nama,
+		"no_telp":    m.User.NoTelp,
+		"email":      m.User.Email,
+		"role":       m.Role,
+		"created_at": m.CreatedAt,
+	}
+}
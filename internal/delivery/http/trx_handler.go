@@ -42,9 +42,15 @@ func (h *TrxHandler) GetAllTrx(c *fiber.Ctx) error {
 		})
 	}
 
-	trxs, err := h.trxUC.GetAll(userID)
+	limit, _ := strconv.Atoi(c.Query("limit", "0"))
+
+	result, err := h.trxUC.GetAll(userID, limit, c.Query("cursor"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
 			"status":  false,
 			"message": "Failed to GET data",
 			"errors":  []string{err.Error()},
@@ -52,9 +58,9 @@ func (h *TrxHandler) GetAllTrx(c *fiber.Ctx) error {
 		})
 	}
 
-	list := make([]fiber.Map, 0, len(trxs))
-	for i := range trxs {
-		list = append(list, buildTrxResponse(&trxs[i]))
+	list := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		list = append(list, buildTrxResponse(&result.Data[i]))
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -62,9 +68,11 @@ func (h *TrxHandler) GetAllTrx(c *fiber.Ctx) error {
 		"message": "Succeed to GET data",
 		"errors":  nil,
 		"data": fiber.Map{
-			"data":  list,
-			"page":  0,
-			"limit": 0,
+			"data":        list,
+			"page":        0,
+			"limit":       result.Limit,
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
 		},
 	})
 }
@@ -119,10 +127,136 @@ func (h *TrxHandler) GetTrxByID(c *fiber.Ctx) error {
 	})
 }
 
+// GetTokoOrders handles GET /toko/orders?toko_id= for staff handling a toko's orders.
+func (h *TrxHandler) GetTokoOrders(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "0"))
+
+	result, err := h.trxUC.GetTokoOrders(actor, uint(tokoID), limit, c.Query("cursor"))
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			statusCode = fiber.StatusBadRequest
+		} else if errors.Is(err, usecase.ErrTokoNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrTokoAccessDenied) {
+			statusCode = fiber.StatusForbidden
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(result.Data))
+	for i := range result.Data {
+		list = append(list, buildTrxResponse(&result.Data[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"data":        list,
+			"page":        0,
+			"limit":       result.Limit,
+			"next_cursor": result.NextCursor,
+			"prev_cursor": result.PrevCursor,
+		},
+	})
+}
+
+// shipTokoOrderRequest is the body of POST /toko/orders/:id/ship.
+type shipTokoOrderRequest struct {
+	TokoID uint   `json:"toko_id"`
+	NoResi string `json:"no_resi"`
+}
+
+// ShipTokoOrder handles POST /toko/orders/:id/ship, marking the toko's lines
+// of the trx as shipped.
+func (h *TrxHandler) ShipTokoOrder(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var req shipTokoOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	trx, err := h.trxUC.ShipTokoOrder(actor, req.TokoID, uint(id), req.NoResi)
+	if err != nil {
+		statusCode := fiber.StatusBadRequest
+		switch {
+		case errors.Is(err, usecase.ErrTrxNotFound), errors.Is(err, usecase.ErrTokoNotFound):
+			statusCode = fiber.StatusNotFound
+		case errors.Is(err, usecase.ErrTokoAccessDenied):
+			statusCode = fiber.StatusForbidden
+		case errors.Is(err, usecase.ErrTrxAlreadyShipped):
+			statusCode = fiber.StatusConflict
+		}
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildTrxResponse(trx),
+	})
+}
+
 // PostTrx handles POST /trx.
 func (h *TrxHandler) PostTrx(c *fiber.Ctx) error {
-	userIDVal := c.Locals("user_id")
-	userID, ok := userIDVal.(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -153,7 +287,7 @@ func (h *TrxHandler) PostTrx(c *fiber.Ctx) error {
 		})
 	}
 
-	trx, err := h.trxUC.Create(userID, in)
+	trx, err := h.trxUC.Create(actor, in)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
@@ -164,12 +298,27 @@ func (h *TrxHandler) PostTrx(c *fiber.Ctx) error {
 		} else if errors.Is(err, usecase.ErrTrxProductNotFound) {
 			statusCode = fiber.StatusBadRequest
 			errs = append(errs, "product tidak ditemukan")
+		} else if errors.Is(err, usecase.ErrTrxProductArchived) {
+			statusCode = fiber.StatusBadRequest
+			errs = append(errs, err.Error())
+		} else if errors.Is(err, usecase.ErrTrxVariantNotFound) {
+			statusCode = fiber.StatusBadRequest
+			errs = append(errs, "variant tidak ditemukan")
+		} else if errors.Is(err, usecase.ErrTrxVariantRequired) {
+			statusCode = fiber.StatusBadRequest
+			errs = append(errs, err.Error())
 		} else if errors.Is(err, usecase.ErrTrxInsufficientStock) {
 			statusCode = fiber.StatusBadRequest
 			errs = append(errs, "stok tidak cukup")
+		} else if errors.Is(err, usecase.ErrTrxPromotionEnded) || errors.Is(err, usecase.ErrTrxPromotionSoldOut) || errors.Is(err, usecase.ErrTrxPromotionLimitReached) {
+			statusCode = fiber.StatusBadRequest
+			errs = append(errs, err.Error())
 		} else if errors.Is(err, usecase.ErrTrxEmptyDetail) {
 			statusCode = fiber.StatusBadRequest
 			errs = append(errs, "detail_trx tidak boleh kosong")
+		} else if errors.Is(err, usecase.ErrAccountNotVerified) {
+			statusCode = fiber.StatusForbidden
+			errs = append(errs, err.Error())
 		} else {
 			errs = append(errs, err.Error())
 		}
@@ -194,11 +343,11 @@ func (h *TrxHandler) PostTrx(c *fiber.Ctx) error {
 func buildTrxResponse(trx *domain.Trx) fiber.Map {
 	// Build alamat_kirim object
 	alamat := fiber.Map{
-		"id":             trx.Alamat.ID,
-		"judul_alamat":   trx.Alamat.JudulAlamat,
-		"nama_penerima":  trx.Alamat.NamaPenerima,
-		"no_telp":        trx.Alamat.NoTelp,
-		"detail_alamat":  trx.Alamat.DetailAlamat,
+		"id":            trx.Alamat.ID,
+		"judul_alamat":  trx.Alamat.JudulAlamat,
+		"nama_penerima": trx.Alamat.NamaPenerima,
+		"no_telp":       trx.Alamat.NoTelp,
+		"detail_alamat": trx.Alamat.DetailAlamat,
 	}
 
 	// Build detail_trx list
@@ -213,10 +362,13 @@ func buildTrxResponse(trx *domain.Trx) fiber.Map {
 		}
 
 		details = append(details, fiber.Map{
+			"id":          d.ID,
 			"product":     productMap,
 			"toko":        tokoMap,
 			"kuantitas":   d.Kuantitas,
 			"harga_total": d.HargaTotal,
+			"no_resi":     d.NoResi,
+			"shipped_at":  d.ShippedAt,
 		})
 	}
 
@@ -254,15 +406,33 @@ func buildProductFromLog(log *domain.LogProduk) fiber.Map {
 		"nama_category": log.Category.Nama,
 	}
 
+	var variant fiber.Map
+	if log.VariantID != nil {
+		variant = fiber.Map{
+			"id":         *log.VariantID,
+			"sku":        log.SKU,
+			"attributes": log.VariantAttributeMap(),
+		}
+	}
+
+	// a line priced at a promotion keeps the normal price to strike through
+	var hargaNormal interface{}
+	if log.PromotionID != nil {
+		hargaNormal, _ = strconv.Atoi(log.HargaKonsumenNormal)
+	}
+
 	return fiber.Map{
-		"id":             log.ProdukID,
-		"nama_produk":    log.NamaProduk,
-		"slug":           log.Slug,
-		"harga_reseler":  hargaReseller,
-		"harga_konsumen": hargaKonsumen,
-		"deskripsi":      log.Deskripsi,
-		"toko":           tokoMap,
-		"category":       categoryMap,
-		"photos":         photos,
+		"id":                    log.ProdukID,
+		"nama_produk":           log.NamaProduk,
+		"slug":                  log.Slug,
+		"harga_reseler":         hargaReseller,
+		"harga_konsumen":        hargaKonsumen,
+		"harga_konsumen_normal": hargaNormal,
+		"promotion_id":          log.PromotionID,
+		"deskripsi":             log.Deskripsi,
+		"toko":                  tokoMap,
+		"category":              categoryMap,
+		"photos":                photos,
+		"variant":               variant,
 	}
 }
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// TwoFactorHandler handles TOTP enrollment for the authenticated user.
+type TwoFactorHandler struct {
+	twoFactorUC usecase.TwoFactorUsecase
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler.
+func NewTwoFactorHandler(twoFactorUC usecase.TwoFactorUsecase) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorUC: twoFactorUC}
+}
+
+// twoFactorErrorStatus maps 2FA usecase errors to HTTP status codes.
+func twoFactorErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTOTPAlreadyEnabled),
+		errors.Is(err, usecase.ErrTOTPNotEnrolled),
+		errors.Is(err, usecase.ErrInvalidTOTP),
+		errors.Is(err, usecase.ErrWrongPassword):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Enroll handles POST /user/2fa/enroll.
+func (h *TwoFactorHandler) Enroll(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	enrollment, err := h.twoFactorUC.Enroll(userID)
+	if err != nil {
+		return c.Status(twoFactorErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    enrollment,
+	})
+}
+
+// Confirm handles POST /user/2fa/confirm.
+func (h *TwoFactorHandler) Confirm(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.ConfirmTOTPInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	confirmation, err := h.twoFactorUC.Confirm(actor, in)
+	if err != nil {
+		return c.Status(twoFactorErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    confirmation,
+	})
+}
+
+// Disable handles POST /user/2fa/disable.
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.DisableTOTPInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	token, err := h.twoFactorUC.Disable(actor, in)
+	if err != nil {
+		return c.Status(twoFactorErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"token": token,
+		},
+	})
+}
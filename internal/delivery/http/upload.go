@@ -0,0 +1,88 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadDir is where uploaded files are stored.
+const uploadDir = "uploads"
+
+// imageExtensions are the file types accepted where only images make sense.
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+}
+
+// errInvalidUpload indicates an uploaded file with a type that is not allowed.
+var errInvalidUpload = errors.New("tipe file tidak didukung")
+
+// saveUploadedFiles saves the multipart files under field into the uploads
+// directory and returns their stored filenames. When allowed is non-nil,
+// files whose extension is not in it are rejected before anything is saved.
+func saveUploadedFiles(c *fiber.Ctx, field string, allowed map[string]bool) ([]string, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		// Not a multipart request or no files - treat as no files.
+		return []string{}, nil
+	}
+
+	files := form.File[field]
+	if len(files) == 0 {
+		return []string{}, nil
+	}
+
+	if allowed != nil {
+		for _, fh := range files {
+			if fh != nil && !allowed[strings.ToLower(filepath.Ext(fh.Filename))] {
+				return nil, errInvalidUpload
+			}
+		}
+	}
+
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	var filenames []string
+	for _, fh := range files {
+		if fh == nil {
+			continue
+		}
+		filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(fh.Filename))
+		fullpath := filepath.Join(uploadDir, filename)
+		if err := c.SaveFile(fh, fullpath); err != nil {
+			return nil, err
+		}
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, nil
+}
+
+// uploadImageStore saves images that arrive outside multipart forms, such
+// as those in a product import zip, into the uploads directory.
+type uploadImageStore struct{}
+
+func (uploadImageStore) SaveImage(name string, data []byte) (string, error) {
+	if !imageExtensions[strings.ToLower(filepath.Ext(name))] {
+		return "", errInvalidUpload
+	}
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	filename := fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(name))
+	if err := os.WriteFile(filepath.Join(uploadDir, filename), data, 0o644); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
@@ -55,22 +55,24 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 		"message": "Succeed to GET data",
 		"errors":  nil,
 		"data": fiber.Map{
-			"id":            user.ID,
-			"nama":          user.Nama,
-			"no_telp":       user.NoTelp,
-			"tanggal_Lahir": user.TanggalLahir,
-			"tentang":       user.Tentang,
-			"pekerjaan":     user.Pekerjaan,
-			"email":         user.Email,
-			"id_provinsi":   user.IDProvinsi,
-			"id_kota":       user.IDKota,
+			"id":               user.ID,
+			"nama":             user.Nama,
+			"no_telp":          user.NoTelp,
+			"tanggal_Lahir":    user.TanggalLahir,
+			"tentang":          user.Tentang,
+			"pekerjaan":        user.Pekerjaan,
+			"email":            user.Email,
+			"id_provinsi":      user.IDProvinsi,
+			"id_kota":          user.IDKota,
+			"email_verified":   user.EmailVerifiedAt != nil,
+			"no_telp_verified": user.NoTelpVerifiedAt != nil,
 		},
 	})
 }
 
 // UpdateProfile handles PUT /user.
 func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
-	userID, ok := c.Locals("user_id").(uint)
+	actor, ok := requestActor(c)
 	if !ok {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  false,
@@ -90,7 +92,7 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := h.userUC.UpdateProfile(userID, in)
+	user, err := h.userUC.UpdateProfile(actor, in)
 	if err != nil {
 		statusCode := fiber.StatusInternalServerError
 		var errs []string
@@ -127,3 +129,52 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// ChangePassword handles PUT /user/password.
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.ChangePasswordInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	token, err := h.userUC.ChangePassword(actor, in)
+	if err != nil {
+		statusCode := fiber.StatusInternalServerError
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			statusCode = fiber.StatusNotFound
+		} else if errors.Is(err, usecase.ErrWrongPassword) {
+			statusCode = fiber.StatusBadRequest
+		}
+
+		return c.Status(statusCode).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"token": token,
+		},
+	})
+}
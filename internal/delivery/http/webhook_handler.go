@@ -0,0 +1,375 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/usecase"
+)
+
+// WebhookHandler handles HTTP requests for toko webhook endpoints.
+type WebhookHandler struct {
+	webhookUC usecase.WebhookUsecase
+}
+
+// NewWebhookHandler creates a new WebhookHandler.
+func NewWebhookHandler(webhookUC usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{webhookUC: webhookUC}
+}
+
+// GetWebhooks handles GET /toko/webhooks?toko_id=.
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	tokoID, err := strconv.Atoi(c.Query("toko_id", "0"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid toko_id"},
+			"data":    nil,
+		})
+	}
+
+	endpoints, err := h.webhookUC.GetAll(userID, uint(tokoID))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(endpoints))
+	for i := range endpoints {
+		data = append(data, buildWebhookResponse(&endpoints[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// CreateWebhook handles POST /toko/webhooks. The signing secret is only
+// returned in this response.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.CreateWebhookInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	created, err := h.webhookUC.Create(actor, in)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	data := buildWebhookResponse(created.Endpoint)
+	data["secret"] = created.Secret
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    data,
+	})
+}
+
+// UpdateWebhook handles PUT /toko/webhooks/:id.
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	var in usecase.UpdateWebhookInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{"invalid request body"},
+			"data":    nil,
+		})
+	}
+
+	endpoint, err := h.webhookUC.Update(actor, uint(id), in)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to PUT data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to PUT data",
+		"errors":  nil,
+		"data":    buildWebhookResponse(endpoint),
+	})
+}
+
+// DeleteWebhook handles DELETE /toko/webhooks/:id.
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	actor, ok := requestActor(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	if err := h.webhookUC.Delete(actor, uint(id)); err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to DELETE data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to DELETE data",
+		"errors":  nil,
+		"data":    "",
+	})
+}
+
+// PingWebhook handles POST /toko/webhooks/:id/ping.
+func (h *WebhookHandler) PingWebhook(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	delivery, err := h.webhookUC.Ping(userID, uint(id))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildWebhookDeliveryResponse(delivery),
+	})
+}
+
+// GetWebhookDeliveries handles GET /toko/webhooks/:id/deliveries.
+func (h *WebhookHandler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+
+	deliveries, err := h.webhookUC.GetDeliveries(userID, uint(id), limit, page)
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to GET data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	list := make([]fiber.Map, 0, len(deliveries))
+	for i := range deliveries {
+		list = append(list, buildWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to GET data",
+		"errors":  nil,
+		"data": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"data":  list,
+		},
+	})
+}
+
+// RedeliverWebhook handles POST /toko/webhooks/deliveries/:id/redeliver.
+func (h *WebhookHandler) RedeliverWebhook(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid user id in token"},
+			"data":    nil,
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{"invalid id"},
+			"data":    nil,
+		})
+	}
+
+	delivery, err := h.webhookUC.Redeliver(userID, uint(id))
+	if err != nil {
+		return c.Status(webhookErrorStatus(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Failed to POST data",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  true,
+		"message": "Succeed to POST data",
+		"errors":  nil,
+		"data":    buildWebhookDeliveryResponse(delivery),
+	})
+}
+
+// webhookErrorStatus maps webhook usecase errors to HTTP status codes.
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, usecase.ErrWebhookNotFound), errors.Is(err, usecase.ErrTokoNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, usecase.ErrTokoAccessDenied):
+		return fiber.StatusForbidden
+	case errors.Is(err, usecase.ErrInvalidWebhookURL), errors.Is(err, usecase.ErrWebhookURLNotPublic), errors.Is(err, usecase.ErrInvalidWebhookEvent):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// buildWebhookResponse maps a webhook endpoint to JSON without its secret.
+func buildWebhookResponse(e *domain.WebhookEndpoint) fiber.Map {
+	return fiber.Map{
+		"id":         e.ID,
+		"toko_id":    e.TokoID,
+		"url":        e.URL,
+		"events":     e.EventList(),
+		"active":     e.Active,
+		"created_at": e.CreatedAt,
+		"updated_at": e.UpdatedAt,
+	}
+}
+
+// buildWebhookDeliveryResponse maps a delivery log row to JSON.
+func buildWebhookDeliveryResponse(d *domain.WebhookDelivery) fiber.Map {
+	return fiber.Map{
+		"id":               d.ID,
+		"endpoint_id":      d.EndpointID,
+		"event":            d.Event,
+		"payload":          json.RawMessage(d.Payload),
+		"status":           d.Status,
+		"attempts":         d.Attempts,
+		"next_attempt_at":  d.NextAttemptAt,
+		"last_status_code": d.LastStatusCode,
+		"last_error":       d.LastError,
+		"delivered_at":     d.DeliveredAt,
+		"created_at":       d.CreatedAt,
+	}
+}
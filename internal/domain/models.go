@@ -1,6 +1,13 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // User represents the user table.
 type User struct {
@@ -16,18 +23,210 @@ type User struct {
 	IDProvinsi   string    `gorm:"column:id_provinsi;size:255"`
 	IDKota       string    `gorm:"column:id_kota;size:255"`
 	IsAdmin      bool      `gorm:"column:is_admin"`
+	// SessionVersion is embedded in issued JWTs; bumping it revokes every
+	// token issued before the bump (password change/reset).
+	SessionVersion   uint       `gorm:"column:session_version;not null;default:0"`
+	EmailVerifiedAt  *time.Time `gorm:"column:email_verified_at"`
+	NoTelpVerifiedAt *time.Time `gorm:"column:notelp_verified_at"`
+	// TOTPSecret is set on enrollment; 2FA is active once TOTPEnabledAt is set.
+	TOTPSecret    string     `gorm:"column:totp_secret;size:64" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at"`
+	// TOTPLastStep is the time step of the last accepted code; codes for
+	// that step or earlier are refused.
+	TOTPLastStep int64 `gorm:"column:totp_last_step;not null;default:0" json:"-"`
 
 	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
 
 	// Relations
-	Toko      *Toko   `gorm:"foreignKey:UserID"`
+	Toko      *Toko    `gorm:"foreignKey:UserID"`
 	Alamat    []Alamat `gorm:"foreignKey:UserID"`
 	Transaksi []Trx    `gorm:"foreignKey:UserID"`
+	Roles     []Role   `gorm:"many2many:user_role;joinForeignKey:id_user;joinReferences:id_role"`
 }
 
 func (User) TableName() string { return "user" }
 
+// RoleNames returns the names of the user's loaded roles.
+func (u *User) RoleNames() []string {
+	names := make([]string, 0, len(u.Roles))
+	for _, r := range u.Roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// PermissionNames returns the distinct permissions of the user's loaded roles.
+func (u *User) PermissionNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range u.Roles {
+		for _, p := range r.Permissions {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Role represents the role table.
+type Role struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	Name        string    `gorm:"column:name;size:100;uniqueIndex;not null"`
+	Description string    `gorm:"column:description;size:255"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+
+	Permissions []Permission `gorm:"many2many:role_permission;joinForeignKey:id_role;joinReferences:id_permission"`
+}
+
+func (Role) TableName() string { return "role" }
+
+// Permission represents the permission table.
+type Permission struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	Name      string    `gorm:"column:name;size:100;uniqueIndex;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Permission) TableName() string { return "permission" }
+
+// DataMigration represents the data_migration table: one row per one-off
+// data fix that has been applied, so it is not run again on restart.
+type DataMigration struct {
+	Name      string    `gorm:"column:name;size:100;primaryKey"`
+	AppliedAt time.Time `gorm:"column:applied_at;autoCreateTime"`
+}
+
+func (DataMigration) TableName() string { return "data_migration" }
+
+// DataMigrationUserRoles marks the backfill of roles for users created
+// before roles existed.
+const DataMigrationUserRoles = "backfill_user_roles"
+
+// UserOTP represents the user_otp table holding one-time codes sent to a user.
+// IP is the client that requested the code, for the per-IP send limit.
+type UserOTP struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `gorm:"column:id_user;not null;index"`
+	Purpose   string     `gorm:"column:purpose;size:50;not null"`
+	Channel   string     `gorm:"column:channel;size:20;not null"`
+	Target    string     `gorm:"column:target;size:255;not null"`
+	IP        string     `gorm:"column:ip;size:45;index"`
+	CodeHash  string     `gorm:"column:code_hash;size:255;not null"`
+	Attempts  int        `gorm:"column:attempts;not null;default:0"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (UserOTP) TableName() string { return "user_otp" }
+
+// RecoveryCode represents the recovery_code table of one-time 2FA backup codes.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `gorm:"column:id_user;not null;index"`
+	CodeHash  string     `gorm:"column:code_hash;size:255;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (RecoveryCode) TableName() string { return "recovery_code" }
+
+// LoginAttempt represents the login_attempt table tracking failed logins
+// per key ("account:<no_telp>" or "ip:<address>").
+type LoginAttempt struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	Key           string     `gorm:"column:attempt_key;size:255;uniqueIndex;not null"`
+	Failures      int        `gorm:"column:failures;not null;default:0"`
+	LastFailureAt time.Time  `gorm:"column:last_failure_at"`
+	LockedUntil   *time.Time `gorm:"column:locked_until"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (LoginAttempt) TableName() string { return "login_attempt" }
+
+// SecurityEvent represents the security_event table (lockouts and other auth events).
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	Type      string    `gorm:"column:type;size:50;not null;index"`
+	UserID    *uint     `gorm:"column:id_user"`
+	IP        string    `gorm:"column:ip;size:64"`
+	Detail    string    `gorm:"column:detail;type:text"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (SecurityEvent) TableName() string { return "security_event" }
+
+// Audit log actions.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditLog represents the audit_log table. BeforeData/AfterData are JSON
+// snapshots of the entity and Diff maps each changed field to {from, to}.
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `gorm:"column:id_user;index"`
+	ActorRoles string    `gorm:"column:actor_roles;size:255"`
+	Action     string    `gorm:"column:action;size:20;not null"`
+	EntityType string    `gorm:"column:entity_type;size:50;not null;index:idx_audit_entity"`
+	EntityID   uint      `gorm:"column:entity_id;index:idx_audit_entity"`
+	BeforeData string    `gorm:"column:before_data;type:text"`
+	AfterData  string    `gorm:"column:after_data;type:text"`
+	Diff       string    `gorm:"column:diff;type:text"`
+	APIKeyID   *uint     `gorm:"column:id_api_key"`
+	IP         string    `gorm:"column:ip;size:64"`
+	RequestID  string    `gorm:"column:request_id;size:64;index"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime;index"`
+}
+
+func (AuditLog) TableName() string { return "audit_log" }
+
+// APIKey represents the api_key table. Only the SHA-256 hash of the key is
+// stored; Prefix is kept in clear so users can tell their keys apart.
+// Scopes is a comma-separated list; a non-nil TokoID binds the key to one toko.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	UserID     uint       `gorm:"column:id_user;not null;index"`
+	TokoID     *uint      `gorm:"column:id_toko"`
+	Name       string     `gorm:"column:name;size:100;not null"`
+	Prefix     string     `gorm:"column:prefix;size:16;not null"`
+	KeyHash    string     `gorm:"column:key_hash;size:64;uniqueIndex;not null"`
+	Scopes     string     `gorm:"column:scopes;size:255;not null"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime"`
+
+	Toko *Toko `gorm:"foreignKey:TokoID;references:ID"`
+}
+
+func (APIKey) TableName() string { return "api_key" }
+
+// ScopeList returns the key's scopes.
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// Active reports whether the key can still be used at t.
+func (k *APIKey) Active(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
 // Toko represents the toko table.
 type Toko struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
@@ -43,6 +242,290 @@ type Toko struct {
 
 func (Toko) TableName() string { return "toko" }
 
+// TokoMember represents the toko_member table linking users to a toko.
+type TokoMember struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	TokoID    uint      `gorm:"column:id_toko;not null;uniqueIndex:idx_toko_member"`
+	UserID    uint      `gorm:"column:id_user;not null;uniqueIndex:idx_toko_member"`
+	Role      string    `gorm:"column:role;size:50;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+
+	Toko Toko `gorm:"foreignKey:TokoID;references:ID"`
+	User User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (TokoMember) TableName() string { return "toko_member" }
+
+// TokoInvitation represents the toko_invitation table. Target is the
+// invitee's no_telp or email, depending on Channel.
+type TokoInvitation struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement"`
+	TokoID     uint       `gorm:"column:id_toko;not null;index"`
+	InvitedBy  uint       `gorm:"column:invited_by;not null"`
+	Channel    string     `gorm:"column:channel;size:20;not null"`
+	Target     string     `gorm:"column:target;size:255;not null"`
+	Role       string     `gorm:"column:role;size:50;not null"`
+	TokenHash  string     `gorm:"column:token_hash;size:255;uniqueIndex;not null"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null"`
+	AcceptedAt *time.Time `gorm:"column:accepted_at"`
+	AcceptedBy *uint      `gorm:"column:accepted_by"`
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	Toko Toko `gorm:"foreignKey:TokoID;references:ID"`
+}
+
+func (TokoInvitation) TableName() string { return "toko_invitation" }
+
+// Webhook event types sent to toko endpoints.
+const (
+	WebhookEventPing           = "ping"
+	WebhookEventTrxCreated     = "trx.created"
+	WebhookEventOrderShipped   = "order.shipped"
+	WebhookEventStockDecreased = "stock.decreased"
+)
+
+// WebhookEvents lists the events an endpoint may subscribe to.
+var WebhookEvents = []string{WebhookEventTrxCreated, WebhookEventOrderShipped, WebhookEventStockDecreased}
+
+// WebhookEndpoint represents the webhook_endpoint table. Events is a
+// comma-separated subscription list; Secret signs every payload.
+type WebhookEndpoint struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	TokoID    uint      `gorm:"column:id_toko;not null;index"`
+	URL       string    `gorm:"column:url;size:500;not null"`
+	Secret    string    `gorm:"column:secret;size:100;not null" json:"-"`
+	Events    string    `gorm:"column:events;size:255;not null"`
+	Active    bool      `gorm:"column:active;not null;default:true"`
+	CreatedBy uint      `gorm:"column:created_by;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (WebhookEndpoint) TableName() string { return "webhook_endpoint" }
+
+// EventList returns the subscribed events.
+func (e *WebhookEndpoint) EventList() []string {
+	if e.Events == "" {
+		return nil
+	}
+	return strings.Split(e.Events, ",")
+}
+
+// Subscribed reports whether the endpoint wants event; ping always goes out.
+func (e *WebhookEndpoint) Subscribed(event string) bool {
+	if event == WebhookEventPing {
+		return true
+	}
+	for _, ev := range e.EventList() {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses.
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusSucceeded = "succeeded"
+	WebhookStatusFailed    = "failed"
+)
+
+// WebhookDelivery represents the webhook_delivery table: one payload to one
+// endpoint with its retry state. Pending rows are sent once NextAttemptAt passes.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement"`
+	EndpointID     uint       `gorm:"column:id_endpoint;not null;index"`
+	Event          string     `gorm:"column:event;size:50;not null"`
+	Payload        string     `gorm:"column:payload;type:text;not null"`
+	Status         string     `gorm:"column:status;size:20;not null;index:idx_webhook_due"`
+	Attempts       int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;index:idx_webhook_due"`
+	LastStatusCode int        `gorm:"column:last_status_code"`
+	LastError      string     `gorm:"column:last_error;size:500"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	Endpoint WebhookEndpoint `gorm:"foreignKey:EndpointID;references:ID"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_delivery" }
+
+// Domain event types written to the outbox.
+const (
+	EventTrxCreated     = "trx.created"
+	EventOrderShipped   = "order.shipped"
+	EventProductCreated = "product.created"
+	EventProductUpdated = "product.updated"
+	EventProductDeleted = "product.deleted"
+	// EventProductApproved and EventProductRejected are moderation
+	// decisions; the status change itself also emits EventProductUpdated.
+	EventProductApproved = "product.approved"
+	EventProductRejected = "product.rejected"
+	EventStockChanged    = "stock.changed"
+	EventUserRegistered  = "user.registered"
+)
+
+// Aggregate types that domain events belong to. Events of one aggregate
+// are dispatched in the order they were written.
+const (
+	AggregateTrx     = "trx"
+	AggregateProduct = "product"
+	AggregateUser    = "user"
+)
+
+// OutboxEvent represents the outbox_event table. Rows are written in the
+// same transaction as the state change and dispatched afterwards; the row
+// is kept until DispatchedAt is set and retention removes it.
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	AggregateType string     `gorm:"column:aggregate_type;size:50;not null;index:idx_outbox_aggregate"`
+	AggregateID   uint       `gorm:"column:aggregate_id;not null;index:idx_outbox_aggregate"`
+	EventType     string     `gorm:"column:event_type;size:50;not null"`
+	Payload       string     `gorm:"column:payload;type:text;not null"`
+	Attempts      int        `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index"`
+	LastError     string     `gorm:"column:last_error;size:500"`
+	DispatchedAt  *time.Time `gorm:"column:dispatched_at;index"`
+	// FailedAt is set when the event is parked after too many attempts; it
+	// is no longer retried and no longer holds back its aggregate.
+	FailedAt  *time.Time `gorm:"column:failed_at;index"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (OutboxEvent) TableName() string { return "outbox_event" }
+
+// OutboxAck represents the outbox_ack table: a subscriber that has already
+// handled an event, so a retry after a partial failure skips it.
+type OutboxAck struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	EventID    uint      `gorm:"column:id_event;not null;uniqueIndex:idx_outbox_ack"`
+	Subscriber string    `gorm:"column:subscriber;size:100;not null;uniqueIndex:idx_outbox_ack"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (OutboxAck) TableName() string { return "outbox_ack" }
+
+// Notification types shown in the notification center.
+const (
+	NotifOrderPlaced     = "order_placed"
+	NotifOrderShipped    = "order_shipped"
+	NotifLowStock        = "low_stock"
+	NotifProductApproved = "product_approved"
+	NotifProductRejected = "product_rejected"
+)
+
+// NotificationTypes lists every notification type users can configure.
+var NotificationTypes = []string{NotifOrderPlaced, NotifOrderShipped, NotifLowStock, NotifProductApproved, NotifProductRejected}
+
+// Notification delivery channels. Email and SMS go out through the notifier.
+const (
+	NotifChannelInApp = "in_app"
+	NotifChannelEmail = "email"
+	NotifChannelSMS   = "sms"
+)
+
+// NotificationChannels lists every delivery channel.
+var NotificationChannels = []string{NotifChannelInApp, NotifChannelEmail, NotifChannelSMS}
+
+// NotificationDefaultEnabled reports whether a channel is on for a type
+// before the user changes it: in-app always, email for order updates.
+func NotificationDefaultEnabled(notifType, channel string) bool {
+	switch channel {
+	case NotifChannelInApp:
+		return true
+	case NotifChannelEmail:
+		return notifType == NotifOrderPlaced || notifType == NotifOrderShipped
+	default:
+		return false
+	}
+}
+
+// Notification represents the notification table. EventID is the outbox
+// event that produced it, so a redelivered event does not notify twice.
+// TokoID is the toko the user is notified for as a member, 0 when the
+// notification is about the user's own activity; a buyer who also sells in
+// the order is notified both ways.
+type Notification struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `gorm:"column:id_user;not null;uniqueIndex:idx_notification_recipient;index:idx_notification_user"`
+	EventID   uint       `gorm:"column:id_event;not null;uniqueIndex:idx_notification_recipient"`
+	Type      string     `gorm:"column:type;size:50;not null;uniqueIndex:idx_notification_recipient"`
+	TokoID    uint       `gorm:"column:id_toko;not null;default:0;uniqueIndex:idx_notification_recipient"`
+	Title     string     `gorm:"column:title;size:255;not null"`
+	Body      string     `gorm:"column:body;type:text"`
+	Data      string     `gorm:"column:data;type:text"`
+	ReadAt    *time.Time `gorm:"column:read_at;index:idx_notification_user"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Notification) TableName() string { return "notification" }
+
+// NotificationPreference represents the notification_preference table.
+// A missing row means NotificationDefaultEnabled applies.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `gorm:"column:id_user;not null;uniqueIndex:idx_notification_pref"`
+	Type      string    `gorm:"column:type;size:50;not null;uniqueIndex:idx_notification_pref"`
+	Channel   string    `gorm:"column:channel;size:20;not null;uniqueIndex:idx_notification_pref"`
+	Enabled   bool      `gorm:"column:enabled;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preference" }
+
+// Chat participant sides.
+const (
+	ChatSideBuyer = "buyer"
+	ChatSideToko  = "toko"
+)
+
+// Conversation represents the conversation table: a chat between a buyer
+// and a toko, optionally about one product or trx.
+type Conversation struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	UserID        uint       `gorm:"column:id_user;not null;index"`
+	TokoID        uint       `gorm:"column:id_toko;not null;index"`
+	ProdukID      *uint      `gorm:"column:id_produk"`
+	TrxID         *uint      `gorm:"column:id_trx"`
+	LastMessage   string     `gorm:"column:last_message;size:255"`
+	LastMessageAt *time.Time `gorm:"column:last_message_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
+	Toko Toko `gorm:"foreignKey:TokoID;references:ID"`
+}
+
+func (Conversation) TableName() string { return "conversation" }
+
+// ChatMessage represents the chat_message table. Attachments is a
+// comma-separated list of uploaded image filenames; ReadAt is set when
+// the other side has read the message.
+type ChatMessage struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement"`
+	ConversationID uint       `gorm:"column:id_conversation;not null;index"`
+	SenderID       uint       `gorm:"column:id_sender;not null"`
+	SenderSide     string     `gorm:"column:sender_side;size:10;not null"`
+	Body           string     `gorm:"column:body;type:text"`
+	Attachments    string     `gorm:"column:attachments;type:text"`
+	ReadAt         *time.Time `gorm:"column:read_at"`
+	CreatedAt      time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ChatMessage) TableName() string { return "chat_message" }
+
+// AttachmentList returns the attached filenames.
+func (m *ChatMessage) AttachmentList() []string {
+	if m.Attachments == "" {
+		return nil
+	}
+	return strings.Split(m.Attachments, ",")
+}
+
 // Alamat represents the alamat table.
 type Alamat struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement"`
@@ -71,28 +554,281 @@ type Category struct {
 
 func (Category) TableName() string { return "category" }
 
+// Product publication statuses. Only published products are listed and
+// sold; the others are seen by their toko only.
+const (
+	// ProductStatusDraft is a listing the seller is still preparing.
+	ProductStatusDraft = "draft"
+	// ProductStatusPending waits in the moderation queue.
+	ProductStatusPending = "pending"
+	// ProductStatusPublished is public.
+	ProductStatusPublished = "published"
+	// ProductStatusRejected was turned down by a moderator, see RejectionReason.
+	ProductStatusRejected = "rejected"
+	// ProductStatusScheduled is published automatically at PublishAt.
+	ProductStatusScheduled = "scheduled"
+)
+
+// ProductStatuses lists every product publication status.
+var ProductStatuses = []string{ProductStatusDraft, ProductStatusPending, ProductStatusPublished, ProductStatusRejected, ProductStatusScheduled}
+
 // Produk represents the produk table.
 type Produk struct {
 	ID            uint      `gorm:"primaryKey;autoIncrement"`
 	NamaProduk    string    `gorm:"column:nama_produk;size:255;not null"`
-	Slug          string    `gorm:"column:slug;size:255;not null"`
+	Slug          string    `gorm:"column:slug;size:255;not null;index;uniqueIndex:idx_produk_toko_slug,priority:2"`
 	HargaReseller string    `gorm:"column:harga_reseller;size:255;not null"`
 	HargaKonsumen string    `gorm:"column:harga_konsumen;size:255;not null"`
 	Stok          int       `gorm:"column:stok;not null"`
 	Deskripsi     string    `gorm:"column:deskripsi;type:text"`
 	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime"`
-	TokoID        uint      `gorm:"column:id_toko;not null"`
+	TokoID        uint      `gorm:"column:id_toko;not null;uniqueIndex:idx_produk_toko_slug,priority:1"`
 	CategoryID    uint      `gorm:"column:id_category;not null"`
+	// RatingAvg and RatingCount aggregate the product's reviews; they are
+	// only written by the review repository.
+	RatingAvg   float64 `gorm:"column:rating_avg;not null;default:0;index"`
+	RatingCount int     `gorm:"column:rating_count;not null;default:0"`
+	// Sold is the units sold, only loaded when listing by best selling.
+	Sold int `gorm:"column:sold;->;-:migration"`
+	// Status is the publication status, one of ProductStatuses. It only
+	// changes through the product repository's SetStatus.
+	Status string `gorm:"column:status;size:20;not null;default:published;index"`
+	// PublishAt is when a scheduled product goes public; a pending product
+	// keeps the time asked for until it is approved.
+	PublishAt       *time.Time `gorm:"column:publish_at;index"`
+	RejectionReason string     `gorm:"column:rejection_reason;size:500"`
+	// ArchivedAt is set while the seller has taken the product off sale; it
+	// stays visible to the toko and in past orders.
+	ArchivedAt *time.Time `gorm:"column:archived_at;index"`
+	// DeletedAt soft-deletes the product, so trx history keeps its data.
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	// Promotion is the running promotion the product sells at, if any;
+	// set by the usecase, not stored.
+	Promotion *Promotion `gorm:"-"`
 
-	Toko       Toko        `gorm:"foreignKey:TokoID;references:ID"`
-	Category   Category    `gorm:"foreignKey:CategoryID;references:ID"`
-	FotoProduk []FotoProduk `gorm:"foreignKey:ProdukID"`
-	LogProduk  []LogProduk  `gorm:"foreignKey:ProdukID"`
+	Toko       Toko            `gorm:"foreignKey:TokoID;references:ID"`
+	Category   Category        `gorm:"foreignKey:CategoryID;references:ID"`
+	FotoProduk []FotoProduk    `gorm:"foreignKey:ProdukID"`
+	LogProduk  []LogProduk     `gorm:"foreignKey:ProdukID"`
+	Options    []ProdukOption  `gorm:"foreignKey:ProdukID"`
+	Variants   []ProdukVariant `gorm:"foreignKey:ProdukID"`
 }
 
 func (Produk) TableName() string { return "produk" }
 
+// Unique indexes on product slugs: ProdukSlugIndex on (id_toko, slug)
+// always, ProdukGlobalSlugIndex on slug alone when slugs must be unique
+// across tokos.
+const (
+	ProdukSlugIndex       = "idx_produk_toko_slug"
+	ProdukGlobalSlugIndex = "idx_produk_slug_global"
+)
+
+// DeletedSlugSuffix is appended to the slug of a deleted product, freeing
+// the slug for reuse; "~" never appears in a live slug.
+func DeletedSlugSuffix(id uint) string {
+	return "~" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ProdukSlugHistory represents the produk_slug_history table: a slug a
+// product had before it was renamed, kept so old links still find it.
+type ProdukSlugHistory struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ProdukID  uint      `gorm:"column:id_produk;not null;index"`
+	TokoID    uint      `gorm:"column:id_toko;not null"`
+	Slug      string    `gorm:"column:slug;size:255;not null;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ProdukSlugHistory) TableName() string { return "produk_slug_history" }
+
+// Sources of a product history version.
+const (
+	ProductChangeInitial  = "initial"
+	ProductChangeCreate   = "create"
+	ProductChangeUpdate   = "update"
+	ProductChangeVariants = "variants"
+	ProductChangeOrder    = "order"
+)
+
+// ProdukHistory represents the produk_history table: one version of a
+// product's name, prices and stock, written whenever any of them changes.
+// Version counts up from 1 per product.
+type ProdukHistory struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	ProdukID      uint      `gorm:"column:id_produk;not null;uniqueIndex:idx_produk_history_version"`
+	Version       int       `gorm:"column:version;not null;uniqueIndex:idx_produk_history_version"`
+	NamaProduk    string    `gorm:"column:nama_produk;size:255;not null"`
+	HargaReseller int       `gorm:"column:harga_reseller;not null"`
+	HargaKonsumen int       `gorm:"column:harga_konsumen;not null"`
+	Stok          int       `gorm:"column:stok;not null"`
+	Source        string    `gorm:"column:source;size:20;not null"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime;index"`
+}
+
+func (ProdukHistory) TableName() string { return "produk_history" }
+
+// Promotion discount types.
+const (
+	// PromotionTypePercent takes Value percent off the consumer price.
+	PromotionTypePercent = "percent"
+	// PromotionTypePrice sells at Value where the consumer price is higher.
+	PromotionTypePrice = "price"
+)
+
+// PromotionTypes lists the valid Promotion.Type values.
+var PromotionTypes = []string{PromotionTypePercent, PromotionTypePrice}
+
+// Promotion represents the promotion table: a price cut from StartAt until
+// EndAt on one product, or on a toko's products in one category. A flash
+// sale is a product promotion listed on the flash-sale page. SaleStock
+// caps the units sold at the promotion's price and MaxPerUser the units
+// one buyer gets at it; 0 is unlimited.
+type Promotion struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	TokoID     uint      `gorm:"column:id_toko;not null;index"`
+	ProdukID   *uint     `gorm:"column:id_produk;index"`
+	CategoryID *uint     `gorm:"column:id_category;index"`
+	Nama       string    `gorm:"column:nama;size:100;not null"`
+	Type       string    `gorm:"column:type;size:20;not null"`
+	Value      int       `gorm:"column:value;not null"`
+	StartAt    time.Time `gorm:"column:start_at;not null;index"`
+	EndAt      time.Time `gorm:"column:end_at;not null;index"`
+	FlashSale  bool      `gorm:"column:flash_sale;not null;default:false;index"`
+	MaxPerUser int       `gorm:"column:max_per_user;not null;default:0"`
+	SaleStock  int       `gorm:"column:sale_stock;not null;default:0"`
+	Sold       int       `gorm:"column:sold;not null;default:0"`
+	CreatedBy  uint      `gorm:"column:created_by;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Promotion) TableName() string { return "promotion" }
+
+// Running reports whether the promotion applies at t: within its period
+// and with sale stock left.
+func (p *Promotion) Running(t time.Time) bool {
+	return !t.Before(p.StartAt) && t.Before(p.EndAt) && (p.SaleStock == 0 || p.Sold < p.SaleStock)
+}
+
+// Price is the promotion's price for an item whose consumer price is
+// harga; it is never above harga.
+func (p *Promotion) Price(harga int) int {
+	switch p.Type {
+	case PromotionTypePercent:
+		return harga - harga*p.Value/100
+	case PromotionTypePrice:
+		return min(p.Value, harga)
+	}
+	return harga
+}
+
+// Applies reports whether the promotion covers product. A sale price is
+// one price, so it does not cover a product sold per variant, whose
+// variants may be priced apart.
+func (p *Promotion) Applies(product *Produk) bool {
+	if p.Type == PromotionTypePrice && product.HasVariants() {
+		return false
+	}
+	if p.ProdukID != nil {
+		return *p.ProdukID == product.ID
+	}
+	return p.CategoryID != nil && *p.CategoryID == product.CategoryID && p.TokoID == product.TokoID
+}
+
+// PromotionUsage represents the promotion_usage table: units of a product a
+// user bought at a promotion's price in one trx.
+type PromotionUsage struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	PromotionID uint      `gorm:"column:id_promotion;not null;index:idx_promotion_usage_user"`
+	UserID      uint      `gorm:"column:id_user;not null;index:idx_promotion_usage_user"`
+	TrxID       uint      `gorm:"column:id_trx;not null;index"`
+	ProdukID    uint      `gorm:"column:id_produk;not null"`
+	Kuantitas   int       `gorm:"column:kuantitas;not null"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (PromotionUsage) TableName() string { return "promotion_usage" }
+
+// HasVariants reports whether the product is sold per variant; its Stok is
+// then the sum of the variants' stock.
+func (p *Produk) HasVariants() bool {
+	return len(p.Variants) > 0
+}
+
+// ProdukOption represents the produk_option table: an option type of a
+// product such as "Ukuran" or "Warna", with its allowed values.
+type ProdukOption struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ProdukID  uint      `gorm:"column:id_produk;not null;index"`
+	Nama      string    `gorm:"column:nama;size:100;not null"`
+	Posisi    int       `gorm:"column:posisi;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+
+	Values []ProdukOptionValue `gorm:"foreignKey:OptionID"`
+}
+
+func (ProdukOption) TableName() string { return "produk_option" }
+
+// ProdukOptionValue represents the produk_option_value table.
+type ProdukOptionValue struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	OptionID  uint      `gorm:"column:id_option;not null;index"`
+	Nilai     string    `gorm:"column:nilai;size:100;not null"`
+	Posisi    int       `gorm:"column:posisi;not null"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ProdukOptionValue) TableName() string { return "produk_option_value" }
+
+// ProdukVariant represents the produk_variant table: one combination of
+// option values with its own SKU and stock. Attributes is a JSON object
+// mapping option name to value; empty prices fall back to the product's.
+type ProdukVariant struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	ProdukID      uint      `gorm:"column:id_produk;not null;uniqueIndex:idx_produk_variant_sku"`
+	SKU           string    `gorm:"column:sku;size:100;not null;uniqueIndex:idx_produk_variant_sku"`
+	Attributes    string    `gorm:"column:attributes;type:text;not null"`
+	HargaReseller string    `gorm:"column:harga_reseller;size:255"`
+	HargaKonsumen string    `gorm:"column:harga_konsumen;size:255"`
+	Stok          int       `gorm:"column:stok;not null"`
+	FotoURL       string    `gorm:"column:foto_url;size:255"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ProdukVariant) TableName() string { return "produk_variant" }
+
+// AttributeMap returns the variant's option values by option name.
+func (v *ProdukVariant) AttributeMap() map[string]string {
+	return parseAttributes(v.Attributes)
+}
+
+// parseAttributes decodes a variant attributes JSON object.
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	if s != "" {
+		_ = json.Unmarshal([]byte(s), &attrs)
+	}
+	return attrs
+}
+
+// Prices returns the variant's reseller and consumer prices, falling back
+// to the product's where the variant does not override them.
+func (v *ProdukVariant) Prices(p *Produk) (hargaReseller, hargaKonsumen string) {
+	hargaReseller, hargaKonsumen = p.HargaReseller, p.HargaKonsumen
+	if v.HargaReseller != "" {
+		hargaReseller = v.HargaReseller
+	}
+	if v.HargaKonsumen != "" {
+		hargaKonsumen = v.HargaKonsumen
+	}
+	return hargaReseller, hargaKonsumen
+}
+
 // FotoProduk represents the foto_produk table.
 type FotoProduk struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement"`
@@ -137,6 +873,14 @@ type LogProduk struct {
 	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime"`
 	TokoID        uint      `gorm:"column:id_toko;not null"`
 	CategoryID    uint      `gorm:"column:id_category;not null"`
+	// VariantID, SKU and VariantAttributes snapshot the variant bought, if any.
+	VariantID         *uint  `gorm:"column:id_variant"`
+	SKU               string `gorm:"column:sku;size:100"`
+	VariantAttributes string `gorm:"column:variant_attributes;type:text"`
+	// PromotionID is the promotion the line was priced at, if any;
+	// HargaKonsumenNormal is then the consumer price without it.
+	PromotionID         *uint  `gorm:"column:id_promotion"`
+	HargaKonsumenNormal string `gorm:"column:harga_konsumen_normal;size:255"`
 
 	Produk    Produk      `gorm:"foreignKey:ProdukID;references:ID"`
 	Toko      Toko        `gorm:"foreignKey:TokoID;references:ID"`
@@ -146,16 +890,23 @@ type LogProduk struct {
 
 func (LogProduk) TableName() string { return "log_produk" }
 
+// VariantAttributeMap returns the snapshotted variant's option values by option name.
+func (l *LogProduk) VariantAttributeMap() map[string]string {
+	return parseAttributes(l.VariantAttributes)
+}
+
 // DetailTrx represents the detail_trx table.
 type DetailTrx struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement"`
-	TrxID       uint      `gorm:"column:id_trx;not null"`
-	LogProdukID uint      `gorm:"column:id_log_produk;not null"`
-	TokoID      uint      `gorm:"column:id_toko;not null"`
-	Kuantitas   int       `gorm:"column:kuantitas;not null"`
-	HargaTotal  int       `gorm:"column:harga_total;not null"`
-	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	TrxID       uint       `gorm:"column:id_trx;not null"`
+	LogProdukID uint       `gorm:"column:id_log_produk;not null"`
+	TokoID      uint       `gorm:"column:id_toko;not null"`
+	Kuantitas   int        `gorm:"column:kuantitas;not null"`
+	HargaTotal  int        `gorm:"column:harga_total;not null"`
+	NoResi      string     `gorm:"column:no_resi;size:100"`
+	ShippedAt   *time.Time `gorm:"column:shipped_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
 
 	Trx       Trx       `gorm:"foreignKey:TrxID;references:ID"`
 	LogProduk LogProduk `gorm:"foreignKey:LogProdukID;references:ID"`
@@ -163,3 +914,198 @@ type DetailTrx struct {
 }
 
 func (DetailTrx) TableName() string { return "detail_trx" }
+
+// Review represents the review table: a buyer's rating of one purchased
+// detail_trx line, at most one per line. Photos is a comma-separated list
+// of uploaded image filenames; Reply is the toko's answer.
+type Review struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	DetailTrxID uint       `gorm:"column:id_detail_trx;not null;uniqueIndex"`
+	ProdukID    uint       `gorm:"column:id_produk;not null;index"`
+	TokoID      uint       `gorm:"column:id_toko;not null;index"`
+	UserID      uint       `gorm:"column:id_user;not null"`
+	Rating      int        `gorm:"column:rating;not null"`
+	Comment     string     `gorm:"column:comment;type:text"`
+	Photos      string     `gorm:"column:photos;type:text"`
+	Reply       string     `gorm:"column:reply;type:text"`
+	RepliedBy   *uint      `gorm:"column:replied_by"`
+	RepliedAt   *time.Time `gorm:"column:replied_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (Review) TableName() string { return "review" }
+
+// PhotoList returns the attached photo filenames.
+func (r *Review) PhotoList() []string {
+	if r.Photos == "" {
+		return nil
+	}
+	return strings.Split(r.Photos, ",")
+}
+
+// Product Q&A items that can be upvoted, reported and hidden.
+const (
+	QATargetQuestion = "question"
+	QATargetAnswer   = "answer"
+)
+
+// Who a product answer was written as: a member of the owning toko or a
+// buyer who purchased the product.
+const (
+	AnsweredAsToko  = "toko"
+	AnsweredAsBuyer = "buyer"
+)
+
+// ProductQuestion represents the product_question table: a public question
+// on a product. HiddenAt is set by moderation or once enough users report it.
+type ProductQuestion struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	ProdukID    uint       `gorm:"column:id_produk;not null;index"`
+	TokoID      uint       `gorm:"column:id_toko;not null;index"`
+	UserID      uint       `gorm:"column:id_user;not null"`
+	Body        string     `gorm:"column:body;type:text;not null"`
+	Upvotes     int        `gorm:"column:upvotes;not null;default:0"`
+	ReportCount int        `gorm:"column:report_count;not null;default:0"`
+	HiddenAt    *time.Time `gorm:"column:hidden_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User    User            `gorm:"foreignKey:UserID;references:ID"`
+	Answers []ProductAnswer `gorm:"foreignKey:QuestionID"`
+}
+
+func (ProductQuestion) TableName() string { return "product_question" }
+
+// ProductAnswer represents the product_answer table. AnsweredAs tells
+// readers whether the toko or a verified buyer wrote it.
+type ProductAnswer struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	QuestionID  uint       `gorm:"column:id_question;not null;index"`
+	UserID      uint       `gorm:"column:id_user;not null"`
+	AnsweredAs  string     `gorm:"column:answered_as;size:10;not null"`
+	Body        string     `gorm:"column:body;type:text;not null"`
+	Upvotes     int        `gorm:"column:upvotes;not null;default:0"`
+	ReportCount int        `gorm:"column:report_count;not null;default:0"`
+	HiddenAt    *time.Time `gorm:"column:hidden_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+
+	User User `gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (ProductAnswer) TableName() string { return "product_answer" }
+
+// QAVote represents the qa_vote table: one user's upvote on a question or answer.
+type QAVote struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `gorm:"column:id_user;not null;uniqueIndex:idx_qa_vote"`
+	TargetType string    `gorm:"column:target_type;size:10;not null;uniqueIndex:idx_qa_vote"`
+	TargetID   uint      `gorm:"column:target_id;not null;uniqueIndex:idx_qa_vote"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (QAVote) TableName() string { return "qa_vote" }
+
+// QAReport represents the qa_report table: one user's report of a question or answer.
+type QAReport struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `gorm:"column:id_user;not null;uniqueIndex:idx_qa_report"`
+	TargetType string    `gorm:"column:target_type;size:10;not null;uniqueIndex:idx_qa_report"`
+	TargetID   uint      `gorm:"column:target_id;not null;uniqueIndex:idx_qa_report"`
+	Reason     string    `gorm:"column:reason;size:255"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (QAReport) TableName() string { return "qa_report" }
+
+// Product import job statuses.
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// Product import/export file formats.
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatXLSX = "xlsx"
+)
+
+// ImportJob represents the import_job table: a CSV/XLSX file of products
+// to create in a toko, processed in the background. The uploaded file and
+// optional zip of images are kept in the row so any instance can process
+// it, and cleared once the job finishes. The worker holds LeaseUntil while
+// running; ProcessedRows lets a job taken over after the lease expires
+// resume where it stopped.
+type ImportJob struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement"`
+	TokoID        uint       `gorm:"column:id_toko;not null;index"`
+	UserID        uint       `gorm:"column:id_user;not null"`
+	ActorRoles    string     `gorm:"column:actor_roles;size:255"`
+	APIKeyID      *uint      `gorm:"column:id_api_key"`
+	IP            string     `gorm:"column:ip;size:64"`
+	RequestID     string     `gorm:"column:request_id;size:64"`
+	Format        string     `gorm:"column:format;size:10;not null"`
+	Filename      string     `gorm:"column:filename;size:255"`
+	File          []byte     `gorm:"column:file;type:longblob" json:"-"`
+	Images        []byte     `gorm:"column:images;type:longblob" json:"-"`
+	Status        string     `gorm:"column:status;size:20;not null;index"`
+	TotalRows     int        `gorm:"column:total_rows;not null;default:0"`
+	ProcessedRows int        `gorm:"column:processed_rows;not null;default:0"`
+	SuccessRows   int        `gorm:"column:success_rows;not null;default:0"`
+	FailedRows    int        `gorm:"column:failed_rows;not null;default:0"`
+	LastError     string     `gorm:"column:last_error;size:500"`
+	LeaseUntil    *time.Time `gorm:"column:lease_until"`
+	StartedAt     *time.Time `gorm:"column:started_at"`
+	FinishedAt    *time.Time `gorm:"column:finished_at"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ImportJob) TableName() string { return "import_job" }
+
+// ImportJobError represents the import_job_error table: why one row of an
+// import file was not imported. Line is the 1-based line in the file,
+// counting the header.
+type ImportJobError struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	JobID      uint      `gorm:"column:id_job;not null;index"`
+	Line       int       `gorm:"column:line;not null"`
+	NamaProduk string    `gorm:"column:nama_produk;size:255"`
+	Message    string    `gorm:"column:message;size:500;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (ImportJobError) TableName() string { return "import_job_error" }
+
+// ProdukSearch represents the produk_search table: the analyzed text of a
+// product for full-text search. Terms holds the stems of the name,
+// description, category and toko name; NameTerms those of the name alone,
+// which rank higher.
+type ProdukSearch struct {
+	ProdukID  uint      `gorm:"column:id_produk;primaryKey"`
+	Terms     string    `gorm:"column:terms;type:text;index:idx_produk_search_terms,class:FULLTEXT"`
+	NameTerms string    `gorm:"column:name_terms;type:text;index:idx_produk_search_name_terms,class:FULLTEXT"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (ProdukSearch) TableName() string { return "produk_search" }
+
+// SearchTerm represents the search_term table: every term ever indexed,
+// looked up to correct typos in search queries.
+type SearchTerm struct {
+	Term string `gorm:"column:term;primaryKey;size:64"`
+}
+
+func (SearchTerm) TableName() string { return "search_term" }
+
+// Search suggestion types.
+const (
+	SuggestTypeProduct  = "product"
+	SuggestTypeCategory = "category"
+	SuggestTypeToko     = "toko"
+)
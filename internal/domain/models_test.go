@@ -0,0 +1,28 @@
+package domain
+
+import "testing"
+
+func TestPromotionPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo Promotion
+		harga int
+		want  int
+	}{
+		{"percent", Promotion{Type: PromotionTypePercent, Value: 25}, 10000, 7500},
+		{"percent discount rounds down", Promotion{Type: PromotionTypePercent, Value: 33}, 999, 670},
+		{"percent of zero", Promotion{Type: PromotionTypePercent, Value: 50}, 0, 0},
+		{"full percent", Promotion{Type: PromotionTypePercent, Value: 100}, 10000, 0},
+		{"sale price below harga", Promotion{Type: PromotionTypePrice, Value: 8000}, 10000, 8000},
+		{"sale price above harga", Promotion{Type: PromotionTypePrice, Value: 12000}, 10000, 10000},
+		{"unknown type", Promotion{Type: "bogus", Value: 50}, 10000, 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.Price(tt.harga); got != tt.want {
+				t.Errorf("Price(%d) = %d, want %d", tt.harga, got, tt.want)
+			}
+		})
+	}
+}
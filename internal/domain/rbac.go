@@ -0,0 +1,124 @@
+package domain
+
+import "strings"
+
+// Permission names checked by middleware.Require.
+const (
+	PermAll             = "*"
+	PermCategoryManage  = "category:manage"
+	PermProductModerate = "product:moderate"
+	PermProductWrite    = "product:write"
+	PermTokoWrite       = "toko:write"
+	PermTrxCreate       = "trx:create"
+	PermTrxReadAll      = "trx:read_all"
+	PermUserRead        = "user:read"
+	PermFinanceRead     = "finance:read"
+	PermRoleAssign      = "role:assign"
+	PermAuditRead       = "audit:read"
+)
+
+// API key scopes. A key acts as its user but only on routes that accept
+// one of its scopes.
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+	ScopeStockWrite    = "stock:write"
+)
+
+// APIScopes lists every scope an API key may be granted.
+var APIScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeOrdersRead, ScopeStockWrite}
+
+// ValidAPIScope reports whether scope is a known API key scope.
+func ValidAPIScope(scope string) bool {
+	for _, s := range APIScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Role names seeded at startup.
+const (
+	RoleSuperAdmin      = "super_admin"
+	RoleCategoryManager = "category_manager"
+	RoleSupport         = "support"
+	RoleFinance         = "finance"
+	RoleSeller          = "seller"
+	RoleReseller        = "reseller"
+	RoleBuyer           = "buyer"
+)
+
+// DefaultRolePermissions lists the permissions of every seeded role.
+var DefaultRolePermissions = map[string][]string{
+	RoleSuperAdmin:      {PermAll},
+	RoleCategoryManager: {PermCategoryManage, PermProductModerate},
+	RoleSupport:         {PermUserRead, PermTrxReadAll, PermAuditRead},
+	RoleFinance:         {PermTrxReadAll, PermFinanceRead},
+	RoleSeller:          {PermProductWrite, PermTokoWrite},
+	RoleReseller:        {PermTrxCreate},
+	RoleBuyer:           {PermTrxCreate},
+}
+
+// DefaultUserRoles are granted to every registered user (each user owns a toko).
+var DefaultUserRoles = []string{RoleBuyer, RoleSeller}
+
+// StaffRoles are back-office roles subject to the admin 2FA policy.
+var StaffRoles = []string{RoleSuperAdmin, RoleCategoryManager, RoleSupport, RoleFinance}
+
+// Toko member roles stored in toko_member.role.
+const (
+	TokoRoleOwner         = "owner"
+	TokoRoleAdmin         = "admin"
+	TokoRoleProductEditor = "product_editor"
+	TokoRoleOrderHandler  = "order_handler"
+)
+
+// Capabilities a toko member role may grant within its toko.
+const (
+	TokoCapProducts = "products"
+	TokoCapOrders   = "orders"
+	TokoCapMembers  = "members"
+	TokoCapSettings = "settings"
+)
+
+// TokoRoleCapabilities lists the capabilities of every toko member role.
+var TokoRoleCapabilities = map[string][]string{
+	TokoRoleOwner:         {TokoCapProducts, TokoCapOrders, TokoCapMembers, TokoCapSettings},
+	TokoRoleAdmin:         {TokoCapProducts, TokoCapOrders, TokoCapMembers, TokoCapSettings},
+	TokoRoleProductEditor: {TokoCapProducts},
+	TokoRoleOrderHandler:  {TokoCapOrders},
+}
+
+// TokoRoleAllows reports whether a toko member role grants capability.
+// An empty capability only requires membership.
+func TokoRoleAllows(role, capability string) bool {
+	caps, ok := TokoRoleCapabilities[role]
+	if !ok {
+		return false
+	}
+	if capability == "" {
+		return true
+	}
+	for _, c := range caps {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission reports whether granted covers required. "*" grants
+// everything and "product:*" grants every product permission.
+func HasPermission(granted []string, required string) bool {
+	for _, p := range granted {
+		if p == PermAll || p == required {
+			return true
+		}
+		if strings.HasSuffix(p, ":*") && strings.HasPrefix(required, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package event
+
+import "sync"
+
+// Handler processes one event. Returning an error makes the dispatcher
+// retry the event later, so handlers must tolerate seeing it again.
+type Handler func(e Event) error
+
+type subscriber struct {
+	name   string
+	types  map[string]bool
+	handle Handler
+}
+
+func (s subscriber) wants(eventType string) bool {
+	return len(s.types) == 0 || s.types[eventType]
+}
+
+// Bus routes events to in-process subscribers.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []subscriber
+}
+
+// NewBus creates an empty Bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers handle for the given event types, or for every event
+// when none are given. name is stored with acknowledgements, so it must be
+// unique and stay stable across releases.
+func (b *Bus) Subscribe(name string, handle Handler, eventTypes ...string) {
+	types := make(map[string]bool, len(eventTypes))
+	for _, t := range eventTypes {
+		types[t] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber{name: name, types: types, handle: handle})
+}
+
+// subscribersFor returns the subscribers of eventType in registration order.
+func (b *Bus) subscribersFor(eventType string) []subscriber {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var subs []subscriber
+	for _, s := range b.subscribers {
+		if s.wants(eventType) {
+			subs = append(subs, s)
+		}
+	}
+	return subs
+}
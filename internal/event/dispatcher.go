@@ -0,0 +1,151 @@
+package event
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	dispatchBaseBackoff = 5 * time.Second
+	dispatchMaxBackoff  = 10 * time.Minute
+	// dispatchLease keeps a claimed event from being handled twice while in flight.
+	dispatchLease     = time.Minute
+	dispatchBatchSize = 100
+	// dispatchMaxAttempts is how often an event is tried before it is parked.
+	dispatchMaxAttempts = 10
+)
+
+// Dispatcher delivers outbox events to the subscribers on a Bus.
+//
+// Delivery is at least once: an event is retried with backoff until every
+// subscriber has handled it, and a subscriber that succeeded is recorded
+// so it is skipped on retry. Events of one aggregate are handled in the
+// order they were written; a failing event holds back the ones after it
+// until dispatchMaxAttempts, when it is parked with failed_at set.
+type Dispatcher struct {
+	repo repository.OutboxRepository
+	bus  *Bus
+}
+
+// NewDispatcher creates a Dispatcher reading from repo.
+func NewDispatcher(repo repository.OutboxRepository, bus *Bus) *Dispatcher {
+	return &Dispatcher{repo: repo, bus: bus}
+}
+
+// DispatchDue handles every due event and returns how many were fully
+// dispatched. It keeps going while each round makes progress, so a
+// backlog of events for one aggregate drains in a single call.
+func (d *Dispatcher) DispatchDue() (int, error) {
+	dispatched := 0
+	for {
+		due, err := d.repo.GetDue(time.Now(), dispatchBatchSize)
+		if err != nil {
+			return dispatched, err
+		}
+
+		progress := 0
+		for i := range due {
+			row := &due[i]
+			claimed, err := d.repo.Claim(row, time.Now().Add(dispatchLease))
+			if err != nil {
+				return dispatched, err
+			}
+			if !claimed {
+				continue
+			}
+
+			ok, err := d.dispatch(fromOutbox(row))
+			now := time.Now()
+			row.Attempts++
+			if ok {
+				row.DispatchedAt = &now
+				row.LastError = ""
+				dispatched++
+				progress++
+			} else if row.Attempts >= dispatchMaxAttempts {
+				// park it so the rest of the aggregate can proceed
+				row.LastError = truncate(err.Error(), 500)
+				row.FailedAt = &now
+				progress++
+				log.Printf("event: %s #%d parked after %d attempts: %v", row.EventType, row.ID, row.Attempts, err)
+			} else {
+				row.LastError = truncate(err.Error(), 500)
+				row.NextAttemptAt = now.Add(dispatchBackoff(row.Attempts))
+				log.Printf("event: %s #%d attempt %d failed: %v", row.EventType, row.ID, row.Attempts, err)
+			}
+			if err := d.repo.Update(row); err != nil {
+				return dispatched, err
+			}
+		}
+
+		if progress == 0 {
+			return dispatched, nil
+		}
+	}
+}
+
+// dispatch runs every subscriber that has not yet acknowledged e and
+// stops at the first failure.
+func (d *Dispatcher) dispatch(e Event) (bool, error) {
+	acked, err := d.repo.GetAcks(e.ID)
+	if err != nil {
+		return false, err
+	}
+	done := make(map[string]bool, len(acked))
+	for _, name := range acked {
+		done[name] = true
+	}
+
+	for _, s := range d.bus.subscribersFor(e.Type) {
+		if done[s.name] {
+			continue
+		}
+		if err := safeHandle(s.handle, e); err != nil {
+			return false, fmt.Errorf("%s: %w", s.name, err)
+		}
+		if err := d.repo.Ack(e.ID, s.name); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Prune deletes events dispatched more than retentionDays ago; 0 keeps them.
+func (d *Dispatcher) Prune(retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	return d.repo.DeleteDispatchedBefore(time.Now().AddDate(0, 0, -retentionDays))
+}
+
+// safeHandle turns a panicking subscriber into a retryable error.
+func safeHandle(h Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(e)
+}
+
+// dispatchBackoff returns the wait before retry number attempts+1.
+func dispatchBackoff(attempts int) time.Duration {
+	d := dispatchBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= dispatchMaxBackoff {
+			return dispatchMaxBackoff
+		}
+	}
+	return d
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,271 @@
+package event
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func TestDispatchBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{5, 80 * time.Second},
+		{7, 320 * time.Second},
+		{8, dispatchMaxBackoff},
+		{30, dispatchMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := dispatchBackoff(tt.attempts); got != tt.want {
+			t.Errorf("dispatchBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// fakeOutboxRepo keeps outbox rows and acks in memory with the same due
+// rules as the MySQL repository.
+type fakeOutboxRepo struct {
+	rows []domain.OutboxEvent
+	acks map[uint][]string
+}
+
+func newFakeOutboxRepo(aggregates ...uint) *fakeOutboxRepo {
+	r := &fakeOutboxRepo{acks: make(map[uint][]string)}
+	for i, id := range aggregates {
+		r.rows = append(r.rows, domain.OutboxEvent{
+			ID:            uint(i + 1),
+			AggregateType: domain.AggregateProduct,
+			AggregateID:   id,
+			EventType:     domain.EventProductUpdated,
+			Payload:       "{}",
+			NextAttemptAt: time.Now().Add(-time.Second),
+		})
+	}
+	return r
+}
+
+func pending(row *domain.OutboxEvent) bool {
+	return row.DispatchedAt == nil && row.FailedAt == nil
+}
+
+func (r *fakeOutboxRepo) GetDue(now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	var due []domain.OutboxEvent
+	held := make(map[uint]bool)
+	for _, row := range r.rows {
+		if !pending(&row) {
+			continue
+		}
+		if !held[row.AggregateID] && !row.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, row)
+		}
+		held[row.AggregateID] = true
+	}
+	return due, nil
+}
+
+func (r *fakeOutboxRepo) row(id uint) *domain.OutboxEvent {
+	for i := range r.rows {
+		if r.rows[i].ID == id {
+			return &r.rows[i]
+		}
+	}
+	return nil
+}
+
+func (r *fakeOutboxRepo) Claim(event *domain.OutboxEvent, leaseUntil time.Time) (bool, error) {
+	row := r.row(event.ID)
+	if !pending(row) || !row.NextAttemptAt.Equal(event.NextAttemptAt) {
+		return false, nil
+	}
+	row.NextAttemptAt = leaseUntil
+	event.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+func (r *fakeOutboxRepo) Update(event *domain.OutboxEvent) error {
+	*r.row(event.ID) = *event
+	return nil
+}
+
+func (r *fakeOutboxRepo) GetAcks(eventID uint) ([]string, error) {
+	return r.acks[eventID], nil
+}
+
+func (r *fakeOutboxRepo) Ack(eventID uint, subscriber string) error {
+	r.acks[eventID] = append(r.acks[eventID], subscriber)
+	return nil
+}
+
+func (r *fakeOutboxRepo) DeleteDispatchedBefore(t time.Time) (int64, error) {
+	return 0, nil
+}
+
+// elapse makes every retry due, as if the backoff had passed.
+func (r *fakeOutboxRepo) elapse() {
+	for i := range r.rows {
+		if pending(&r.rows[i]) {
+			r.rows[i].NextAttemptAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+// recorder is a subscriber that notes the events it handled and fails
+// those listed in failing.
+type recorder struct {
+	handled []uint
+	failing map[uint]bool
+}
+
+func (rec *recorder) handle(e Event) error {
+	if rec.failing[e.ID] {
+		return errors.New("subscriber down")
+	}
+	rec.handled = append(rec.handled, e.ID)
+	return nil
+}
+
+// inAggregate returns the handled events of the aggregate, in handling order.
+func inAggregate(repo *fakeOutboxRepo, handled []uint, aggregateID uint) []uint {
+	var ids []uint
+	for _, id := range handled {
+		if repo.row(id).AggregateID == aggregateID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestDispatchDueKeepsAggregateOrder(t *testing.T) {
+	// events 1, 2, 4 belong to aggregate 10 and 3, 5 to aggregate 20
+	repo := newFakeOutboxRepo(10, 10, 20, 10, 20)
+	rec := &recorder{}
+	bus := NewBus()
+	bus.Subscribe("recorder", rec.handle)
+
+	n, err := NewDispatcher(repo, bus).DispatchDue()
+	if err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("DispatchDue = %d, want 5", n)
+	}
+	if got := inAggregate(repo, rec.handled, 10); !reflect.DeepEqual(got, []uint{1, 2, 4}) {
+		t.Errorf("aggregate 10 handled %v, want [1 2 4]", got)
+	}
+	if got := inAggregate(repo, rec.handled, 20); !reflect.DeepEqual(got, []uint{3, 5}) {
+		t.Errorf("aggregate 20 handled %v, want [3 5]", got)
+	}
+}
+
+func TestDispatchDueHoldsBackFailingAggregate(t *testing.T) {
+	repo := newFakeOutboxRepo(10, 10, 20)
+	rec := &recorder{failing: map[uint]bool{1: true}}
+	bus := NewBus()
+	bus.Subscribe("recorder", rec.handle)
+	d := NewDispatcher(repo, bus)
+
+	before := time.Now()
+	if _, err := d.DispatchDue(); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	if !reflect.DeepEqual(rec.handled, []uint{3}) {
+		t.Errorf("handled %v, want only [3]", rec.handled)
+	}
+	failed := repo.row(1)
+	if failed.Attempts != 1 || failed.LastError == "" || failed.DispatchedAt != nil {
+		t.Errorf("event 1 = %d attempts, error %q, dispatched %v; want a recorded failure", failed.Attempts, failed.LastError, failed.DispatchedAt)
+	}
+	if wait := failed.NextAttemptAt.Sub(before); wait < dispatchBaseBackoff {
+		t.Errorf("event 1 retried in %v, want at least %v", wait, dispatchBaseBackoff)
+	}
+
+	// once the subscriber recovers, the held back event follows in order
+	rec.failing = nil
+	repo.elapse()
+	if _, err := d.DispatchDue(); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	if !reflect.DeepEqual(rec.handled, []uint{3, 1, 2}) {
+		t.Errorf("handled %v, want [3 1 2]", rec.handled)
+	}
+}
+
+func TestDispatchDueSkipsAcknowledgedSubscribers(t *testing.T) {
+	repo := newFakeOutboxRepo(10)
+	first, second := &recorder{}, &recorder{failing: map[uint]bool{1: true}}
+	bus := NewBus()
+	bus.Subscribe("first", first.handle)
+	bus.Subscribe("second", second.handle)
+	d := NewDispatcher(repo, bus)
+
+	if _, err := d.DispatchDue(); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	second.failing = nil
+	repo.elapse()
+	if _, err := d.DispatchDue(); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+
+	if len(first.handled) != 1 || len(second.handled) != 1 {
+		t.Errorf("first handled %v, second %v; want event 1 once each", first.handled, second.handled)
+	}
+	acks := append([]string(nil), repo.acks[1]...)
+	sort.Strings(acks)
+	if !reflect.DeepEqual(acks, []string{"first", "second"}) {
+		t.Errorf("acks = %v, want [first second]", acks)
+	}
+	if repo.row(1).DispatchedAt == nil {
+		t.Error("event 1 not marked dispatched")
+	}
+}
+
+func TestDispatchDueParksAfterMaxAttempts(t *testing.T) {
+	repo := newFakeOutboxRepo(10, 10, 20)
+	rec := &recorder{failing: map[uint]bool{1: true}}
+	bus := NewBus()
+	bus.Subscribe("recorder", rec.handle)
+	d := NewDispatcher(repo, bus)
+
+	for i := 1; i < dispatchMaxAttempts; i++ {
+		if _, err := d.DispatchDue(); err != nil {
+			t.Fatalf("DispatchDue: %v", err)
+		}
+		if row := repo.row(1); row.FailedAt != nil || row.Attempts != i {
+			t.Fatalf("after %d rounds event 1 has %d attempts, parked %v", i, row.Attempts, row.FailedAt != nil)
+		}
+		repo.elapse()
+	}
+	if !reflect.DeepEqual(rec.handled, []uint{3}) {
+		t.Fatalf("handled %v before parking, want only [3]", rec.handled)
+	}
+
+	// the last attempt parks event 1 and lets event 2 through in the same call
+	if _, err := d.DispatchDue(); err != nil {
+		t.Fatalf("DispatchDue: %v", err)
+	}
+	parked := repo.row(1)
+	if parked.FailedAt == nil || parked.Attempts != dispatchMaxAttempts || parked.DispatchedAt != nil {
+		t.Errorf("event 1 = %d attempts, parked %v, dispatched %v; want parked after %d", parked.Attempts, parked.FailedAt != nil, parked.DispatchedAt != nil, dispatchMaxAttempts)
+	}
+	if !reflect.DeepEqual(rec.handled, []uint{3, 2}) {
+		t.Errorf("handled %v, want [3 2]", rec.handled)
+	}
+
+	// a parked event is not retried
+	repo.elapse()
+	if n, err := d.DispatchDue(); err != nil || n != 0 {
+		t.Errorf("DispatchDue after parking = %d, %v, want 0, nil", n, err)
+	}
+	if parked := repo.row(1); parked.Attempts != dispatchMaxAttempts {
+		t.Errorf("parked event retried: %d attempts", parked.Attempts)
+	}
+}
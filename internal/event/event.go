@@ -0,0 +1,163 @@
+// Package event carries domain events from the transactional outbox to
+// in-process subscribers such as webhooks and notifications.
+package event
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+// Event is a dispatched domain event. ID is the outbox row ID and is the
+// same on every redelivery, so subscribers can deduplicate on it.
+type Event struct {
+	ID            uint
+	Type          string
+	AggregateType string
+	AggregateID   uint
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+}
+
+// Decode unmarshals the payload into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+func fromOutbox(row *domain.OutboxEvent) Event {
+	return Event{
+		ID:            row.ID,
+		Type:          row.EventType,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Payload:       json.RawMessage(row.Payload),
+		OccurredAt:    row.CreatedAt,
+	}
+}
+
+// TrxCreated is the payload of domain.EventTrxCreated.
+type TrxCreated struct {
+	TrxID       uint          `json:"trx_id"`
+	UserID      uint          `json:"user_id"`
+	KodeInvoice string        `json:"kode_invoice"`
+	MethodBayar string        `json:"method_bayar"`
+	HargaTotal  int           `json:"harga_total"`
+	Alamat      TrxAlamat     `json:"alamat"`
+	Items       []TrxLineItem `json:"items"`
+}
+
+// TrxAlamat is the shipping address of a created trx.
+type TrxAlamat struct {
+	NamaPenerima string `json:"nama_penerima"`
+	NoTelp       string `json:"no_telp"`
+	DetailAlamat string `json:"detail_alamat"`
+}
+
+// TrxLineItem is one purchased product of a created trx.
+type TrxLineItem struct {
+	ProductID  uint   `json:"product_id"`
+	VariantID  uint   `json:"variant_id,omitempty"`
+	SKU        string `json:"sku,omitempty"`
+	TokoID     uint   `json:"toko_id"`
+	NamaProduk string `json:"nama_produk"`
+	Kuantitas  int    `json:"kuantitas"`
+	HargaTotal int    `json:"harga_total"`
+}
+
+// OrderShipped is the payload of domain.EventOrderShipped: one toko has
+// shipped its lines of a trx.
+type OrderShipped struct {
+	TrxID       uint   `json:"trx_id"`
+	UserID      uint   `json:"user_id"`
+	TokoID      uint   `json:"toko_id"`
+	KodeInvoice string `json:"kode_invoice"`
+	NoResi      string `json:"no_resi"`
+}
+
+// ProductChanged is the payload of domain.EventProductCreated,
+// EventProductUpdated and EventProductDeleted, with the product's state
+// after the change (before it, for deletes).
+type ProductChanged struct {
+	ProductID     uint   `json:"product_id"`
+	TokoID        uint   `json:"toko_id"`
+	CategoryID    uint   `json:"category_id"`
+	NamaProduk    string `json:"nama_produk"`
+	Slug          string `json:"slug"`
+	HargaReseller string `json:"harga_reseller"`
+	HargaKonsumen string `json:"harga_konsumen"`
+	Stok          int    `json:"stok"`
+}
+
+// NewProductChanged builds a ProductChanged payload from p.
+func NewProductChanged(p *domain.Produk) ProductChanged {
+	return ProductChanged{
+		ProductID:     p.ID,
+		TokoID:        p.TokoID,
+		CategoryID:    p.CategoryID,
+		NamaProduk:    p.NamaProduk,
+		Slug:          p.Slug,
+		HargaReseller: p.HargaReseller,
+		HargaKonsumen: p.HargaKonsumen,
+		Stok:          p.Stok,
+	}
+}
+
+// ProductModerated is the payload of domain.EventProductApproved and
+// EventProductRejected. Status is the product's status after the decision:
+// published, scheduled (with PublishAt) or rejected (with Reason).
+type ProductModerated struct {
+	ProductID  uint       `json:"product_id"`
+	TokoID     uint       `json:"toko_id"`
+	NamaProduk string     `json:"nama_produk"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// StockChanged is the payload of domain.EventStockChanged.
+type StockChanged struct {
+	ProductID   uint   `json:"product_id"`
+	TokoID      uint   `json:"toko_id"`
+	NamaProduk  string `json:"nama_produk"`
+	StokSebelum int    `json:"stok_sebelum"`
+	Stok        int    `json:"stok"`
+}
+
+// UserRegistered is the payload of domain.EventUserRegistered.
+type UserRegistered struct {
+	UserID uint   `json:"user_id"`
+	Nama   string `json:"nama"`
+	Email  string `json:"email"`
+	NoTelp string `json:"no_telp"`
+}
+
+// Batch collects outbox rows for one change. The first payload that fails
+// to marshal is reported by Events.
+type Batch struct {
+	events []domain.OutboxEvent
+	err    error
+}
+
+// Add appends an event of eventType for the given aggregate.
+func (b *Batch) Add(eventType, aggregateType string, aggregateID uint, payload interface{}) {
+	if b.err != nil {
+		return
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.events = append(b.events, domain.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       string(raw),
+	})
+}
+
+// Events returns the collected rows; it fits repository.OutboxFunc.
+func (b *Batch) Events() ([]domain.OutboxEvent, error) {
+	return b.events, b.err
+}
@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope string
+		c     Cursor
+	}{
+		{"after", "products:created_at:desc", Cursor{Values: []string{"2024-01-02T03:04:05Z", "42"}}},
+		{"before", "products:created_at:desc", Cursor{Values: []string{"2024-01-02T03:04:05Z", "42"}, Before: true}},
+		{"scope with query", "search:kopi gayo & teh", Cursor{Values: []string{"1.5", "7"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.scope, EncodeCursor(tt.scope, tt.c))
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.c) {
+				t.Errorf("DecodeCursor = %+v, want %+v", got, tt.c)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	const scope = "products:id:asc"
+	valid := EncodeCursor(scope, Cursor{Values: []string{"10"}})
+	payload, mac, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name  string
+		scope string
+		token string
+	}{
+		{"empty", scope, ""},
+		{"no signature", scope, payload},
+		{"wrong signature", scope, payload + ".AAAA"},
+		{"tampered payload", scope, EncodeCursor(scope, Cursor{Values: []string{"11"}})[:len(payload)] + "." + mac},
+		{"other scope", "products:id:desc", valid},
+		{"no values", scope, EncodeCursor(scope, Cursor{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.scope, tt.token); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
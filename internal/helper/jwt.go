@@ -7,11 +7,23 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// JWT purposes. Session tokens carry an empty purpose; other purposes are
+// only accepted by the endpoint they were issued for.
+const (
+	JWTPurposeTwoFactor = "2fa"
+)
+
 // JWTClaims represents the JWT payload used in the application.
 type JWTClaims struct {
-	UserID  uint   `json:"id"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
+	UserID         uint     `json:"id"`
+	Email          string   `json:"email"`
+	IsAdmin        bool     `json:"is_admin"`
+	SessionVersion uint     `json:"sv"`
+	Roles          []string `json:"roles,omitempty"`
+	Permissions    []string `json:"permissions,omitempty"`
+	// MFA is true when the session was established with a second factor.
+	MFA     bool   `json:"mfa,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -23,16 +35,25 @@ func getJWTSecret() []byte {
 	return []byte(secret)
 }
 
-// GenerateJWT generates a signed JWT token for a user.
-func GenerateJWT(userID uint, email string, isAdmin bool) (string, error) {
-	claims := JWTClaims{
-		UserID:  userID,
-		Email:   email,
-		IsAdmin: isAdmin,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// GenerateJWT generates a signed 24h session token from claims.
+func GenerateJWT(claims JWTClaims) (string, error) {
+	return signJWT(claims, 24*time.Hour)
+}
+
+// GenerateChallengeJWT generates a short-lived token proving the password
+// step of a two-step login succeeded.
+func GenerateChallengeJWT(userID uint, sessionVersion uint) (string, error) {
+	return signJWT(JWTClaims{
+		UserID:         userID,
+		SessionVersion: sessionVersion,
+		Purpose:        JWTPurposeTwoFactor,
+	}, 5*time.Minute)
+}
+
+func signJWT(claims JWTClaims, ttl time.Duration) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -54,4 +75,4 @@ func ParseJWT(tokenStr string) (*JWTClaims, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
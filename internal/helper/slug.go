@@ -0,0 +1,66 @@
+package helper
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength leaves room in a 255 character column for a numeric suffix.
+const MaxSlugLength = 200
+
+// slugTransliterations spells out letters that do not decompose into an
+// ASCII letter and a diacritic, and symbols worth keeping as words.
+var slugTransliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l",
+	'þ': "th", 'ı': "i", 'ħ': "h", 'ŀ': "l", 'ĸ': "k", 'ŋ': "n", 'ſ': "s",
+	'&': "dan", '@': "at", '+': "plus", '%': "persen",
+}
+
+// Slugify turns text into a URL slug: lowercase ASCII letters and digits
+// separated by single hyphens, with accented letters transliterated
+// ("Kopi Gayo Café & Bar!" becomes "kopi-gayo-cafe-dan-bar"). Text without
+// any letter or digit gives "".
+func Slugify(text string) string {
+	var b strings.Builder
+	hyphen := false
+	write := func(s string) {
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(s)
+	}
+
+	// NFKD splits "é" into "e" and a combining accent, and "ﬁ" into "fi"
+	for _, r := range norm.NFKD.String(strings.ToLower(text)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			write(string(r))
+		case unicode.Is(unicode.Mn, r):
+			// diacritic of the previous letter
+		case slugTransliterations[r] != "":
+			t := slugTransliterations[r]
+			if !unicode.IsLetter(r) {
+				// a symbol is a word of its own
+				hyphen = true
+				write(t)
+				hyphen = true
+				continue
+			}
+			write(t)
+		default:
+			hyphen = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = slug[:MaxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Kopi Gayo Café & Bar!", "kopi-gayo-cafe-dan-bar"},
+		{"  --Hello   World--  ", "hello-world"},
+		{"Ünïcödé 2024", "unicode-2024"},
+		{"Straße Œuvre", "strasse-oeuvre"},
+		{"ﬁne 100%", "fine-100-persen"},
+		{"Ø+A@B", "o-plus-a-at-b"},
+		{"!!!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Slugify(tt.text); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyCutsAtWordBoundary(t *testing.T) {
+	got := Slugify(strings.Repeat("abcdefghi ", 25))
+	if len(got) > MaxSlugLength {
+		t.Fatalf("len(Slugify) = %d, want at most %d", len(got), MaxSlugLength)
+	}
+	if want := strings.TrimSuffix(strings.Repeat("abcdefghi-", 20), "-"); got != want {
+		t.Errorf("Slugify = %q, want %q", got, want)
+	}
+}
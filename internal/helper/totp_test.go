@@ -0,0 +1,68 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA1 test key "12345678901234567890" in base32.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		// the RFC 6238 vectors, cut to six digits
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := TOTPStep(now)
+	code := func(t time.Time) string {
+		c, _ := TOTPCode(rfcSecret, t)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(now), 0, step, true},
+		{"previous step within skew", code(now.Add(-30 * time.Second)), 0, step - 1, true},
+		{"next step within skew", code(now.Add(30 * time.Second)), 0, step + 1, true},
+		{"outside skew", code(now.Add(-90 * time.Second)), 0, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+		{"wrong length", "12345", 0, 0, false},
+		{"replayed step", code(now), step, 0, false},
+		{"step before the last accepted", code(now.Add(-30 * time.Second)), step, 0, false},
+		{"step after the last accepted", code(now.Add(30 * time.Second)), step, step + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP(%q) = %d, %v, want %d, %v", tt.code, gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
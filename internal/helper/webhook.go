@@ -0,0 +1,172 @@
+package helper
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WebhookSignatureHeader carries "t=<unix>,v1=<hex hmac>" on outbound webhooks.
+const WebhookSignatureHeader = "X-Evermos-Signature"
+
+var (
+	// ErrInvalidWebhookSignature indicates a missing, malformed, stale or wrong signature.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	// ErrWebhookAddressBlocked indicates a webhook target on a loopback,
+	// private, link-local, multicast or otherwise non-public address.
+	ErrWebhookAddressBlocked = errors.New("webhook target address is not allowed")
+)
+
+// nonPublicNets are the IPv4 ranges, besides those the net package
+// classifies, that are not reachable on the public internet.
+var nonPublicNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+	mustCIDR("240.0.0.0/4"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// PublicWebhookAddress reports whether ip may receive webhooks: it must not
+// be loopback, private, link-local, multicast, unspecified or reserved.
+func PublicWebhookAddress(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWebhookClient returns the HTTP client outbound webhooks are sent
+// with. Redirects are not followed, so a 3xx answer counts as a failed
+// attempt. Unless allowPrivate, connections to addresses that are not
+// PublicWebhookAddress are refused; the check runs on the address actually
+// dialed, after DNS resolution, so a host cannot pass registration and
+// later resolve to an internal address.
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !PublicWebhookAddress(net.ParseIP(host)) {
+				return ErrWebhookAddressBlocked
+			}
+			return nil
+		}
+		// a proxy would be dialed instead of the target, skipping the check
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CheckWebhookHost resolves host and returns ErrWebhookAddressBlocked if
+// any of its addresses is not PublicWebhookAddress. It gives early feedback
+// when an endpoint is registered; NewWebhookClient still checks every
+// connection.
+func CheckWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if !PublicWebhookAddress(ip) {
+			return ErrWebhookAddressBlocked
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicWebhookAddress(addr.IP) {
+			return ErrWebhookAddressBlocked
+		}
+	}
+	return nil
+}
+
+// SignWebhook returns the signature header value for body sent at t.
+// The MAC covers "<unix t>.<body>" so a captured payload cannot be replayed
+// with a fresh timestamp.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, webhookMAC(secret, ts, body))
+}
+
+// VerifyWebhookSignature checks a signature header produced by SignWebhook.
+// Signatures older than tolerance are rejected; 0 disables the age check.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sig = kv[1]
+		}
+	}
+	if ts == "" || sig == "" {
+		return ErrInvalidWebhookSignature
+	}
+
+	if tolerance > 0 {
+		unix, err := strconv.ParseInt(ts, 10, 64)
+		if err != nil {
+			return ErrInvalidWebhookSignature
+		}
+		if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrInvalidWebhookSignature
+		}
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(webhookMAC(secret, ts, body))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+func webhookMAC(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"a":1}`)
+
+	got := SignWebhook("s3cret", at, body)
+	want := "t=1700000000,v1=1698a50bc74d1ff1db85c4e0a5297c2ad9fdba245d5737cdb789e4cc6e098940"
+	if got != want {
+		t.Fatalf("SignWebhook = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		tolerance time.Duration
+		wantErr   bool
+	}{
+		{"valid, no age check", "s3cret", got, body, 0, false},
+		{"wrong secret", "other", got, body, 0, true},
+		{"body changed", "s3cret", got, []byte(`{"a":2}`), 0, true},
+		{"timestamp changed", "s3cret", "t=1700000001,v1=1698a50bc74d1ff1db85c4e0a5297c2ad9fdba245d5737cdb789e4cc6e098940", body, 0, true},
+		{"missing signature", "s3cret", "t=1700000000", body, 0, true},
+		{"empty header", "s3cret", "", body, 0, true},
+		{"too old", "s3cret", got, body, time.Minute, true},
+		{"fresh", "s3cret", SignWebhook("s3cret", time.Now(), body), body, time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, tt.header, tt.body, tt.tolerance)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("VerifyWebhookSignature error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidWebhookSignature) {
+				t.Errorf("VerifyWebhookSignature error = %v, want ErrInvalidWebhookSignature", err)
+			}
+		})
+	}
+}
+
+func TestPublicWebhookAddress(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := PublicWebhookAddress(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("PublicWebhookAddress(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody rejects request bodies larger than limit bytes with 413. The
+// app streams large bodies instead of buffering them, so this is where the
+// size is enforced. A body sent without Content-Length is read up to the
+// limit before the next handler runs. Requests matching one of except,
+// given as "METHOD /path", are passed through unchecked so a route can be
+// given its own LimitBody.
+func LimitBody(limit int, except ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		route := c.Method() + " " + strings.TrimSuffix(c.Path(), "/")
+		for _, r := range except {
+			if r == route {
+				return c.Next()
+			}
+		}
+
+		req := c.Request()
+		if req.Header.ContentLength() > limit {
+			return bodyTooLarge(c, limit)
+		}
+		if req.IsBodyStream() && req.Header.ContentLength() < 0 {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  false,
+					"message": "Bad Request",
+					"errors":  []string{"cannot read request body"},
+					"data":    nil,
+				})
+			}
+			if len(body) > limit {
+				return bodyTooLarge(c, limit)
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx, limit int) error {
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"status":  false,
+		"message": "Request Entity Too Large",
+		"errors":  []string{"request body exceeds " + strconv.Itoa(limit) + " bytes"},
+		"data":    nil,
+	})
+}
@@ -1,15 +1,38 @@
 package middleware
 
 import (
+	"errors"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
 )
 
+// ErrSessionEnded indicates the credential a long-lived connection was
+// opened with is no longer valid.
+var ErrSessionEnded = errors.New("session expired")
+
+// SessionValidator reports whether the credential a request was
+// authenticated with still holds. JWTMiddleware stores one in the
+// `session_valid` local; streams that outlive the request call it on every
+// poll and close once it returns an error.
+type SessionValidator func() error
+
 // JWTMiddleware validates JWT from the `token` header and injects
-// user information into the request context.
-func JWTMiddleware() fiber.Handler {
+// user information into the request context. Tokens whose session
+// version is older than the user's (e.g. after a password reset) are rejected.
+//
+// When apiKeyRepo is non-nil the route also accepts a personal API key in
+// the `X-API-Key` header; such requests get `api_scopes` for RequireScope.
+// Pass nil for routes that must only be reachable with a login session.
+func JWTMiddleware(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if apiKey := c.Get("X-API-Key"); apiKey != "" && apiKeyRepo != nil {
+			return authenticateAPIKey(c, apiKey, userRepo, apiKeyRepo)
+		}
+
 		tokenStr := c.Get("token")
 		if tokenStr == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -21,7 +44,8 @@ func JWTMiddleware() fiber.Handler {
 		}
 
 		claims, err := helper.ParseJWT(tokenStr)
-		if err != nil {
+		// purpose-bound tokens (e.g. 2FA challenge) are not sessions
+		if err != nil || claims.Purpose != "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"status":  false,
 				"message": "Unauthorized",
@@ -30,10 +54,157 @@ func JWTMiddleware() fiber.Handler {
 			})
 		}
 
+		user, err := userRepo.FindByID(claims.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Unauthorized",
+				"errors":  []string{err.Error()},
+				"data":    nil,
+			})
+		}
+		if user == nil || user.SessionVersion != claims.SessionVersion {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  false,
+				"message": "Unauthorized",
+				"errors":  []string{"session expired"},
+				"data":    nil,
+			})
+		}
+
 		c.Locals("user_id", claims.UserID)
 		c.Locals("email", claims.Email)
 		c.Locals("is_admin", claims.IsAdmin)
+		c.Locals("mfa", claims.MFA)
+		c.Locals("roles", claims.Roles)
+		c.Locals("permissions", claims.Permissions)
+		c.Locals("session_valid", tokenSession(userRepo, claims))
 
 		return c.Next()
 	}
 }
+
+// OptionalAuth runs auth, e.g. a JWTMiddleware, only when the request
+// carries a session token or API key, so public routes can tell members
+// apart from anonymous visitors. A credential that is sent but invalid is
+// still rejected.
+func OptionalAuth(auth fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("token") == "" && c.Get("X-API-Key") == "" {
+			return c.Next()
+		}
+		return auth(c)
+	}
+}
+
+// TokenFromQuery copies a `token` query parameter into the `token` header
+// for clients that cannot set headers, such as a browser EventSource or
+// WebSocket. Use it only on streaming routes: query strings end up in
+// access logs.
+func TokenFromQuery() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("token") == "" {
+			if token := c.Query("token"); token != "" {
+				c.Request().Header.Set("token", token)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// authenticateAPIKey resolves an API key to its user. The key acts with the
+// user's current roles and permissions, loaded on every request; admin
+// status and MFA are never granted. Changing or resetting the password
+// revokes the user's keys.
+func authenticateAPIKey(c *fiber.Ctx, apiKey string, userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository) error {
+	key, err := apiKeyRepo.GetByHash(helper.HashToken(apiKey))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+	now := time.Now()
+	if key == nil || !key.Active(now) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid api key"},
+			"data":    nil,
+		})
+	}
+
+	user, err := userRepo.FindByID(key.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{err.Error()},
+			"data":    nil,
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  []string{"invalid api key"},
+			"data":    nil,
+		})
+	}
+
+	// usage tracking is best effort and must not block the request
+	_ = apiKeyRepo.TouchLastUsed(key.ID, now)
+
+	var tokoID uint
+	if key.TokoID != nil {
+		tokoID = *key.TokoID
+	}
+
+	c.Locals("user_id", user.ID)
+	c.Locals("email", user.Email)
+	c.Locals("is_admin", false)
+	c.Locals("mfa", false)
+	c.Locals("roles", user.RoleNames())
+	c.Locals("permissions", user.PermissionNames())
+	c.Locals("api_key_id", key.ID)
+	c.Locals("api_toko_id", tokoID)
+	c.Locals("api_scopes", key.ScopeList())
+	c.Locals("session_valid", apiKeySession(apiKeyRepo, key.KeyHash))
+
+	return c.Next()
+}
+
+// tokenSession validates a login session: the token has not expired and
+// the user's session version has not moved on, as it does on a password
+// change or reset, a 2FA change or new roles.
+func tokenSession(userRepo repository.UserRepository, claims *helper.JWTClaims) SessionValidator {
+	return func() error {
+		if claims.ExpiresAt != nil && time.Now().After(claims.ExpiresAt.Time) {
+			return ErrSessionEnded
+		}
+		user, err := userRepo.FindByID(claims.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil || user.SessionVersion != claims.SessionVersion {
+			return ErrSessionEnded
+		}
+		return nil
+	}
+}
+
+// apiKeySession validates that an API key is still active.
+func apiKeySession(apiKeyRepo repository.APIKeyRepository, keyHash string) SessionValidator {
+	return func() error {
+		key, err := apiKeyRepo.GetByHash(keyHash)
+		if err != nil {
+			return err
+		}
+		if key == nil || !key.Active(time.Now()) {
+			return ErrSessionEnded
+		}
+		return nil
+	}
+}
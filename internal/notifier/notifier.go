@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Delivery channels supported by notifiers.
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+// Message is a single outbound message to a user.
+type Message struct {
+	Channel string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages (OTP codes, links, alerts) to users.
+// Production deployments plug in an email/SMS gateway; the console and
+// file sinks below are meant for local development.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+type consoleNotifier struct{}
+
+// NewConsoleNotifier creates a Notifier that writes messages to the application log.
+func NewConsoleNotifier() Notifier {
+	return &consoleNotifier{}
+}
+
+func (n *consoleNotifier) Send(msg Message) error {
+	log.Printf("[notifier] %s to %s: %s - %s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier creates a Notifier that appends messages to a local file.
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\t%s\n",
+		time.Now().Format(time.RFC3339), msg.Channel, msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// New returns the notifier for the given sink name ("console" or "file").
+func New(sink, filePath string) Notifier {
+	if sink == "file" {
+		return NewFileNotifier(filePath)
+	}
+	return NewConsoleNotifier()
+}
@@ -9,7 +9,7 @@ import (
 
 // AlamatRepository defines methods to interact with alamat (address) data.
 type AlamatRepository interface {
-	GetAllByUser(userID uint, judul string) ([]domain.Alamat, error)
+	GetAllByUser(userID uint, judul string, limit int, keyset *Keyset) ([]domain.Alamat, error)
 	GetByIDForUser(userID uint, id uint) (*domain.Alamat, error)
 	Create(alamat *domain.Alamat) error
 	Update(alamat *domain.Alamat) error
@@ -25,13 +25,22 @@ func NewAlamatRepository(db *gorm.DB) AlamatRepository {
 	return &alamatRepository{db: db}
 }
 
-func (r *alamatRepository) GetAllByUser(userID uint, judul string) ([]domain.Alamat, error) {
+// GetAllByUser returns the user's alamat by ID: up to limit past keyset,
+// or all of them when limit is 0.
+func (r *alamatRepository) GetAllByUser(userID uint, judul string, limit int, keyset *Keyset) ([]domain.Alamat, error) {
 	var list []domain.Alamat
 
 	query := r.db.Where("id_user = ?", userID)
 	if judul != "" {
 		query = query.Where("judul_alamat LIKE ?", "%"+judul+"%")
 	}
+	query, err := orderBy(false, "id").apply(query, keyset)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
 
 	if err := query.Find(&list).Error; err != nil {
 		return nil, err
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines DB operations for api_key.
+type APIKeyRepository interface {
+	Create(key *domain.APIKey) error
+	GetByHash(keyHash string) (*domain.APIKey, error)
+	GetByIDForUser(userID, id uint) (*domain.APIKey, error)
+	GetAllByUser(userID uint) ([]domain.APIKey, error)
+	Update(key *domain.APIKey) error
+	TouchLastUsed(id uint, t time.Time) error
+	RevokeAllByUser(userID uint, t time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *domain.APIKey) error {
+	return r.db.Omit("Toko").Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByIDForUser(userID, id uint) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.Where("id_user = ?", userID).Preload("Toko").First(&key, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetAllByUser(userID uint) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	if err := r.db.Where("id_user = ?", userID).Preload("Toko").Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Update(key *domain.APIKey) error {
+	return r.db.Omit("Toko").Save(key).Error
+}
+
+// TouchLastUsed records key usage; at most one write per minute per key.
+func (r *apiKeyRepository) TouchLastUsed(id uint, t time.Time) error {
+	return r.db.Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, t.Add(-time.Minute)).
+		Update("last_used_at", t).Error
+}
+
+// RevokeAllByUser revokes every key of the user that is not revoked yet.
+func (r *apiKeyRepository) RevokeAllByUser(userID uint, t time.Time) error {
+	return r.db.Model(&domain.APIKey{}).
+		Where("id_user = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", t).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter represents filters for querying the audit log.
+type AuditLogFilter struct {
+	UserID     uint
+	Action     string
+	EntityType string
+	EntityID   uint
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// AuditLogRepository defines DB operations for audit_log.
+type AuditLogRepository interface {
+	Create(entry *domain.AuditLog) error
+	GetAll(limit, page int, filter AuditLogFilter) ([]domain.AuditLog, error)
+	DeleteBefore(t time.Time) (int64, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new AuditLogRepository.
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(entry *domain.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *auditLogRepository) GetAll(limit, page int, filter AuditLogFilter) ([]domain.AuditLog, error) {
+	var entries []domain.AuditLog
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	db := r.db.Model(&domain.AuditLog{})
+
+	if filter.UserID != 0 {
+		db = db.Where("id_user = ?", filter.UserID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		db = db.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		db = db.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", *filter.To)
+	}
+
+	if err := db.Order("id DESC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// DeleteBefore removes entries created before t and returns how many were removed.
+func (r *auditLogRepository) DeleteBefore(t time.Time) (int64, error) {
+	res := r.db.Where("created_at < ?", t).Delete(&domain.AuditLog{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ConversationRepository defines DB operations for conversation.
+type ConversationRepository interface {
+	Create(conversation *domain.Conversation) error
+	GetByID(id uint) (*domain.Conversation, error)
+	Find(userID, tokoID uint, produkID, trxID *uint) (*domain.Conversation, error)
+	GetAllByUser(userID uint, limit, page int) ([]domain.Conversation, error)
+	GetAllByToko(tokoID uint, limit, page int) ([]domain.Conversation, error)
+}
+
+// ChatMessageRepository defines DB operations for chat_message.
+type ChatMessageRepository interface {
+	Create(message *domain.ChatMessage, preview string) error
+	GetByConversation(conversationID, beforeID uint, limit int) ([]domain.ChatMessage, error)
+	GetForParticipantSince(userID uint, tokoIDs []uint, afterID uint, limit int) ([]domain.ChatMessage, error)
+	LatestIDForParticipant(userID uint, tokoIDs []uint) (uint, error)
+	MarkRead(conversationID uint, senderSide string, upToID uint, at time.Time) (int64, error)
+	CountUnread(conversationID uint, senderSide string) (int64, error)
+}
+
+type conversationRepository struct {
+	db *gorm.DB
+}
+
+type chatMessageRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository creates a new ConversationRepository.
+func NewConversationRepository(db *gorm.DB) ConversationRepository {
+	return &conversationRepository{db: db}
+}
+
+// NewChatMessageRepository creates a new ChatMessageRepository.
+func NewChatMessageRepository(db *gorm.DB) ChatMessageRepository {
+	return &chatMessageRepository{db: db}
+}
+
+func (r *conversationRepository) Create(conversation *domain.Conversation) error {
+	return r.db.Omit("User", "Toko").Create(conversation).Error
+}
+
+func (r *conversationRepository) GetByID(id uint) (*domain.Conversation, error) {
+	var conversation domain.Conversation
+	if err := r.db.Preload("User").Preload("Toko").First(&conversation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// Find returns the buyer's conversation with the toko about exactly the
+// given product and trx (nil meaning none).
+func (r *conversationRepository) Find(userID, tokoID uint, produkID, trxID *uint) (*domain.Conversation, error) {
+	query := r.db.Where("id_user = ? AND id_toko = ?", userID, tokoID)
+	if produkID != nil {
+		query = query.Where("id_produk = ?", *produkID)
+	} else {
+		query = query.Where("id_produk IS NULL")
+	}
+	if trxID != nil {
+		query = query.Where("id_trx = ?", *trxID)
+	} else {
+		query = query.Where("id_trx IS NULL")
+	}
+
+	var conversation domain.Conversation
+	if err := query.Preload("User").Preload("Toko").First(&conversation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (r *conversationRepository) GetAllByUser(userID uint, limit, page int) ([]domain.Conversation, error) {
+	return r.list(r.db.Where("id_user = ?", userID), limit, page)
+}
+
+func (r *conversationRepository) GetAllByToko(tokoID uint, limit, page int) ([]domain.Conversation, error) {
+	return r.list(r.db.Where("id_toko = ?", tokoID), limit, page)
+}
+
+// list pages conversations with the most recently active first.
+func (r *conversationRepository) list(query *gorm.DB, limit, page int) ([]domain.Conversation, error) {
+	var conversations []domain.Conversation
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	if err := query.Preload("User").Preload("Toko").
+		Order("last_message_at DESC").Order("id DESC").
+		Limit(limit).Offset(offset).
+		Find(&conversations).Error; err != nil {
+		return nil, err
+	}
+	return conversations, nil
+}
+
+// Create stores the message and updates the conversation's preview.
+func (r *chatMessageRepository) Create(message *domain.ChatMessage, preview string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Conversation{}).
+			Where("id = ?", message.ConversationID).
+			Updates(map[string]interface{}{"last_message": preview, "last_message_at": message.CreatedAt}).Error
+	})
+}
+
+// GetByConversation returns up to limit messages older than beforeID
+// (0 for the newest), newest first.
+func (r *chatMessageRepository) GetByConversation(conversationID, beforeID uint, limit int) ([]domain.ChatMessage, error) {
+	var messages []domain.ChatMessage
+
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := r.db.Where("id_conversation = ?", conversationID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// participantConversations selects the conversations a user takes part in,
+// as buyer or as a member of one of tokoIDs.
+func (r *chatMessageRepository) participantConversations(userID uint, tokoIDs []uint) *gorm.DB {
+	query := r.db.Model(&domain.Conversation{}).Select("id").Where("id_user = ?", userID)
+	if len(tokoIDs) > 0 {
+		query = query.Or("id_toko IN ?", tokoIDs)
+	}
+	return query
+}
+
+// GetForParticipantSince returns messages newer than afterID in any of the
+// user's conversations, oldest first.
+func (r *chatMessageRepository) GetForParticipantSince(userID uint, tokoIDs []uint, afterID uint, limit int) ([]domain.ChatMessage, error) {
+	var messages []domain.ChatMessage
+	if err := r.db.Where("id > ? AND id_conversation IN (?)", afterID, r.participantConversations(userID, tokoIDs)).
+		Order("id ASC").Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// LatestIDForParticipant returns the newest message ID in any of the
+// user's conversations, or 0.
+func (r *chatMessageRepository) LatestIDForParticipant(userID uint, tokoIDs []uint) (uint, error) {
+	var latest *uint
+	if err := r.db.Model(&domain.ChatMessage{}).
+		Where("id_conversation IN (?)", r.participantConversations(userID, tokoIDs)).
+		Select("MAX(id)").Scan(&latest).Error; err != nil {
+		return 0, err
+	}
+	if latest == nil {
+		return 0, nil
+	}
+	return *latest, nil
+}
+
+// MarkRead marks unread messages sent by senderSide up to upToID as read.
+func (r *chatMessageRepository) MarkRead(conversationID uint, senderSide string, upToID uint, at time.Time) (int64, error) {
+	res := r.db.Model(&domain.ChatMessage{}).
+		Where("id_conversation = ? AND sender_side = ? AND id <= ? AND read_at IS NULL", conversationID, senderSide, upToID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+// CountUnread counts messages sent by senderSide not yet read by the other side.
+func (r *chatMessageRepository) CountUnread(conversationID uint, senderSide string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.ChatMessage{}).
+		Where("id_conversation = ? AND sender_side = ? AND read_at IS NULL", conversationID, senderSide).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ImportJobRepository defines DB operations for import_job and import_job_error.
+type ImportJobRepository interface {
+	Create(job *domain.ImportJob) error
+	GetByID(id uint) (*domain.ImportJob, error)
+	GetClaimable(now time.Time) (*domain.ImportJob, error)
+	Claim(job *domain.ImportJob, now, leaseUntil time.Time) (bool, error)
+	GetFiles(id uint) (file, images []byte, err error)
+	SaveProgress(job *domain.ImportJob, rowError *domain.ImportJobError, leaseUntil time.Time) error
+	Finish(job *domain.ImportJob) error
+	GetErrors(jobID uint) ([]domain.ImportJobError, error)
+}
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+// NewImportJobRepository creates a new ImportJobRepository.
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(job *domain.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// GetByID returns a job without its uploaded files.
+func (r *importJobRepository) GetByID(id uint) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	if err := r.db.Omit("file", "images").First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetClaimable returns the oldest job that is pending, or running under an
+// expired lease, without its uploaded files.
+func (r *importJobRepository) GetClaimable(now time.Time) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	if err := r.db.Omit("file", "images").
+		Where("status = ? OR (status = ? AND lease_until < ?)", domain.ImportStatusPending, domain.ImportStatusRunning, now).
+		Order("id ASC").
+		First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim marks the job running under a lease until leaseUntil. Only one
+// worker (across replicas) wins the conditional update.
+func (r *importJobRepository) Claim(job *domain.ImportJob, now, leaseUntil time.Time) (bool, error) {
+	updates := map[string]interface{}{"status": domain.ImportStatusRunning, "lease_until": leaseUntil}
+	if job.StartedAt == nil {
+		updates["started_at"] = now
+	}
+	res := r.db.Model(&domain.ImportJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND lease_until < ?))", job.ID, domain.ImportStatusPending, domain.ImportStatusRunning, now).
+		Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	job.Status = domain.ImportStatusRunning
+	job.LeaseUntil = &leaseUntil
+	if job.StartedAt == nil {
+		job.StartedAt = &now
+	}
+	return true, nil
+}
+
+func (r *importJobRepository) GetFiles(id uint) ([]byte, []byte, error) {
+	var job domain.ImportJob
+	if err := r.db.Select("id", "file", "images").First(&job, id).Error; err != nil {
+		return nil, nil, err
+	}
+	return job.File, job.Images, nil
+}
+
+// SaveProgress stores the job's counters and extends its lease, together
+// with the error of the row just processed, if any.
+func (r *importJobRepository) SaveProgress(job *domain.ImportJob, rowError *domain.ImportJobError, leaseUntil time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if rowError != nil {
+			if err := tx.Create(rowError).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&domain.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"total_rows":     job.TotalRows,
+			"processed_rows": job.ProcessedRows,
+			"success_rows":   job.SuccessRows,
+			"failed_rows":    job.FailedRows,
+			"lease_until":    leaseUntil,
+		}).Error; err != nil {
+			return err
+		}
+		job.LeaseUntil = &leaseUntil
+		return nil
+	})
+}
+
+// Finish stores the job's final status and drops its uploaded files.
+func (r *importJobRepository) Finish(job *domain.ImportJob) error {
+	return r.db.Model(&domain.ImportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":         job.Status,
+		"total_rows":     job.TotalRows,
+		"processed_rows": job.ProcessedRows,
+		"success_rows":   job.SuccessRows,
+		"failed_rows":    job.FailedRows,
+		"last_error":     job.LastError,
+		"finished_at":    job.FinishedAt,
+		"lease_until":    nil,
+		"file":           nil,
+		"images":         nil,
+	}).Error
+}
+
+func (r *importJobRepository) GetErrors(jobID uint) ([]domain.ImportJobError, error) {
+	var rowErrors []domain.ImportJobError
+	if err := r.db.Where("id_job = ?", jobID).Order("line ASC").Find(&rowErrors).Error; err != nil {
+		return nil, err
+	}
+	return rowErrors, nil
+}
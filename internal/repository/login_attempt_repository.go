@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptRepository tracks failed login attempts per key.
+// The DB implementation is shared across replicas; the in-memory one suits
+// single-instance deployments and local development.
+type LoginAttemptRepository interface {
+	Get(key string) (*domain.LoginAttempt, error)
+	// RecordFailure increments the failure counter, restarting it from 1 when
+	// the previous failure is older than window, and returns the new state.
+	RecordFailure(key string, now time.Time, window time.Duration) (*domain.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a DB-backed LoginAttemptRepository.
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Get(key string) (*domain.LoginAttempt, error) {
+	var attempt domain.LoginAttempt
+	if err := r.db.Where("attempt_key = ?", key).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptRepository) RecordFailure(key string, now time.Time, window time.Duration) (*domain.LoginAttempt, error) {
+	attempt := domain.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	// Atomic upsert so concurrent replicas never lose an increment.
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("IF(last_failure_at < ?, 1, failures + 1)", now.Add(-window))},
+			{Column: clause.Column{Name: "last_failure_at"}, Value: now},
+			{Column: clause.Column{Name: "updated_at"}, Value: now},
+		},
+	}).Create(&attempt).Error; err != nil {
+		return nil, err
+	}
+
+	return r.Get(key)
+}
+
+func (r *loginAttemptRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&domain.LoginAttempt{}).
+		Where("attempt_key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (r *loginAttemptRepository) Reset(key string) error {
+	return r.db.Where("attempt_key = ?", key).Delete(&domain.LoginAttempt{}).Error
+}
+
+type memoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempt
+}
+
+// NewMemoryLoginAttemptRepository creates an in-memory LoginAttemptRepository.
+func NewMemoryLoginAttemptRepository() LoginAttemptRepository {
+	return &memoryLoginAttemptRepository{attempts: make(map[string]domain.LoginAttempt)}
+}
+
+func (r *memoryLoginAttemptRepository) Get(key string) (*domain.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (r *memoryLoginAttemptRepository) RecordFailure(key string, now time.Time, window time.Duration) (*domain.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(now.Add(-window)) {
+		attempt.Key = key
+		attempt.Failures = 0
+		attempt.CreatedAt = now
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	attempt.UpdatedAt = now
+	r.attempts[key] = attempt
+
+	return &attempt, nil
+}
+
+func (r *memoryLoginAttemptRepository) Lock(key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempt.LockedUntil = &until
+	r.attempts[key] = attempt
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) Reset(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
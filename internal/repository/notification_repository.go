@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository defines DB operations for notification.
+type NotificationRepository interface {
+	Create(notification *domain.Notification) (bool, error)
+	GetAllByUser(userID uint, limit, page int, unreadOnly bool) ([]domain.Notification, error)
+	GetSince(userID, afterID uint, limit int) ([]domain.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID, id uint, at time.Time) (bool, error)
+	MarkAllRead(userID uint, at time.Time) (int64, error)
+}
+
+// NotificationPreferenceRepository defines DB operations for notification_preference.
+type NotificationPreferenceRepository interface {
+	GetAllByUser(userID uint) ([]domain.NotificationPreference, error)
+	Upsert(prefs []domain.NotificationPreference) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+type notificationPreferenceRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository.
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// NewNotificationPreferenceRepository creates a new NotificationPreferenceRepository.
+func NewNotificationPreferenceRepository(db *gorm.DB) NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{db: db}
+}
+
+// Create stores a notification and reports whether it was new; one that
+// already exists for the same user, event, type and toko is left untouched.
+func (r *notificationRepository) Create(notification *domain.Notification) (bool, error) {
+	res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *notificationRepository) GetAllByUser(userID uint, limit, page int, unreadOnly bool) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	query := r.db.Where("id_user = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// GetSince returns the user's notifications newer than afterID, oldest first.
+func (r *notificationRepository) GetSince(userID, afterID uint, limit int) ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	if err := r.db.Where("id_user = ? AND id > ?", userID, afterID).
+		Order("id ASC").Limit(limit).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.Notification{}).
+		Where("id_user = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications read and reports whether it exists.
+func (r *notificationRepository) MarkRead(userID, id uint, at time.Time) (bool, error) {
+	var notification domain.Notification
+	res := r.db.Where("id = ? AND id_user = ?", id, userID).Limit(1).Find(&notification)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	if notification.ReadAt != nil {
+		return true, nil
+	}
+	return true, r.db.Model(&notification).Update("read_at", at).Error
+}
+
+func (r *notificationRepository) MarkAllRead(userID uint, at time.Time) (int64, error) {
+	res := r.db.Model(&domain.Notification{}).
+		Where("id_user = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+func (r *notificationPreferenceRepository) GetAllByUser(userID uint) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	if err := r.db.Where("id_user = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// Upsert creates or updates preferences keyed by user, type and channel.
+func (r *notificationPreferenceRepository) Upsert(prefs []domain.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id_user"}, {Name: "type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&prefs).Error
+}
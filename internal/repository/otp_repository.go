@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// OTPRepository defines DB operations for user_otp.
+type OTPRepository interface {
+	Create(otp *domain.UserOTP) error
+	GetActive(userID uint, purpose string) (*domain.UserOTP, error)
+	CountSince(userID uint, purpose string, since time.Time) (int64, error)
+	CountByIPSince(ip string, since time.Time) (int64, error)
+	Update(otp *domain.UserOTP) error
+	InvalidateAll(userID uint, purpose string) error
+}
+
+type otpRepository struct {
+	db *gorm.DB
+}
+
+// NewOTPRepository creates a new OTPRepository.
+func NewOTPRepository(db *gorm.DB) OTPRepository {
+	return &otpRepository{db: db}
+}
+
+func (r *otpRepository) Create(otp *domain.UserOTP) error {
+	return r.db.Create(otp).Error
+}
+
+// GetActive returns the latest unused and unexpired OTP for the purpose.
+func (r *otpRepository) GetActive(userID uint, purpose string) (*domain.UserOTP, error) {
+	var otp domain.UserOTP
+	if err := r.db.
+		Where("id_user = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", userID, purpose, time.Now()).
+		Order("id DESC").
+		First(&otp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func (r *otpRepository) CountSince(userID uint, purpose string, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.UserOTP{}).
+		Where("id_user = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountByIPSince counts codes requested from ip since, for any account.
+func (r *otpRepository) CountByIPSince(ip string, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.Model(&domain.UserOTP{}).
+		Where("ip = ? AND created_at >= ?", ip, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *otpRepository) Update(otp *domain.UserOTP) error {
+	return r.db.Save(otp).Error
+}
+
+func (r *otpRepository) InvalidateAll(userID uint, purpose string) error {
+	return r.db.Model(&domain.UserOTP{}).
+		Where("id_user = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
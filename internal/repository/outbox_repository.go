@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// OutboxFunc builds the domain events for a change once its rows are
+// written, so generated IDs can go into the payload. It runs inside the
+// same transaction; an error rolls the change back.
+type OutboxFunc func() ([]domain.OutboxEvent, error)
+
+// writeOutbox stores the events built by fn in tx. A nil fn writes nothing.
+func writeOutbox(tx *gorm.DB, fn OutboxFunc) error {
+	if fn == nil {
+		return nil
+	}
+	events, err := fn()
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range events {
+		if events[i].NextAttemptAt.IsZero() {
+			events[i].NextAttemptAt = now
+		}
+	}
+	return tx.Create(&events).Error
+}
+
+// OutboxRepository defines DB operations for dispatching outbox events.
+type OutboxRepository interface {
+	GetDue(now time.Time, limit int) ([]domain.OutboxEvent, error)
+	Claim(event *domain.OutboxEvent, leaseUntil time.Time) (bool, error)
+	Update(event *domain.OutboxEvent) error
+	GetAcks(eventID uint) ([]string, error)
+	Ack(eventID uint, subscriber string) error
+	DeleteDispatchedBefore(t time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository creates a new OutboxRepository.
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// GetDue returns pending events whose next attempt time has passed. Only
+// the oldest pending event of each aggregate is returned, so a failing
+// event holds back later events of the same aggregate until it is parked.
+func (r *outboxRepository) GetDue(now time.Time, limit int) ([]domain.OutboxEvent, error) {
+	var events []domain.OutboxEvent
+	if err := r.db.Where("dispatched_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_event prev
+			WHERE prev.aggregate_type = outbox_event.aggregate_type
+			AND prev.aggregate_id = outbox_event.aggregate_id
+			AND prev.dispatched_at IS NULL
+			AND prev.failed_at IS NULL
+			AND prev.id < outbox_event.id)`).
+		Order("id ASC").Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Claim leases a due event by moving its next attempt to leaseUntil.
+// Only one dispatcher (across replicas) wins the conditional update.
+func (r *outboxRepository) Claim(event *domain.OutboxEvent, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&domain.OutboxEvent{}).
+		Where("id = ? AND dispatched_at IS NULL AND failed_at IS NULL AND next_attempt_at = ?", event.ID, event.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	event.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+func (r *outboxRepository) Update(event *domain.OutboxEvent) error {
+	return r.db.Save(event).Error
+}
+
+// GetAcks returns the subscribers that already handled the event.
+func (r *outboxRepository) GetAcks(eventID uint) ([]string, error) {
+	var subscribers []string
+	if err := r.db.Model(&domain.OutboxAck{}).
+		Where("id_event = ?", eventID).
+		Pluck("subscriber", &subscribers).Error; err != nil {
+		return nil, err
+	}
+	return subscribers, nil
+}
+
+func (r *outboxRepository) Ack(eventID uint, subscriber string) error {
+	return r.db.Create(&domain.OutboxAck{EventID: eventID, Subscriber: subscriber}).Error
+}
+
+// DeleteDispatchedBefore removes events dispatched before t with their acks.
+func (r *outboxRepository) DeleteDispatchedBefore(t time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_event IN (?)",
+			tx.Model(&domain.OutboxEvent{}).Select("id").Where("dispatched_at < ?", t),
+		).Delete(&domain.OutboxAck{}).Error; err != nil {
+			return err
+		}
+		res := tx.Where("dispatched_at < ?", t).Delete(&domain.OutboxEvent{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidKeyset indicates a keyset that does not fit the list's order.
+var ErrInvalidKeyset = errors.New("invalid cursor")
+
+// Keyset is a position in a list ordered by a sort key ending in a unique
+// column: the key values of the boundary row, and whether to read the rows
+// before it. Rows before it come back nearest first, in reverse list order.
+type Keyset struct {
+	Values []string
+	Before bool
+}
+
+// keysetOrder is the order of a keyset-paginated list: key expressions,
+// the last one unique, all sorted in one direction.
+type keysetOrder struct {
+	exprs []clause.Expr
+	desc  bool
+}
+
+func orderBy(desc bool, exprs ...string) keysetOrder {
+	o := keysetOrder{desc: desc}
+	for _, e := range exprs {
+		o.exprs = append(o.exprs, clause.Expr{SQL: e})
+	}
+	return o
+}
+
+// apply orders db and, with a keyset, keeps only the rows past it.
+func (o keysetOrder) apply(db *gorm.DB, keyset *Keyset) (*gorm.DB, error) {
+	desc := o.desc
+	if keyset != nil {
+		if len(keyset.Values) != len(o.exprs) {
+			return nil, ErrInvalidKeyset
+		}
+		if keyset.Before {
+			desc = !desc
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		sqls := make([]string, len(o.exprs))
+		var vars []interface{}
+		for i, e := range o.exprs {
+			sqls[i] = e.SQL
+			vars = append(vars, e.Vars...)
+		}
+		for _, v := range keyset.Values {
+			vars = append(vars, v)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keyset.Values)), ", ")
+		db = db.Where("("+strings.Join(sqls, ", ")+") "+op+" ("+placeholders+")", vars...)
+	}
+
+	dir := " ASC"
+	if desc {
+		dir = " DESC"
+	}
+	// One expression: a later OrderBy expression replaces an earlier one
+	sqls := make([]string, len(o.exprs))
+	var vars []interface{}
+	for i, e := range o.exprs {
+		sqls[i] = e.SQL + dir
+		vars = append(vars, e.Vars...)
+	}
+	return db.Clauses(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(sqls, ", "), Vars: vars, WithoutParentheses: true}}), nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// ProductHistoryRepository defines DB operations for produk_history.
+type ProductHistoryRepository interface {
+	GetByProduct(productID uint, limit int, keyset *Keyset) ([]domain.ProdukHistory, error)
+	GetBetween(productID uint, from, to time.Time) ([]domain.ProdukHistory, error)
+	Backfill() (int64, error)
+}
+
+type productHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewProductHistoryRepository creates a new ProductHistoryRepository.
+func NewProductHistoryRepository(db *gorm.DB) ProductHistoryRepository {
+	return &productHistoryRepository{db: db}
+}
+
+// recordProductVersion writes the product's current name, prices and
+// stock as its next version, unless they equal the latest version. It
+// runs in the transaction that changed the product, after the product row
+// was written, so the row lock keeps versions of one product in order.
+func recordProductVersion(tx *gorm.DB, productID uint, source string) error {
+	var product domain.Produk
+	if err := tx.Unscoped().
+		Select("id", "nama_produk", "harga_reseller", "harga_konsumen", "stok").
+		First(&product, productID).Error; err != nil {
+		return err
+	}
+	hargaReseller, _ := strconv.Atoi(product.HargaReseller)
+	hargaKonsumen, _ := strconv.Atoi(product.HargaKonsumen)
+
+	var latest domain.ProdukHistory
+	if err := tx.Where("id_produk = ?", productID).
+		Order("version DESC").
+		Limit(1).
+		Find(&latest).Error; err != nil {
+		return err
+	}
+	if latest.ID != 0 && latest.NamaProduk == product.NamaProduk && latest.HargaReseller == hargaReseller &&
+		latest.HargaKonsumen == hargaKonsumen && latest.Stok == product.Stok {
+		return nil
+	}
+
+	return tx.Create(&domain.ProdukHistory{
+		ProdukID:      productID,
+		Version:       latest.Version + 1,
+		NamaProduk:    product.NamaProduk,
+		HargaReseller: hargaReseller,
+		HargaKonsumen: hargaKonsumen,
+		Stok:          product.Stok,
+		Source:        source,
+	}).Error
+}
+
+// GetByProduct returns the product's versions, newest first: up to limit
+// past keyset, or all of them when limit is 0.
+func (r *productHistoryRepository) GetByProduct(productID uint, limit int, keyset *Keyset) ([]domain.ProdukHistory, error) {
+	var versions []domain.ProdukHistory
+	db, err := orderBy(true, "version").apply(r.db.Where("id_produk = ?", productID), keyset)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetBetween returns the product's versions written from from up to, but
+// not including, to, oldest first, preceded by the version in effect at
+// from if there is one.
+func (r *productHistoryRepository) GetBetween(productID uint, from, to time.Time) ([]domain.ProdukHistory, error) {
+	var before []domain.ProdukHistory
+	if err := r.db.Where("id_produk = ? AND created_at < ?", productID, from).
+		Order("version DESC").
+		Limit(1).
+		Find(&before).Error; err != nil {
+		return nil, err
+	}
+
+	var versions []domain.ProdukHistory
+	if err := r.db.Where("id_produk = ? AND created_at >= ? AND created_at < ?", productID, from, to).
+		Order("version ASC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return append(before, versions...), nil
+}
+
+// Backfill gives every product without a history its current state as
+// version 1, dated at its last update, and returns how many it gave one.
+func (r *productHistoryRepository) Backfill() (int64, error) {
+	res := r.db.Exec(`INSERT INTO produk_history
+		(id_produk, version, nama_produk, harga_reseller, harga_konsumen, stok, source, created_at)
+		SELECT produk.id, 1, produk.nama_produk, CAST(produk.harga_reseller AS UNSIGNED),
+			CAST(produk.harga_konsumen AS UNSIGNED), produk.stok, ?, produk.updated_at
+		FROM produk
+		LEFT JOIN produk_history ON produk_history.id_produk = produk.id
+		WHERE produk_history.id IS NULL`, domain.ProductChangeInitial)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProductQARepository defines DB operations for product questions, their
+// answers, and the votes and reports on both.
+type ProductQARepository interface {
+	CreateQuestion(question *domain.ProductQuestion) error
+	CreateAnswer(answer *domain.ProductAnswer) error
+	GetQuestionByID(id uint) (*domain.ProductQuestion, error)
+	GetAnswerByID(id uint) (*domain.ProductAnswer, error)
+	GetVisibleByProduct(produkID uint, limit, page int) ([]domain.ProductQuestion, error)
+	GetReported(targetType string, limit, page int) ([]domain.ProductQuestion, []domain.ProductAnswer, error)
+	AddVote(vote *domain.QAVote) (bool, error)
+	RemoveVote(userID uint, targetType string, targetID uint) (bool, error)
+	AddReport(report *domain.QAReport, hideThreshold int, at time.Time) (bool, error)
+	SetHidden(targetType string, id uint, hiddenAt *time.Time) error
+}
+
+type productQARepository struct {
+	db *gorm.DB
+}
+
+// NewProductQARepository creates a new ProductQARepository.
+func NewProductQARepository(db *gorm.DB) ProductQARepository {
+	return &productQARepository{db: db}
+}
+
+// qaModel returns the model a Q&A target type is stored as.
+func qaModel(targetType string) interface{} {
+	if targetType == domain.QATargetAnswer {
+		return &domain.ProductAnswer{}
+	}
+	return &domain.ProductQuestion{}
+}
+
+func (r *productQARepository) CreateQuestion(question *domain.ProductQuestion) error {
+	return r.db.Omit("User", "Answers").Create(question).Error
+}
+
+func (r *productQARepository) CreateAnswer(answer *domain.ProductAnswer) error {
+	return r.db.Omit("User").Create(answer).Error
+}
+
+func (r *productQARepository) GetQuestionByID(id uint) (*domain.ProductQuestion, error) {
+	var question domain.ProductQuestion
+	if err := r.db.Preload("User").First(&question, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (r *productQARepository) GetAnswerByID(id uint) (*domain.ProductAnswer, error) {
+	var answer domain.ProductAnswer
+	if err := r.db.Preload("User").First(&answer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &answer, nil
+}
+
+// GetVisibleByProduct returns the product's questions that are not hidden,
+// most upvoted first, each with its visible answers: the toko's first,
+// then by upvotes.
+func (r *productQARepository) GetVisibleByProduct(produkID uint, limit, page int) ([]domain.ProductQuestion, error) {
+	var questions []domain.ProductQuestion
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	if err := r.db.Where("id_produk = ? AND hidden_at IS NULL", produkID).
+		Preload("User").
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Where("hidden_at IS NULL").
+				Order(clause.OrderBy{Expression: clause.Expr{SQL: "answered_as = ? DESC", Vars: []interface{}{domain.AnsweredAsToko}}}).
+				Order("upvotes DESC").Order("id ASC")
+		}).
+		Preload("Answers.User").
+		Order("upvotes DESC").Order("id DESC").
+		Limit(limit).Offset(offset).
+		Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// GetReported returns reported questions or answers, depending on
+// targetType, most reported first, hidden ones included.
+func (r *productQARepository) GetReported(targetType string, limit, page int) ([]domain.ProductQuestion, []domain.ProductAnswer, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	query := r.db.Where("report_count > 0").Preload("User").
+		Order("report_count DESC").Order("id DESC").
+		Limit(limit).Offset(offset)
+
+	if targetType == domain.QATargetAnswer {
+		var answers []domain.ProductAnswer
+		if err := query.Find(&answers).Error; err != nil {
+			return nil, nil, err
+		}
+		return nil, answers, nil
+	}
+	var questions []domain.ProductQuestion
+	if err := query.Find(&questions).Error; err != nil {
+		return nil, nil, err
+	}
+	return questions, nil, nil
+}
+
+// AddVote stores an upvote and bumps the target's count. It reports false
+// when the user already upvoted the target.
+func (r *productQARepository) AddVote(vote *domain.QAVote) (bool, error) {
+	var created bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected > 0
+		if !created {
+			return nil
+		}
+		return tx.Model(qaModel(vote.TargetType)).Where("id = ?", vote.TargetID).
+			UpdateColumn("upvotes", gorm.Expr("upvotes + 1")).Error
+	})
+	return created, err
+}
+
+// RemoveVote deletes an upvote and lowers the target's count. It reports
+// false when there was no upvote to remove.
+func (r *productQARepository) RemoveVote(userID uint, targetType string, targetID uint) (bool, error) {
+	var removed bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id_user = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+			Delete(&domain.QAVote{})
+		if res.Error != nil {
+			return res.Error
+		}
+		removed = res.RowsAffected > 0
+		if !removed {
+			return nil
+		}
+		return tx.Model(qaModel(targetType)).Where("id = ? AND upvotes > 0", targetID).
+			UpdateColumn("upvotes", gorm.Expr("upvotes - 1")).Error
+	})
+	return removed, err
+}
+
+// AddReport stores a report and bumps the target's report count, hiding
+// the target once the count reaches hideThreshold. It reports false when
+// the user already reported the target.
+func (r *productQARepository) AddReport(report *domain.QAReport, hideThreshold int, at time.Time) (bool, error) {
+	var created bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected > 0
+		if !created {
+			return nil
+		}
+		if err := tx.Model(qaModel(report.TargetType)).Where("id = ?", report.TargetID).
+			UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error; err != nil {
+			return err
+		}
+		if hideThreshold <= 0 {
+			return nil
+		}
+		return tx.Model(qaModel(report.TargetType)).
+			Where("id = ? AND report_count >= ? AND hidden_at IS NULL", report.TargetID, hideThreshold).
+			UpdateColumn("hidden_at", at).Error
+	})
+	return created, err
+}
+
+// SetHidden hides the target at hiddenAt, or shows it again when nil.
+func (r *productQARepository) SetHidden(targetType string, id uint, hiddenAt *time.Time) error {
+	return r.db.Model(qaModel(targetType)).Where("id = ?", id).UpdateColumn("hidden_at", hiddenAt).Error
+}
@@ -2,28 +2,129 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Product sort orders.
+const (
+	// ProductSortRating orders products by average rating, best first.
+	ProductSortRating = "rating"
+	// ProductSortPriceAsc orders products by consumer price, cheapest first.
+	ProductSortPriceAsc = "price_asc"
+	// ProductSortPriceDesc orders products by consumer price, dearest first.
+	ProductSortPriceDesc = "price_desc"
+	// ProductSortNewest orders products by creation, newest first.
+	ProductSortNewest = "newest"
+	// ProductSortBestSelling orders products by units sold, most first.
+	ProductSortBestSelling = "best_selling"
+)
+
+// Product slug scopes.
+const (
+	// SlugScopeToko keeps product slugs unique within each toko.
+	SlugScopeToko = "toko"
+	// SlugScopeGlobal keeps product slugs unique across all tokos.
+	SlugScopeGlobal = "global"
+)
+
+// ErrSlugAmbiguous indicates a slug looked up across tokos that more than
+// one toko's product has, which slugs unique per toko allow.
+var ErrSlugAmbiguous = errors.New("slug is used by more than one toko")
+
+// slugAttempts is how many times a product write is tried when a
+// concurrent write took the slug it picked.
+const slugAttempts = 3
+
+// ProductSorts lists the valid ProductFilter.Sort values.
+var ProductSorts = []string{ProductSortRating, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortNewest, ProductSortBestSelling}
+
 // ProductFilter represents filters for listing products.
 type ProductFilter struct {
-	NamaProduk string
+	NamaProduk  string
+	CategoryIDs []uint
+	TokoID      uint
+	MinHarga    int
+	MaxHarga    int
+	// InStock keeps only products with stock left.
+	InStock bool
+	// KotaID keeps only products of tokos whose owner lives in this city.
+	KotaID string
+	Sort   string
+	// Query is a full-text search; the usecase resolves it into IDs.
+	Query string
+	// IDs restricts the results to these products, listed in this order
+	// unless Sort is set.
+	IDs []uint
+	// IncludeArchived also lists archived products, for their toko.
+	IncludeArchived bool
+	// Statuses restricts the listing to these publication statuses; nil
+	// lists published products only.
+	Statuses []string
+	// Cursor is an opaque position from a previous page; the usecase
+	// resolves it into Keyset, which replaces the page offset.
+	Cursor string
+	Keyset *Keyset
+}
+
+// CategoryFacet is how many listed products are in one category.
+type CategoryFacet struct {
 	CategoryID uint
-	TokoID     uint
-	MinHarga   int
-	MaxHarga   int
+	Nama       string
+	Count      int64
+}
+
+// PriceBucketFacet is how many listed products have a consumer price from
+// Min up to, but not including, Max; Max 0 is unbounded.
+type PriceBucketFacet struct {
+	Min   int
+	Max   int
+	Count int64
 }
 
+// ProductFacets breaks a product listing down for filter menus. Each facet
+// ignores its own filter, so every choice shows what selecting it would give.
+type ProductFacets struct {
+	Categories   []CategoryFacet
+	PriceBuckets []PriceBucketFacet
+}
+
+// priceBucketBounds are the lower bounds of the price facet buckets.
+var priceBucketBounds = []int{0, 50000, 100000, 250000, 500000, 1000000}
+
+// productPrice is harga_konsumen as a number.
+const productPrice = "CAST(produk.harga_konsumen AS UNSIGNED)"
+
+// productSold is the units sold of a product, counted from detail_trx
+// through the log_produk snapshot of each line.
+const productSold = "(SELECT COALESCE(SUM(detail_trx.kuantitas), 0) FROM detail_trx JOIN log_produk ON log_produk.id = detail_trx.id_log_produk WHERE log_produk.id_produk = produk.id)"
+
 // ProductRepository defines DB operations for produk.
 type ProductRepository interface {
 	GetAll(limit, page int, filter ProductFilter) ([]domain.Produk, error)
+	Count(filter ProductFilter) (int64, error)
+	GetFacets(filter ProductFilter) (*ProductFacets, error)
 	GetByID(id uint) (*domain.Produk, error)
 	GetByIDForToko(tokoID, productID uint) (*domain.Produk, error)
-	Create(product *domain.Produk) error
-	Update(product *domain.Produk) error
-	Delete(id uint) error
+	GetBySlug(tokoID uint, slug string) (*domain.Produk, error)
+	GetByFormerSlug(tokoID uint, slug string) (*domain.Produk, error)
+	Create(product *domain.Produk, events OutboxFunc) error
+	Update(product *domain.Produk, events OutboxFunc) error
+	UpdateWithStock(product *domain.Produk, events OutboxFunc) error
+	Delete(id uint, events OutboxFunc) error
+	SetStatus(product *domain.Produk, from []string, events OutboxFunc) (bool, error)
+	GetDueScheduled(now time.Time, limit int) ([]domain.Produk, error)
+	GetVariantByID(id uint) (*domain.ProdukVariant, error)
+	ReplaceVariants(product *domain.Produk, options []domain.ProdukOption, variants []domain.ProdukVariant, events OutboxFunc) error
+	UpdateVariantStock(product *domain.Produk, variant *domain.ProdukVariant, events OutboxFunc) error
 }
 
 // FotoProdukRepository defines DB operations for foto_produk.
@@ -33,16 +134,18 @@ type FotoProdukRepository interface {
 }
 
 type productRepository struct {
-	db *gorm.DB
+	db        *gorm.DB
+	slugScope string
 }
 
 type fotoProdukRepository struct {
 	db *gorm.DB
 }
 
-// NewProductRepository creates a new ProductRepository.
-func NewProductRepository(db *gorm.DB) ProductRepository {
-	return &productRepository{db: db}
+// NewProductRepository creates a new ProductRepository. slugScope is
+// SlugScopeToko or SlugScopeGlobal.
+func NewProductRepository(db *gorm.DB, slugScope string) ProductRepository {
+	return &productRepository{db: db, slugScope: slugScope}
 }
 
 // NewFotoProdukRepository creates a new FotoProdukRepository.
@@ -61,38 +164,176 @@ func (r *productRepository) GetAll(limit, page int, filter ProductFilter) ([]dom
 	}
 	offset := (page - 1) * limit
 
-	db := r.db.Model(&domain.Produk{}).
+	db := preloadVariants(r.db.Model(&domain.Produk{})).
 		Preload("Toko").
 		Preload("Category").
 		Preload("FotoProduk")
+	db = applyProductFilter(db, filter)
 
+	if filter.Sort == ProductSortBestSelling {
+		db = db.Select("produk.*, " + productSold + " AS sold")
+	}
+	db, err := productOrder(filter).apply(db, filter.Keyset)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Keyset != nil {
+		offset = 0
+	}
+
+	if err := db.Limit(limit).Offset(offset).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// productOrder is the order of a product listing. ProductKeyValues gives
+// the matching key values of a product.
+func productOrder(filter ProductFilter) keysetOrder {
+	switch {
+	case filter.Sort == ProductSortRating:
+		return orderBy(true, "produk.rating_avg", "produk.rating_count", "produk.id")
+	case filter.Sort == ProductSortPriceAsc:
+		return orderBy(false, productPrice, "produk.id")
+	case filter.Sort == ProductSortPriceDesc:
+		return orderBy(true, productPrice, "produk.id")
+	case filter.Sort == ProductSortNewest:
+		return orderBy(true, "produk.created_at", "produk.id")
+	case filter.Sort == ProductSortBestSelling:
+		return orderBy(true, productSold, "produk.id")
+	case len(filter.IDs) > 0:
+		// Search relevance: the position in IDs
+		vars := make([]interface{}, len(filter.IDs))
+		for i, id := range filter.IDs {
+			vars[i] = id
+		}
+		field := clause.Expr{SQL: "FIELD(produk.id" + strings.Repeat(", ?", len(vars)) + ")", Vars: vars}
+		return keysetOrder{exprs: []clause.Expr{field, {SQL: "produk.id"}}}
+	default:
+		return orderBy(false, "produk.id")
+	}
+}
+
+// ProductKeyValues returns the sort key values of p in a listing with
+// filter, to build the keyset of the page after or before it.
+func ProductKeyValues(p *domain.Produk, filter ProductFilter) []string {
+	id := strconv.FormatUint(uint64(p.ID), 10)
+	switch {
+	case filter.Sort == ProductSortRating:
+		return []string{strconv.FormatFloat(p.RatingAvg, 'g', -1, 64), strconv.Itoa(p.RatingCount), id}
+	case filter.Sort == ProductSortPriceAsc, filter.Sort == ProductSortPriceDesc:
+		return []string{p.HargaKonsumen, id}
+	case filter.Sort == ProductSortNewest:
+		// The driver reads and writes DATETIME in local time (loc=Local)
+		return []string{p.CreatedAt.In(time.Local).Format("2006-01-02 15:04:05.999999"), id}
+	case filter.Sort == ProductSortBestSelling:
+		return []string{strconv.Itoa(p.Sold), id}
+	case len(filter.IDs) > 0:
+		return []string{strconv.Itoa(slices.Index(filter.IDs, p.ID) + 1), id}
+	default:
+		return []string{id}
+	}
+}
+
+// applyProductFilter adds the conditions of filter to db, a query on produk.
+func applyProductFilter(db *gorm.DB, filter ProductFilter) *gorm.DB {
+	if !filter.IncludeArchived {
+		db = db.Where("produk.archived_at IS NULL")
+	}
+	if filter.Statuses == nil {
+		db = db.Where("produk.status = ?", domain.ProductStatusPublished)
+	} else {
+		db = db.Where("produk.status IN ?", filter.Statuses)
+	}
 	if filter.NamaProduk != "" {
-		db = db.Where("nama_produk LIKE ?", "%"+filter.NamaProduk+"%")
+		db = db.Where("produk.nama_produk LIKE ?", "%"+filter.NamaProduk+"%")
 	}
-	if filter.CategoryID != 0 {
-		db = db.Where("id_category = ?", filter.CategoryID)
+	if len(filter.CategoryIDs) > 0 {
+		db = db.Where("produk.id_category IN ?", filter.CategoryIDs)
 	}
 	if filter.TokoID != 0 {
-		db = db.Where("id_toko = ?", filter.TokoID)
+		db = db.Where("produk.id_toko = ?", filter.TokoID)
 	}
 	if filter.MinHarga > 0 {
 		// filter based on harga_konsumen numeric value
-		db = db.Where("CAST(harga_konsumen AS UNSIGNED) >= ?", filter.MinHarga)
+		db = db.Where(productPrice+" >= ?", filter.MinHarga)
 	}
 	if filter.MaxHarga > 0 {
-		db = db.Where("CAST(harga_konsumen AS UNSIGNED) <= ?", filter.MaxHarga)
+		db = db.Where(productPrice+" <= ?", filter.MaxHarga)
+	}
+	if filter.InStock {
+		db = db.Where("produk.stok > 0")
+	}
+	if filter.KotaID != "" {
+		db = db.Where("produk.id_toko IN (SELECT toko.id FROM toko JOIN `user` ON `user`.id = toko.id_user WHERE `user`.id_kota = ?)", filter.KotaID)
+	}
+	if filter.IDs != nil {
+		db = db.Where("produk.id IN ?", filter.IDs)
 	}
+	return db
+}
 
-	if err := db.Limit(limit).Offset(offset).Find(&products).Error; err != nil {
+func (r *productRepository) Count(filter ProductFilter) (int64, error) {
+	var total int64
+	if err := applyProductFilter(r.db.Model(&domain.Produk{}), filter).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func (r *productRepository) GetFacets(filter ProductFilter) (*ProductFacets, error) {
+	facets := &ProductFacets{Categories: []CategoryFacet{}}
+
+	categoryFilter := filter
+	categoryFilter.CategoryIDs = nil
+	if err := applyProductFilter(r.db.Model(&domain.Produk{}), categoryFilter).
+		Select("produk.id_category AS category_id, category.nama_category AS nama, COUNT(*) AS count").
+		Joins("JOIN category ON category.id = produk.id_category").
+		Group("produk.id_category, category.nama_category").
+		Order("count DESC").
+		Order("produk.id_category ASC").
+		Scan(&facets.Categories).Error; err != nil {
 		return nil, err
 	}
 
-	return products, nil
+	priceFilter := filter
+	priceFilter.MinHarga, priceFilter.MaxHarga = 0, 0
+	selects := make([]string, len(priceBucketBounds))
+	args := make([]interface{}, 0, 2*len(priceBucketBounds))
+	for i, lower := range priceBucketBounds {
+		if i+1 < len(priceBucketBounds) {
+			selects[i] = fmt.Sprintf("COALESCE(SUM(%s >= ? AND %s < ?), 0)", productPrice, productPrice)
+			args = append(args, lower, priceBucketBounds[i+1])
+		} else {
+			selects[i] = fmt.Sprintf("COALESCE(SUM(%s >= ?), 0)", productPrice)
+			args = append(args, lower)
+		}
+	}
+	counts := make([]int64, len(priceBucketBounds))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := applyProductFilter(r.db.Model(&domain.Produk{}), priceFilter).
+		Select(strings.Join(selects, ", "), args...).
+		Row().Scan(dest...); err != nil {
+		return nil, err
+	}
+	for i, lower := range priceBucketBounds {
+		bucket := PriceBucketFacet{Min: lower, Count: counts[i]}
+		if i+1 < len(priceBucketBounds) {
+			bucket.Max = priceBucketBounds[i+1]
+		}
+		facets.PriceBuckets = append(facets.PriceBuckets, bucket)
+	}
+
+	return facets, nil
 }
 
 func (r *productRepository) GetByID(id uint) (*domain.Produk, error) {
 	var product domain.Produk
-	if err := r.db.Preload("Toko").Preload("Category").Preload("FotoProduk").First(&product, id).Error; err != nil {
+	if err := preloadVariants(r.db).Preload("Toko").Preload("Category").Preload("FotoProduk").First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -103,7 +344,7 @@ func (r *productRepository) GetByID(id uint) (*domain.Produk, error) {
 
 func (r *productRepository) GetByIDForToko(tokoID, productID uint) (*domain.Produk, error) {
 	var product domain.Produk
-	if err := r.db.Where("id_toko = ?", tokoID).
+	if err := preloadVariants(r.db).Where("id_toko = ?", tokoID).
 		Preload("Toko").Preload("Category").Preload("FotoProduk").
 		First(&product, productID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -114,16 +355,404 @@ func (r *productRepository) GetByIDForToko(tokoID, productID uint) (*domain.Prod
 	return &product, nil
 }
 
-func (r *productRepository) Create(product *domain.Produk) error {
-	return r.db.Create(product).Error
+// GetBySlug returns the published product with slug; tokoID 0 searches
+// every toko and fails with ErrSlugAmbiguous when several tokos have it.
+func (r *productRepository) GetBySlug(tokoID uint, slug string) (*domain.Produk, error) {
+	var products []domain.Produk
+	db := preloadVariants(r.db).Where("slug = ? AND status = ?", slug, domain.ProductStatusPublished)
+	if tokoID != 0 {
+		db = db.Where("id_toko = ?", tokoID)
+	}
+	if err := db.Preload("Toko").Preload("Category").Preload("FotoProduk").
+		Order("id ASC").
+		Limit(2).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	switch len(products) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &products[0], nil
+	}
+	return nil, ErrSlugAmbiguous
+}
+
+// GetByFormerSlug returns the product that last had slug before a rename;
+// tokoID 0 searches every toko and fails with ErrSlugAmbiguous when
+// products of several tokos had it.
+func (r *productRepository) GetByFormerSlug(tokoID uint, slug string) (*domain.Produk, error) {
+	var ids []uint
+	db := r.db.Model(&domain.ProdukSlugHistory{}).Where("slug = ?", slug)
+	if tokoID != 0 {
+		db = db.Where("id_toko = ?", tokoID)
+	}
+	if err := db.Distinct("id_produk").Limit(2).Pluck("id_produk", &ids).Error; err != nil {
+		return nil, err
+	}
+	switch len(ids) {
+	case 0:
+		return nil, nil
+	case 1:
+		return r.GetByID(ids[0])
+	}
+	return nil, ErrSlugAmbiguous
+}
+
+// Create stores product under the first free slug among product.Slug,
+// product.Slug-2, product.Slug-3 and so on.
+func (r *productRepository) Create(product *domain.Produk, events OutboxFunc) error {
+	return r.retrySlug(product, func() error {
+		return r.create(product, events)
+	})
+}
+
+func (r *productRepository) create(product *domain.Produk, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		slug, err := r.uniqueSlug(tx, product.Slug, product.TokoID, 0)
+		if err != nil {
+			return err
+		}
+		product.Slug = slug
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordProductVersion(tx, product.ID, domain.ProductChangeCreate); err != nil {
+			return err
+		}
+		return writeOutbox(tx, events)
+	})
+}
+
+// Update saves product except its stock, which is left to orders and to
+// UpdateWithStock; product.Stok is reloaded from the DB. A product.Slug
+// other than the stored one is taken as the base of a new slug, made
+// unique like in Create, and the old slug is kept in the history; a stored
+// slug already derived from the same base is kept. A changed name or price
+// is written as a new version.
+func (r *productRepository) Update(product *domain.Produk, events OutboxFunc) error {
+	return r.retrySlug(product, func() error {
+		return r.update(product, false, events)
+	})
+}
+
+// UpdateWithStock saves product like Update, and also sets its stock to
+// product.Stok.
+func (r *productRepository) UpdateWithStock(product *domain.Produk, events OutboxFunc) error {
+	return r.retrySlug(product, func() error {
+		return r.update(product, true, events)
+	})
+}
+
+func (r *productRepository) update(product *domain.Produk, withStock bool, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.changeSlug(tx, product); err != nil {
+			return err
+		}
+		// rating columns belong to the review repository; a stale copy
+		// loaded before a review came in must not overwrite them
+		// nor may it undo a status change made meanwhile, see SetStatus,
+		// nor put back stock an order has taken since, see takeStock; with
+		// variants the stock is their sum, see syncVariantStock
+		if err := tx.Omit("stok", "rating_avg", "rating_count", "status", "publish_at", "rejection_reason", "Options", "Variants").Save(product).Error; err != nil {
+			return err
+		}
+		stock := tx.Model(&domain.Produk{}).Where("id = ?", product.ID)
+		if withStock {
+			if err := stock.UpdateColumn("stok", product.Stok).Error; err != nil {
+				return err
+			}
+		} else if err := stock.Select("stok").Scan(&product.Stok).Error; err != nil {
+			return err
+		}
+		if err := recordProductVersion(tx, product.ID, domain.ProductChangeUpdate); err != nil {
+			return err
+		}
+		return writeOutbox(tx, events)
+	})
+}
+
+// SetStatus stores product's Status, PublishAt and RejectionReason if its
+// stored status is still one of from, and reports whether it was. A
+// moderator and the scheduler may act on the same product at once; only
+// the first change applies and writes its events.
+func (r *productRepository) SetStatus(product *domain.Produk, from []string, events OutboxFunc) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.Produk{}).
+			Where("id = ? AND status IN ?", product.ID, from).
+			Updates(map[string]interface{}{
+				"status":           product.Status,
+				"publish_at":       product.PublishAt,
+				"rejection_reason": product.RejectionReason,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		changed = true
+		return writeOutbox(tx, events)
+	})
+	return changed, err
+}
+
+// GetDueScheduled returns up to limit scheduled products whose publish
+// time has come, earliest first.
+func (r *productRepository) GetDueScheduled(now time.Time, limit int) ([]domain.Produk, error) {
+	var products []domain.Produk
+	if err := r.db.
+		Where("status = ? AND publish_at <= ?", domain.ProductStatusScheduled, now).
+		Order("publish_at ASC").
+		Limit(limit).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Delete soft-deletes the product. Its photos, options and variants stay,
+// since past orders still show them; its slug is freed for other products.
+func (r *productRepository) Delete(id uint, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_produk = ?", id).Delete(&domain.ProdukSlugHistory{}).Error; err != nil {
+			return err
+		}
+		// the unique slug indexes also cover soft-deleted rows
+		if err := tx.Model(&domain.Produk{}).Where("id = ?", id).
+			UpdateColumn("slug", gorm.Expr("CONCAT(slug, ?)", domain.DeletedSlugSuffix(id))).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&domain.Produk{}, id).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, events)
+	})
+}
+
+func (r *productRepository) GetVariantByID(id uint) (*domain.ProdukVariant, error) {
+	var variant domain.ProdukVariant
+	if err := r.db.First(&variant, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// ReplaceVariants swaps the product's options and variants for the given
+// ones. Variants keep their ID when their SKU is kept, so past trx and
+// open carts still point at them. When it has variants, the product's
+// stock is set to their sum, and so is product.Stok.
+func (r *productRepository) ReplaceVariants(product *domain.Produk, options []domain.ProdukOption, variants []domain.ProdukVariant, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteOptions(tx, product.ID); err != nil {
+			return err
+		}
+		for i := range options {
+			options[i].ProdukID = product.ID
+		}
+		if len(options) > 0 {
+			if err := tx.Create(&options).Error; err != nil {
+				return err
+			}
+		}
+
+		var existing []domain.ProdukVariant
+		if err := tx.Where("id_produk = ?", product.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		idBySKU := make(map[string]uint, len(existing))
+		for _, v := range existing {
+			idBySKU[v.SKU] = v.ID
+		}
+
+		kept := make([]uint, 0, len(variants))
+		for i := range variants {
+			variants[i].ProdukID = product.ID
+			if id, ok := idBySKU[variants[i].SKU]; ok {
+				variants[i].ID = id
+				if err := tx.Omit("created_at").Save(&variants[i]).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Create(&variants[i]).Error; err != nil {
+				return err
+			}
+			kept = append(kept, variants[i].ID)
+		}
+
+		removed := tx.Where("id_produk = ?", product.ID)
+		if len(kept) > 0 {
+			removed = removed.Where("id NOT IN ?", kept)
+		}
+		if err := removed.Delete(&domain.ProdukVariant{}).Error; err != nil {
+			return err
+		}
+
+		if len(variants) > 0 {
+			if err := syncVariantStock(tx, product); err != nil {
+				return err
+			}
+			if err := recordProductVersion(tx, product.ID, domain.ProductChangeVariants); err != nil {
+				return err
+			}
+		}
+		return writeOutbox(tx, events)
+	})
+}
+
+// UpdateVariantStock sets a variant of product to variant.Stok and
+// recomputes the product's stock, and product.Stok, as the sum over its
+// variants.
+func (r *productRepository) UpdateVariantStock(product *domain.Produk, variant *domain.ProdukVariant, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.ProdukVariant{}).Where("id = ?", variant.ID).Update("stok", variant.Stok).Error; err != nil {
+			return err
+		}
+		if err := syncVariantStock(tx, product); err != nil {
+			return err
+		}
+		if err := recordProductVersion(tx, product.ID, domain.ProductChangeVariants); err != nil {
+			return err
+		}
+		return writeOutbox(tx, events)
+	})
+}
+
+// syncVariantStock sets the stock of product, in the DB and in
+// product.Stok, to the sum over its variants as stored in tx. Computing it
+// in SQL keeps orders placed meanwhile from being counted twice or lost.
+func syncVariantStock(tx *gorm.DB, product *domain.Produk) error {
+	if err := tx.Exec(`UPDATE produk SET stok =
+		(SELECT COALESCE(SUM(stok), 0) FROM produk_variant WHERE id_produk = ?)
+		WHERE id = ?`, product.ID, product.ID).Error; err != nil {
+		return err
+	}
+	return tx.Model(&domain.Produk{}).Where("id = ?", product.ID).Select("stok").Scan(&product.Stok).Error
+}
+
+func (r *productRepository) changeSlug(tx *gorm.DB, product *domain.Produk) error {
+	var current string
+	if err := tx.Model(&domain.Produk{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", product.ID).
+		Pluck("slug", &current).Error; err != nil {
+		return err
+	}
+	if product.Slug == current {
+		return nil
+	}
+	if slugSuffix(current, product.Slug) >= 0 {
+		product.Slug = current
+		return nil
+	}
+
+	slug, err := r.uniqueSlug(tx, product.Slug, product.TokoID, product.ID)
+	if err != nil {
+		return err
+	}
+	product.Slug = slug
+	if current == "" || current == slug {
+		return nil
+	}
+	return tx.Create(&domain.ProdukSlugHistory{ProdukID: product.ID, TokoID: product.TokoID, Slug: current}).Error
+}
+
+// retrySlug runs write, which picks a slug for product from product.Slug,
+// again from the same base when the unique slug index rejects its pick.
+// The rows uniqueSlug locks cannot stop two writers from picking a slug
+// that no product had yet.
+func (r *productRepository) retrySlug(product *domain.Produk, write func() error) error {
+	base := product.Slug
+	for attempt := 1; ; attempt++ {
+		product.Slug = base
+		err := write()
+		if attempt == slugAttempts || !isDuplicateSlug(err) {
+			return err
+		}
+	}
 }
 
-func (r *productRepository) Update(product *domain.Produk) error {
-	return r.db.Save(product).Error
+// isDuplicateSlug reports whether err is MySQL refusing a slug that one of
+// the unique slug indexes already holds.
+func isDuplicateSlug(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 &&
+		(strings.Contains(mysqlErr.Message, domain.ProdukSlugIndex) || strings.Contains(mysqlErr.Message, domain.ProdukGlobalSlugIndex))
 }
 
-func (r *productRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.Produk{}, id).Error
+// uniqueSlug returns the first of base, base-2, base-3... that no other
+// product in the slug scope has, now or before a rename. The matching rows
+// stay locked until tx ends, so concurrent writers wait for each other.
+func (r *productRepository) uniqueSlug(tx *gorm.DB, base string, tokoID, productID uint) (string, error) {
+	scoped := func(db *gorm.DB) *gorm.DB {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("slug = ? OR slug LIKE ?", base, base+"-%")
+		if r.slugScope != SlugScopeGlobal {
+			db = db.Where("id_toko = ?", tokoID)
+		}
+		return db
+	}
+
+	var current, former []string
+	if err := scoped(tx.Model(&domain.Produk{})).Where("id <> ?", productID).Pluck("slug", &current).Error; err != nil {
+		return "", err
+	}
+	if err := scoped(tx.Model(&domain.ProdukSlugHistory{})).Where("id_produk <> ?", productID).Pluck("slug", &former).Error; err != nil {
+		return "", err
+	}
+
+	taken := make(map[int]bool)
+	for _, slug := range append(current, former...) {
+		if n := slugSuffix(slug, base); n >= 0 {
+			taken[n] = true
+		}
+	}
+	if !taken[0] {
+		return base, nil
+	}
+	n := 2
+	for taken[n] {
+		n++
+	}
+	return base + "-" + strconv.Itoa(n), nil
+}
+
+// slugSuffix returns n when slug is base-n, 0 when it is base itself, and
+// -1 when it is neither.
+func slugSuffix(slug, base string) int {
+	if slug == base {
+		return 0
+	}
+	rest, ok := strings.CutPrefix(slug, base+"-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n < 2 || strconv.Itoa(n) != rest {
+		return -1
+	}
+	return n
+}
+
+// preloadVariants loads a product's options, their values and variants in
+// display order.
+func preloadVariants(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("posisi ASC") }).
+		Preload("Options.Values", func(db *gorm.DB) *gorm.DB { return db.Order("posisi ASC") }).
+		Preload("Variants", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") })
+}
+
+// deleteOptions removes a product's options and their values.
+func deleteOptions(tx *gorm.DB, produkID uint) error {
+	if err := tx.Where("id_option IN (?)", tx.Model(&domain.ProdukOption{}).Select("id").Where("id_produk = ?", produkID)).
+		Delete(&domain.ProdukOptionValue{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id_produk = ?", produkID).Delete(&domain.ProdukOption{}).Error
 }
 
 func (r *fotoProdukRepository) CreateMany(photos []domain.FotoProduk) error {
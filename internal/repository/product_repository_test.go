@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func TestIsDuplicateSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"per-toko slug index", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '3-kopi' for key 'produk.idx_produk_toko_slug'"}, true},
+		{"global slug index", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'kopi' for key 'produk.idx_produk_slug_global'"}, true},
+		{"wrapped", fmt.Errorf("create: %w", &mysql.MySQLError{Number: 1062, Message: "for key 'idx_produk_toko_slug'"}), true},
+		{"other unique index", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1' for key 'PRIMARY'"}, false},
+		{"other mysql error", &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}, false},
+		{"other error", errors.New("idx_produk_toko_slug"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDuplicateSlug(tt.err); got != tt.want {
+				t.Errorf("isDuplicateSlug(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugSuffix(t *testing.T) {
+	tests := []struct {
+		slug, base string
+		want       int
+	}{
+		{"kopi", "kopi", 0},
+		{"kopi-2", "kopi", 2},
+		{"kopi-15", "kopi", 15},
+		{"kopi-1", "kopi", -1},
+		{"kopi-02", "kopi", -1},
+		{"kopi-gayo", "kopi", -1},
+		{"teh", "kopi", -1},
+		{"kopi~7", "kopi", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			if got := slugSuffix(tt.slug, tt.base); got != tt.want {
+				t.Errorf("slugSuffix(%q, %q) = %d, want %d", tt.slug, tt.base, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductUpdateAfterCheckout(t *testing.T) {
+	tests := []struct {
+		name      string
+		withStock bool
+		stok      int
+		want      int
+	}{
+		{"edit without stock keeps the checkout", false, 10, 7},
+		{"stock set by the seller", true, 25, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := &fakeProdukRow{values: map[string]driver.Value{
+				"id": int64(1), "slug": "kopi", "nama_produk": "Kopi", "harga_reseller": "9000", "harga_konsumen": "10000", "stok": int64(10),
+			}}
+			db := openFakeProduk(t, row)
+			repo := &productRepository{db: db, slugScope: SlugScopeToko}
+
+			// the seller loads the product, then a checkout takes 3 units
+			// before the edit is saved
+			product := &domain.Produk{ID: 1, Slug: "kopi", NamaProduk: "Kopi Gayo", HargaReseller: "9000", HargaKonsumen: "10000", Stok: 10}
+			if err := takeStock(db, &domain.Produk{}, 1, 3); err != nil {
+				t.Fatalf("takeStock: %v", err)
+			}
+
+			product.Stok = tt.stok
+			save := repo.Update
+			if tt.withStock {
+				save = repo.UpdateWithStock
+			}
+			if err := save(product, nil); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if got := row.stok(); got != tt.want {
+				t.Errorf("stored stok = %d, want %d", got, tt.want)
+			}
+			if product.Stok != tt.want {
+				t.Errorf("product.Stok = %d, want %d", product.Stok, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductUpdateDuringCheckouts(t *testing.T) {
+	row := &fakeProdukRow{values: map[string]driver.Value{
+		"id": int64(1), "slug": "kopi", "nama_produk": "Kopi", "harga_reseller": "9000", "harga_konsumen": "10000", "stok": int64(10),
+	}}
+	db := openFakeProduk(t, row)
+	repo := &productRepository{db: db, slugScope: SlugScopeToko}
+	product := &domain.Produk{ID: 1, Slug: "kopi", NamaProduk: "Kopi Gayo", HargaReseller: "9000", HargaKonsumen: "12000", Stok: 10}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- takeStock(db, &domain.Produk{}, 1, 1)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- repo.Update(product, nil)
+	}()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := row.stok(); got != 5 {
+		t.Errorf("stored stok = %d, want 5", got)
+	}
+}
+
+// fakeProdukRow is a database/sql driver holding a single produk row. It
+// understands just enough of the SQL the product repository writes to
+// apply stock changes: `stok`=? and `stok`=stok - ? in an UPDATE of
+// produk, and column lists in a SELECT from it. Other statements succeed
+// without effect and other tables read as empty.
+type fakeProdukRow struct {
+	mu     sync.Mutex
+	values map[string]driver.Value
+}
+
+func (r *fakeProdukRow) stok() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int(r.values["stok"].(int64))
+}
+
+func (r *fakeProdukRow) exec(query string, args []driver.Value) {
+	if !strings.HasPrefix(query, "UPDATE `produk` SET") {
+		return
+	}
+	i := strings.Index(query, "`stok`=")
+	if i < 0 {
+		return
+	}
+	arg := args[strings.Count(query[:i], "?")].(int64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch stok := r.values["stok"].(int64); {
+	case strings.HasPrefix(query[i:], "`stok`=stok - ?"):
+		if stok >= arg {
+			r.values["stok"] = stok - arg
+		}
+	case strings.HasPrefix(query[i:], "`stok`=?"):
+		r.values["stok"] = arg
+	}
+}
+
+func (r *fakeProdukRow) query(query string) (driver.Rows, error) {
+	from := strings.Index(query, " FROM ")
+	if !strings.HasPrefix(query, "SELECT ") || from < 0 || !strings.HasPrefix(query[from:], " FROM `produk` ") {
+		return &fakeRows{columns: []string{"id"}}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var columns []string
+	var values []driver.Value
+	for _, c := range strings.Split(query[len("SELECT "):from], ",") {
+		c = strings.Trim(c[strings.LastIndex(c, ".")+1:], "` ")
+		v, ok := r.values[c]
+		if !ok {
+			return nil, fmt.Errorf("fake produk: unknown column %q", c)
+		}
+		columns = append(columns, c)
+		values = append(values, v)
+	}
+	return &fakeRows{columns: columns, rows: [][]driver.Value{values}}, nil
+}
+
+func openFakeProduk(t *testing.T, row *fakeProdukRow) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(fakeConnector{row})
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return db
+}
+
+type fakeConnector struct{ row *fakeProdukRow }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ row *fakeProdukRow }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.row, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	row   *fakeProdukRow
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.row.exec(s.query, args)
+	return fakeResult{}, nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.row.query(s.query)
+}
+
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/search"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SearchHit is a product matching a search query and its relevance.
+type SearchHit struct {
+	ProductID uint
+	Score     float64
+}
+
+// ProductSearchRepository indexes products for full-text search over their
+// name, description, category and toko name. Every query term must match,
+// exactly or within its typo allowance; hits are ranked best first.
+// The MySQL implementation is shared across replicas; the in-memory one
+// suits tests and single-instance development.
+type ProductSearchRepository interface {
+	// Index adds or replaces a product; Category and Toko must be loaded.
+	Index(product *domain.Produk) error
+	Remove(productID uint) error
+	// Search returns up to limit hits, best first; all of them when limit
+	// is 0.
+	Search(query string, limit int) ([]SearchHit, error)
+}
+
+// searchFields returns the text of product to index, name first.
+func searchFields(product *domain.Produk) []string {
+	return []string{product.NamaProduk, product.Deskripsi, product.Category.Nama, product.Toko.NamaToko}
+}
+
+// fuzzyCandidateLimit caps the indexed terms considered as typo fixes of
+// one query term.
+const fuzzyCandidateLimit = 200
+
+// fulltextMinTokenSize is MySQL's default innodb_ft_min_token_size: shorter
+// words are left out of FULLTEXT indexes, so such query terms are matched
+// with LIKE instead.
+const fulltextMinTokenSize = 3
+
+type productSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewProductSearchRepository creates a ProductSearchRepository backed by
+// MySQL FULLTEXT indexes on produk_search.
+func NewProductSearchRepository(db *gorm.DB) ProductSearchRepository {
+	return &productSearchRepository{db: db}
+}
+
+func (r *productSearchRepository) Index(product *domain.Produk) error {
+	var terms []string
+	for _, field := range searchFields(product) {
+		terms = append(terms, search.Terms(field)...)
+	}
+	doc := domain.ProdukSearch{
+		ProdukID:  product.ID,
+		Terms:     strings.Join(terms, " "),
+		NameTerms: strings.Join(search.Terms(product.NamaProduk), " "),
+	}
+
+	seen := make(map[string]bool)
+	var vocab []domain.SearchTerm
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			vocab = append(vocab, domain.SearchTerm{Term: term})
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&doc).Error; err != nil {
+			return err
+		}
+		if len(vocab) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&vocab).Error
+	})
+}
+
+func (r *productSearchRepository) Remove(productID uint) error {
+	return r.db.Where("id_produk = ?", productID).Delete(&domain.ProdukSearch{}).Error
+}
+
+// Search builds a boolean FULLTEXT query requiring every query term, each
+// OR-ed with lower-weighted typo corrections from search_term. Terms too
+// short for the FULLTEXT index must instead start a word of the document.
+// Name matches add to the score.
+func (r *productSearchRepository) Search(query string, limit int) ([]SearchHit, error) {
+	terms := search.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var required, optional, short []string
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < fulltextMinTokenSize {
+			short = append(short, term)
+			continue
+		}
+		alternatives, err := r.fuzzyAlternatives(term)
+		if err != nil {
+			return nil, err
+		}
+		group := term
+		for _, alt := range alternatives {
+			group += " <" + alt
+		}
+		required = append(required, "+("+group+")")
+		optional = append(optional, group)
+	}
+
+	q := r.db.Model(&domain.ProdukSearch{})
+	score := "0"
+	var scoreArgs []interface{}
+	if len(required) > 0 {
+		requiredExpr := strings.Join(required, " ")
+		score = "MATCH(terms) AGAINST(? IN BOOLEAN MODE) + 2 * MATCH(name_terms) AGAINST(? IN BOOLEAN MODE)"
+		scoreArgs = append(scoreArgs, requiredExpr, strings.Join(optional, " "))
+		q = q.Where("MATCH(terms) AGAINST(? IN BOOLEAN MODE)", requiredExpr)
+	}
+	for _, term := range short {
+		// terms are letters and digits only, so hold no LIKE wildcards
+		pattern := "% " + term + "%"
+		score += " + 2 * (CONCAT(' ', name_terms) LIKE ?)"
+		scoreArgs = append(scoreArgs, pattern)
+		q = q.Where("CONCAT(' ', terms) LIKE ?", pattern)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var rows []struct {
+		ProdukID uint
+		Score    float64
+	}
+	if err := q.Select("id_produk, "+score+" AS score", scoreArgs...).
+		Order("score DESC").
+		Order("id_produk ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, len(rows))
+	for i, row := range rows {
+		hits[i] = SearchHit{ProductID: row.ProdukID, Score: row.Score}
+	}
+	return hits, nil
+}
+
+// fuzzyAlternatives returns indexed terms other than term within its typo
+// allowance. Only terms sharing the first letter are considered.
+func (r *productSearchRepository) fuzzyAlternatives(term string) ([]string, error) {
+	maxEdits := search.MaxEdits(term)
+	if maxEdits == 0 {
+		return nil, nil
+	}
+	n := len([]rune(term))
+	var candidates []string
+	if err := r.db.Model(&domain.SearchTerm{}).
+		Where("term LIKE ? AND term <> ? AND CHAR_LENGTH(term) BETWEEN ? AND ?", string([]rune(term)[:1])+"%", term, n-maxEdits, n+maxEdits).
+		Limit(fuzzyCandidateLimit).
+		Pluck("term", &candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var alternatives []string
+	for _, candidate := range candidates {
+		if search.Matches(term, candidate) {
+			alternatives = append(alternatives, candidate)
+		}
+	}
+	return alternatives, nil
+}
+
+// BM25 ranking parameters and field weights of the in-memory index.
+const (
+	bm25K1          = 1.2
+	bm25B           = 0.75
+	nameFieldWeight = 3
+	// fuzzyPenalty scales the score of a term matched through a typo.
+	fuzzyPenalty = 0.7
+)
+
+type memoryDoc struct {
+	// terms maps each term to its field-weighted frequency.
+	terms  map[string]float64
+	length float64
+}
+
+type memoryProductSearchRepository struct {
+	mu       sync.RWMutex
+	docs     map[uint]*memoryDoc
+	postings map[string]map[uint]float64
+	totalLen float64
+}
+
+// NewMemoryProductSearchRepository creates an in-memory ProductSearchRepository:
+// an inverted index ranked with BM25.
+func NewMemoryProductSearchRepository() ProductSearchRepository {
+	return &memoryProductSearchRepository{
+		docs:     make(map[uint]*memoryDoc),
+		postings: make(map[string]map[uint]float64),
+	}
+}
+
+func (r *memoryProductSearchRepository) Index(product *domain.Produk) error {
+	doc := &memoryDoc{terms: make(map[string]float64)}
+	for i, field := range searchFields(product) {
+		weight := 1.0
+		if i == 0 {
+			weight = nameFieldWeight
+		}
+		for _, term := range search.Terms(field) {
+			doc.terms[term] += weight
+			doc.length += weight
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(product.ID)
+	r.docs[product.ID] = doc
+	r.totalLen += doc.length
+	for term, tf := range doc.terms {
+		if r.postings[term] == nil {
+			r.postings[term] = make(map[uint]float64)
+		}
+		r.postings[term][product.ID] = tf
+	}
+	return nil
+}
+
+func (r *memoryProductSearchRepository) Remove(productID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(productID)
+	return nil
+}
+
+func (r *memoryProductSearchRepository) remove(productID uint) {
+	doc, ok := r.docs[productID]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(r.postings[term], productID)
+		if len(r.postings[term]) == 0 {
+			delete(r.postings, term)
+		}
+	}
+	r.totalLen -= doc.length
+	delete(r.docs, productID)
+}
+
+func (r *memoryProductSearchRepository) Search(query string, limit int) ([]SearchHit, error) {
+	terms := search.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.docs) == 0 {
+		return nil, nil
+	}
+	n := float64(len(r.docs))
+	avgLen := r.totalLen / n
+
+	var scores map[uint]float64
+	for _, term := range terms {
+		// A document scores its best match among term and its typo corrections
+		best := make(map[uint]float64)
+		for candidate, postings := range r.postings {
+			if !search.Matches(term, candidate) {
+				continue
+			}
+			df := float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for id, tf := range postings {
+				if scores != nil {
+					if _, ok := scores[id]; !ok {
+						continue
+					}
+				}
+				length := r.docs[id].length
+				score := idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLen))
+				if candidate != term {
+					score *= fuzzyPenalty
+				}
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			best[id] = score + scores[id]
+		}
+		scores = best
+		if len(scores) == 0 {
+			return nil, nil
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, SearchHit{ProductID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ProductID < hits[j].ProductID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func searchProduct(id uint, nama, deskripsi, category, toko string) *domain.Produk {
+	return &domain.Produk{
+		ID:         id,
+		NamaProduk: nama,
+		Deskripsi:  deskripsi,
+		Category:   domain.Category{Nama: category},
+		Toko:       domain.Toko{NamaToko: toko},
+	}
+}
+
+func TestMemoryProductSearchRanking(t *testing.T) {
+	repo := NewMemoryProductSearchRepository()
+	products := []*domain.Produk{
+		searchProduct(1, "Kopi Gayo Arabika", "Biji kopi pilihan dari Aceh", "Minuman", "Toko Kopi Nusantara"),
+		searchProduct(2, "Teh Hijau", "Teh hijau segar, cocok dengan kopi", "Minuman", "Warung Teh"),
+		searchProduct(3, "Gelas Kaca", "Gelas untuk kopi dan teh", "Peralatan Dapur", "Dapur Sehat"),
+		searchProduct(4, "Sepatu Lari", "Sepatu ringan untuk berlari", "Olahraga", "Sport Center"),
+		searchProduct(5, "Kopi Toraja", "Kopi robusta", "Minuman", "Kedai Timur"),
+	}
+	for _, p := range products {
+		if err := repo.Index(p); err != nil {
+			t.Fatalf("Index(%d): %v", p.ID, err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []uint
+	}{
+		// name matches rank first; BM25 saturates term frequency, so the
+		// shorter product 5 beats product 1 despite fewer mentions
+		{"name beats description", "kopi", 0, []uint{5, 1, 3, 2}},
+		{"every term must match", "kopi teh", 0, []uint{2, 3}},
+		{"inflection", "sepatunya", 0, []uint{4}},
+		{"typo", "kopi gayp", 0, []uint{1}},
+		{"limit", "kopi", 2, []uint{5, 1}},
+		{"no match", "laptop", 0, nil},
+		{"only stopwords", "yang dan", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := repo.Search(tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Search(%q): %v", tt.query, err)
+			}
+			var got []uint
+			for i, hit := range hits {
+				got = append(got, hit.ProductID)
+				if i > 0 && hit.Score > hits[i-1].Score {
+					t.Errorf("Search(%q) hit %d scores above hit %d", tt.query, i, i-1)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryProductSearchReindexAndRemove(t *testing.T) {
+	repo := NewMemoryProductSearchRepository()
+	_ = repo.Index(searchProduct(1, "Kopi Gayo", "", "Minuman", "Toko A"))
+	_ = repo.Index(searchProduct(2, "Teh Melati", "", "Minuman", "Toko B"))
+
+	// renaming a product replaces its terms
+	_ = repo.Index(searchProduct(1, "Cokelat Panas", "", "Minuman", "Toko A"))
+	if hits, _ := repo.Search("kopi", 0); len(hits) != 0 {
+		t.Errorf("Search(kopi) after rename = %v, want none", hits)
+	}
+	if hits, _ := repo.Search("cokelat", 0); len(hits) != 1 || hits[0].ProductID != 1 {
+		t.Errorf("Search(cokelat) = %v, want product 1", hits)
+	}
+
+	_ = repo.Remove(2)
+	if hits, _ := repo.Search("teh", 0); len(hits) != 0 {
+		t.Errorf("Search(teh) after remove = %v, want none", hits)
+	}
+}
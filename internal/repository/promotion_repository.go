@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrPromotionEnded indicates a promotion that ended before the order was placed.
+	ErrPromotionEnded = errors.New("promo sudah berakhir")
+	// ErrPromotionSoldOut indicates an order for more units than the promotion's sale stock has left.
+	ErrPromotionSoldOut = errors.New("stok promo tidak cukup")
+	// ErrPromotionLimitReached indicates an order taking a buyer past the promotion's per-user limit.
+	ErrPromotionLimitReached = errors.New("melebihi batas pembelian promo")
+)
+
+// PromotionRepository defines DB operations for promotion.
+type PromotionRepository interface {
+	Create(promotion *domain.Promotion) error
+	GetByID(id uint) (*domain.Promotion, error)
+	GetAllByToko(tokoID uint) ([]domain.Promotion, error)
+	GetRunningFor(products []*domain.Produk, now time.Time) ([]domain.Promotion, error)
+	GetRunningFlashSales(now time.Time, limit, page int) ([]domain.Promotion, error)
+	GetBoughtByUser(userID uint, promotionIDs []uint) (map[uint]int, error)
+	Update(promotion *domain.Promotion) error
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+// NewPromotionRepository creates a new PromotionRepository.
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (r *promotionRepository) Create(promotion *domain.Promotion) error {
+	return r.db.Create(promotion).Error
+}
+
+func (r *promotionRepository) GetByID(id uint) (*domain.Promotion, error) {
+	var promotion domain.Promotion
+	if err := r.db.First(&promotion, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// GetAllByToko returns the toko's promotions, latest start first.
+func (r *promotionRepository) GetAllByToko(tokoID uint) ([]domain.Promotion, error) {
+	var promotions []domain.Promotion
+	if err := r.db.Where("id_toko = ?", tokoID).
+		Order("start_at DESC, id DESC").
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetRunningFor returns the promotions running at now that cover any of
+// products, by product or by toko and category.
+func (r *promotionRepository) GetRunningFor(products []*domain.Produk, now time.Time) ([]domain.Promotion, error) {
+	if len(products) == 0 {
+		return nil, nil
+	}
+	productIDs := make([]uint, 0, len(products))
+	tokoIDs := make([]uint, 0, len(products))
+	categoryIDs := make([]uint, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+		tokoIDs = append(tokoIDs, p.TokoID)
+		categoryIDs = append(categoryIDs, p.CategoryID)
+	}
+
+	var promotions []domain.Promotion
+	if err := runningPromotions(r.db, now).
+		Where("id_produk IN ? OR (id_produk IS NULL AND id_toko IN ? AND id_category IN ?)", productIDs, tokoIDs, categoryIDs).
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// GetRunningFlashSales returns, for a page of the products on sale in a
+// flash sale running at now, the flash sale of each that ends soonest;
+// one per product, ending soonest first.
+func (r *promotionRepository) GetRunningFlashSales(now time.Time, limit, page int) ([]domain.Promotion, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	flashSales := func() *gorm.DB {
+		return runningPromotions(r.db.Model(&domain.Promotion{}), now).
+			Where("promotion.flash_sale = ?", true).
+			Joins("JOIN produk ON produk.id = promotion.id_produk").
+			Where("produk.status = ? AND produk.archived_at IS NULL AND produk.deleted_at IS NULL", domain.ProductStatusPublished)
+	}
+
+	var productIDs []uint
+	if err := flashSales().
+		Group("promotion.id_produk").
+		Order("MIN(promotion.end_at) ASC, promotion.id_produk ASC").
+		Limit(limit).
+		Offset((page-1)*limit).
+		Pluck("promotion.id_produk", &productIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	var promotions []domain.Promotion
+	if err := flashSales().
+		Where("promotion.id_produk IN ?", productIDs).
+		Order("promotion.end_at ASC, promotion.id ASC").
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	first := make(map[uint]domain.Promotion, len(productIDs))
+	for _, promotion := range promotions {
+		if _, ok := first[*promotion.ProdukID]; !ok {
+			first[*promotion.ProdukID] = promotion
+		}
+	}
+	sales := make([]domain.Promotion, 0, len(productIDs))
+	for _, id := range productIDs {
+		if promotion, ok := first[id]; ok {
+			sales = append(sales, promotion)
+		}
+	}
+	return sales, nil
+}
+
+// GetBoughtByUser returns, per promotion, how many units the user has
+// bought at it.
+func (r *promotionRepository) GetBoughtByUser(userID uint, promotionIDs []uint) (map[uint]int, error) {
+	bought := make(map[uint]int, len(promotionIDs))
+	if len(promotionIDs) == 0 {
+		return bought, nil
+	}
+	var rows []struct {
+		PromotionID uint
+		Kuantitas   int
+	}
+	if err := r.db.Model(&domain.PromotionUsage{}).
+		Select("id_promotion AS promotion_id, SUM(kuantitas) AS kuantitas").
+		Where("id_user = ? AND id_promotion IN ?", userID, promotionIDs).
+		Group("id_promotion").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		bought[row.PromotionID] = row.Kuantitas
+	}
+	return bought, nil
+}
+
+func (r *promotionRepository) Update(promotion *domain.Promotion) error {
+	// sold belongs to claimPromotion; a stale copy must not overwrite it
+	return r.db.Omit("sold").Save(promotion).Error
+}
+
+// runningPromotions scopes db to promotions running at now; see
+// domain.Promotion.Running.
+func runningPromotions(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("promotion.start_at <= ? AND promotion.end_at > ? AND (promotion.sale_stock = 0 OR promotion.sold < promotion.sale_stock)", now, now)
+}
+
+// claimPromotion takes usage's units from its promotion in the
+// transaction placing the order and records the usage. The promotion row
+// stays locked until the order commits, so concurrent orders cannot
+// oversell the sale stock or a buyer's limit.
+func claimPromotion(tx *gorm.DB, usage *domain.PromotionUsage, now time.Time) error {
+	var promotion domain.Promotion
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, usage.PromotionID).Error; err != nil {
+		return err
+	}
+	if now.Before(promotion.StartAt) || !now.Before(promotion.EndAt) {
+		return ErrPromotionEnded
+	}
+	if promotion.SaleStock > 0 && promotion.Sold+usage.Kuantitas > promotion.SaleStock {
+		return ErrPromotionSoldOut
+	}
+	if promotion.MaxPerUser > 0 {
+		var bought int
+		if err := tx.Model(&domain.PromotionUsage{}).
+			Where("id_promotion = ? AND id_user = ?", promotion.ID, usage.UserID).
+			Select("COALESCE(SUM(kuantitas), 0)").
+			Scan(&bought).Error; err != nil {
+			return err
+		}
+		if bought+usage.Kuantitas > promotion.MaxPerUser {
+			return ErrPromotionLimitReached
+		}
+	}
+
+	if err := tx.Model(&domain.Promotion{}).
+		Where("id = ?", promotion.ID).
+		UpdateColumn("sold", gorm.Expr("sold + ?", usage.Kuantitas)).Error; err != nil {
+		return err
+	}
+	return tx.Create(usage).Error
+}
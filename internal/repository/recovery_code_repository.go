@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository defines DB operations for recovery_code.
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uint, codes []domain.RecoveryCode) error
+	FindUnused(userID uint, codeHash string) (*domain.RecoveryCode, error)
+	MarkUsed(id uint) error
+	DeleteByUser(userID uint) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository creates a new RecoveryCodeRepository.
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) ReplaceForUser(userID uint, codes []domain.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_user = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *recoveryCodeRepository) FindUnused(userID uint, codeHash string) (*domain.RecoveryCode, error) {
+	var code domain.RecoveryCode
+	if err := r.db.Where("id_user = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		First(&code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkUsed consumes a recovery code; it fails if the code was used concurrently.
+func (r *recoveryCodeRepository) MarkUsed(id uint) error {
+	result := r.db.Model(&domain.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("id_user = ?", userID).Delete(&domain.RecoveryCode{}).Error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewRepository defines DB operations for review.
+type ReviewRepository interface {
+	Create(review *domain.Review) (bool, error)
+	GetByID(id uint) (*domain.Review, error)
+	GetAllByProduct(produkID uint, rating, limit, page int) ([]domain.Review, error)
+	CountByRating(produkID uint) (map[int]int64, error)
+	UpdateReply(id uint, reply string, repliedBy uint, at time.Time) error
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository creates a new ReviewRepository.
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// Create stores a review and refreshes the product's rating aggregate in
+// the same transaction. It reports false when the detail_trx line already
+// has a review.
+func (r *reviewRepository) Create(review *domain.Review) (bool, error) {
+	var created bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Omit("User").Clauses(clause.OnConflict{DoNothing: true}).Create(review)
+		if res.Error != nil {
+			return res.Error
+		}
+		created = res.RowsAffected > 0
+		if !created {
+			return nil
+		}
+		return tx.Exec(`UPDATE produk SET
+			rating_avg = (SELECT COALESCE(AVG(rating), 0) FROM review WHERE id_produk = ?),
+			rating_count = (SELECT COUNT(*) FROM review WHERE id_produk = ?)
+			WHERE id = ?`, review.ProdukID, review.ProdukID, review.ProdukID).Error
+	})
+	return created, err
+}
+
+func (r *reviewRepository) GetByID(id uint) (*domain.Review, error) {
+	var review domain.Review
+	if err := r.db.Preload("User").First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetAllByProduct returns the product's reviews newest first, optionally
+// only those with the given rating.
+func (r *reviewRepository) GetAllByProduct(produkID uint, rating, limit, page int) ([]domain.Review, error) {
+	var reviews []domain.Review
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	query := r.db.Where("id_produk = ?", produkID)
+	if rating != 0 {
+		query = query.Where("rating = ?", rating)
+	}
+	if err := query.Preload("User").Order("id DESC").Limit(limit).Offset(offset).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// CountByRating returns how many reviews the product has per rating.
+func (r *reviewRepository) CountByRating(produkID uint) (map[int]int64, error) {
+	var rows []struct {
+		Rating int
+		Total  int64
+	}
+	if err := r.db.Model(&domain.Review{}).
+		Select("rating, COUNT(*) AS total").
+		Where("id_produk = ?", produkID).
+		Group("rating").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Rating] = row.Total
+	}
+	return counts, nil
+}
+
+func (r *reviewRepository) UpdateReply(id uint, reply string, repliedBy uint, at time.Time) error {
+	return r.db.Model(&domain.Review{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"reply": reply, "replied_by": repliedBy, "replied_at": at}).Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepository defines DB operations for roles, permissions and user roles.
+type RoleRepository interface {
+	Seed(rolePermissions map[string][]string) error
+	BackfillUserRoles(defaultRoles []string, adminRole string) error
+	GetAll() ([]domain.Role, error)
+	GetByNames(names []string) ([]domain.Role, error)
+	SetUserRoles(userID uint, roles []domain.Role) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new RoleRepository.
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Seed creates missing roles with their permissions. Roles that already
+// exist are left untouched so grants edited in the DB are kept.
+func (r *roleRepository) Seed(rolePermissions map[string][]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permNames := range rolePermissions {
+			var role domain.Role
+			err := tx.Where("name = ?", roleName).First(&role).Error
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			perms := make([]domain.Permission, 0, len(permNames))
+			for _, name := range permNames {
+				perm := domain.Permission{Name: name}
+				if err := tx.Where("name = ?", name).FirstOrCreate(&perm).Error; err != nil {
+					return err
+				}
+				perms = append(perms, perm)
+			}
+
+			role = domain.Role{Name: roleName, Permissions: perms}
+			if err := tx.Create(&role).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BackfillUserRoles grants defaultRoles to users without any role and
+// adminRole to every is_admin user, once: a data_migration row records
+// that it ran, so roles revoked afterwards stay revoked across restarts.
+// Only users created before the first role was seeded are touched, since
+// later users got their roles on register.
+func (r *roleRepository) BackfillUserRoles(defaultRoles []string, adminRole string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		marker := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&domain.DataMigration{Name: domain.DataMigrationUserRoles})
+		if marker.Error != nil {
+			return marker.Error
+		}
+		if marker.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Exec(
+			"INSERT INTO user_role (id_user, id_role) "+
+				"SELECT u.id, r.id FROM `user` u JOIN role r ON r.name IN ? "+
+				"WHERE u.created_at < (SELECT MIN(created_at) FROM role) AND NOT EXISTS "+
+				"(SELECT 1 FROM user_role ur WHERE ur.id_user = u.id)",
+			defaultRoles,
+		).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"INSERT INTO user_role (id_user, id_role) "+
+				"SELECT u.id, r.id FROM `user` u JOIN role r ON r.name = ? "+
+				"WHERE u.is_admin = 1 AND u.created_at < (SELECT MIN(created_at) FROM role) AND NOT EXISTS "+
+				"(SELECT 1 FROM user_role ur WHERE ur.id_user = u.id AND ur.id_role = r.id)",
+			adminRole,
+		).Error
+	})
+}
+
+func (r *roleRepository) GetAll() ([]domain.Role, error) {
+	var roles []domain.Role
+	if err := r.db.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) GetByNames(names []string) ([]domain.Role, error) {
+	var roles []domain.Role
+	if len(names) == 0 {
+		return roles, nil
+	}
+	if err := r.db.Preload("Permissions").Where("name IN ?", names).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) SetUserRoles(userID uint, roles []domain.Role) error {
+	return r.db.Model(&domain.User{ID: userID}).Association("Roles").Replace(roles)
+}
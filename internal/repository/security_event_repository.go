@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// SecurityEventRepository defines DB operations for security_event.
+type SecurityEventRepository interface {
+	Create(event *domain.SecurityEvent) error
+}
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityEventRepository creates a new SecurityEventRepository.
+func NewSecurityEventRepository(db *gorm.DB) SecurityEventRepository {
+	return &securityEventRepository{db: db}
+}
+
+func (r *securityEventRepository) Create(event *domain.SecurityEvent) error {
+	return r.db.Create(event).Error
+}
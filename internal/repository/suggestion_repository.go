@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// SuggestionSource is a product, category or toko name offered as a
+// search suggestion, with its units sold as popularity.
+type SuggestionSource struct {
+	Type string
+	ID   uint
+	Text string
+	Sold int
+}
+
+// SuggestionRepository loads the names search suggestions are made from.
+type SuggestionRepository interface {
+	GetSources() ([]SuggestionSource, error)
+}
+
+type suggestionRepository struct {
+	db *gorm.DB
+}
+
+// NewSuggestionRepository creates a new SuggestionRepository.
+func NewSuggestionRepository(db *gorm.DB) SuggestionRepository {
+	return &suggestionRepository{db: db}
+}
+
+// GetSources returns every product, category and toko name. Sales are
+// counted from detail_trx through the log_produk snapshot of each line.
+func (r *suggestionRepository) GetSources() ([]SuggestionSource, error) {
+	productSales := r.db.Table("detail_trx").
+		Select("log_produk.id_produk, SUM(detail_trx.kuantitas) AS sold").
+		Joins("JOIN log_produk ON log_produk.id = detail_trx.id_log_produk").
+		Group("log_produk.id_produk")
+
+	var products []SuggestionSource
+	if err := r.db.Table("produk").
+		Select("produk.id, produk.nama_produk AS text, COALESCE(sales.sold, 0) AS sold").
+		Joins("LEFT JOIN (?) AS sales ON sales.id_produk = produk.id", productSales).
+		Where("produk.deleted_at IS NULL AND produk.archived_at IS NULL AND produk.status = ?", domain.ProductStatusPublished).
+		Scan(&products).Error; err != nil {
+		return nil, err
+	}
+
+	var categories []SuggestionSource
+	if err := r.db.Table("category").
+		Select("category.id, category.nama_category AS text, COALESCE(SUM(sales.sold), 0) AS sold").
+		Joins("LEFT JOIN produk ON produk.id_category = category.id AND produk.deleted_at IS NULL AND produk.archived_at IS NULL AND produk.status = ?", domain.ProductStatusPublished).
+		Joins("LEFT JOIN (?) AS sales ON sales.id_produk = produk.id", productSales).
+		Group("category.id, category.nama_category").
+		Scan(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	var tokos []SuggestionSource
+	if err := r.db.Table("toko").
+		Select("toko.id, toko.nama_toko AS text, COALESCE(SUM(detail_trx.kuantitas), 0) AS sold").
+		Joins("LEFT JOIN detail_trx ON detail_trx.id_toko = toko.id").
+		Group("toko.id, toko.nama_toko").
+		Scan(&tokos).Error; err != nil {
+		return nil, err
+	}
+
+	sources := make([]SuggestionSource, 0, len(products)+len(categories)+len(tokos))
+	for _, group := range []struct {
+		typ  string
+		rows []SuggestionSource
+	}{
+		{domain.SuggestTypeProduct, products},
+		{domain.SuggestTypeCategory, categories},
+		{domain.SuggestTypeToko, tokos},
+	} {
+		for _, row := range group.rows {
+			row.Type = group.typ
+			sources = append(sources, row)
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// TokoMemberRepository defines DB operations for toko_member.
+type TokoMemberRepository interface {
+	Create(member *domain.TokoMember) error
+	GetByTokoAndUser(tokoID, userID uint) (*domain.TokoMember, error)
+	GetAllByUser(userID uint) ([]domain.TokoMember, error)
+	GetAllByToko(tokoID uint) ([]domain.TokoMember, error)
+	Update(member *domain.TokoMember) error
+	Delete(tokoID, userID uint) error
+	BackfillOwners() error
+}
+
+// TokoInvitationRepository defines DB operations for toko_invitation.
+type TokoInvitationRepository interface {
+	Create(invitation *domain.TokoInvitation) error
+	GetByTokenHash(tokenHash string) (*domain.TokoInvitation, error)
+	Update(invitation *domain.TokoInvitation) error
+}
+
+type tokoMemberRepository struct {
+	db *gorm.DB
+}
+
+type tokoInvitationRepository struct {
+	db *gorm.DB
+}
+
+// NewTokoMemberRepository creates a new TokoMemberRepository.
+func NewTokoMemberRepository(db *gorm.DB) TokoMemberRepository {
+	return &tokoMemberRepository{db: db}
+}
+
+// NewTokoInvitationRepository creates a new TokoInvitationRepository.
+func NewTokoInvitationRepository(db *gorm.DB) TokoInvitationRepository {
+	return &tokoInvitationRepository{db: db}
+}
+
+func (r *tokoMemberRepository) Create(member *domain.TokoMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *tokoMemberRepository) GetByTokoAndUser(tokoID, userID uint) (*domain.TokoMember, error) {
+	var member domain.TokoMember
+	if err := r.db.Preload("Toko").
+		Where("id_toko = ? AND id_user = ?", tokoID, userID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetAllByUser returns the user's memberships, owned tokos first.
+func (r *tokoMemberRepository) GetAllByUser(userID uint) ([]domain.TokoMember, error) {
+	var list []domain.TokoMember
+	if err := r.db.Preload("Toko").
+		Where("id_user = ?", userID).
+		Order(gorm.Expr("role = ? DESC, id ASC", domain.TokoRoleOwner)).
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *tokoMemberRepository) GetAllByToko(tokoID uint) ([]domain.TokoMember, error) {
+	var list []domain.TokoMember
+	if err := r.db.Preload("User").
+		Where("id_toko = ?", tokoID).
+		Order("id ASC").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *tokoMemberRepository) Update(member *domain.TokoMember) error {
+	return r.db.Omit("Toko", "User").Save(member).Error
+}
+
+func (r *tokoMemberRepository) Delete(tokoID, userID uint) error {
+	result := r.db.Where("id_toko = ? AND id_user = ?", tokoID, userID).Delete(&domain.TokoMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// BackfillOwners makes every toko's id_user an owner member if not yet one.
+func (r *tokoMemberRepository) BackfillOwners() error {
+	return r.db.Exec(
+		"INSERT INTO toko_member (id_toko, id_user, role, created_at, updated_at) "+
+			"SELECT t.id, t.id_user, ?, NOW(), NOW() FROM toko t "+
+			"WHERE NOT EXISTS (SELECT 1 FROM toko_member m WHERE m.id_toko = t.id AND m.id_user = t.id_user)",
+		domain.TokoRoleOwner,
+	).Error
+}
+
+func (r *tokoInvitationRepository) Create(invitation *domain.TokoInvitation) error {
+	return r.db.Create(invitation).Error
+}
+
+func (r *tokoInvitationRepository) GetByTokenHash(tokenHash string) (*domain.TokoInvitation, error) {
+	var invitation domain.TokoInvitation
+	if err := r.db.Preload("Toko").Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *tokoInvitationRepository) Update(invitation *domain.TokoInvitation) error {
+	return r.db.Omit("Toko").Save(invitation).Error
+}
@@ -8,7 +8,7 @@ import (
 // TokoRepository defines methods to interact with the toko table.
 type TokoRepository interface {
 	Create(toko *domain.Toko) error
-	GetAll(limit, page int, nama string) ([]domain.Toko, error)
+	GetAll(limit, page int, nama string, keyset *Keyset) ([]domain.Toko, error)
 	GetByID(id uint) (*domain.Toko, error)
 	GetByUserID(userID uint) (*domain.Toko, error)
 	Update(toko *domain.Toko) error
@@ -27,13 +27,21 @@ func (r *tokoRepository) Create(toko *domain.Toko) error {
 	return r.db.Create(toko).Error
 }
 
-func (r *tokoRepository) GetAll(limit, page int, nama string) ([]domain.Toko, error) {
+// GetAll lists tokos by ID. With a keyset, page is ignored.
+func (r *tokoRepository) GetAll(limit, page int, nama string, keyset *Keyset) ([]domain.Toko, error) {
 	var list []domain.Toko
 	query := r.db.Model(&domain.Toko{})
 	if nama != "" {
 		query = query.Where("nama_toko LIKE ?", "%"+nama+"%")
 	}
+	query, err := orderBy(false, "id").apply(query, keyset)
+	if err != nil {
+		return nil, err
+	}
 	offset := (page - 1) * limit
+	if keyset != nil {
+		offset = 0
+	}
 	if err := query.Limit(limit).Offset(offset).Find(&list).Error; err != nil {
 		return nil, err
 	}
@@ -2,16 +2,34 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
 	"gorm.io/gorm"
 )
 
+// ErrInsufficientStock indicates an order for more units than a product or
+// variant has left.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// StockTake is the units one order line takes from Product and, when set,
+// from its Variant.
+type StockTake struct {
+	Product   *domain.Produk
+	Variant   *domain.ProdukVariant
+	Kuantitas int
+}
+
 // TrxRepository defines DB operations for transaksi and related details.
 type TrxRepository interface {
-	CreateWithDetails(trx *domain.Trx, logs []domain.LogProduk, details []domain.DetailTrx, products []*domain.Produk) error
-	GetAllByUser(userID uint) ([]domain.Trx, error)
+	CreateWithDetails(trx *domain.Trx, logs []domain.LogProduk, details []domain.DetailTrx, takes []StockTake, usages []domain.PromotionUsage, events OutboxFunc) error
+	GetAllByUser(userID uint, limit int, keyset *Keyset) ([]domain.Trx, error)
 	GetByIDForUser(userID, trxID uint) (*domain.Trx, error)
+	GetAllByToko(tokoID uint, limit int, keyset *Keyset) ([]domain.Trx, error)
+	GetByIDForToko(tokoID, trxID uint) (*domain.Trx, error)
+	MarkShipped(trxID, tokoID uint, noResi string, shippedAt time.Time, events OutboxFunc) (int64, error)
+	GetDetailForUser(userID, detailID uint) (*domain.DetailTrx, error)
+	HasPurchased(userID, produkID uint) (bool, error)
 }
 
 type trxRepository struct {
@@ -23,7 +41,12 @@ func NewTrxRepository(db *gorm.DB) TrxRepository {
 	return &trxRepository{db: db}
 }
 
-func (r *trxRepository) CreateWithDetails(trx *domain.Trx, logs []domain.LogProduk, details []domain.DetailTrx, products []*domain.Produk) error {
+// CreateWithDetails stores trx with its lines and takes the ordered units
+// from stock. Stock is decremented in place, guarded by the units left, so
+// concurrent orders for the last units cannot both succeed; the loser gets
+// ErrInsufficientStock. The Stok of every product and variant in takes is
+// set to its stored value afterwards, for events.
+func (r *trxRepository) CreateWithDetails(trx *domain.Trx, logs []domain.LogProduk, details []domain.DetailTrx, takes []StockTake, usages []domain.PromotionUsage, events OutboxFunc) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(trx).Error; err != nil {
 			return err
@@ -50,26 +73,83 @@ func (r *trxRepository) CreateWithDetails(trx *domain.Trx, logs []domain.LogProd
 			}
 		}
 
-		// Update product stocks
+		// Take the ordered units from stock; a product with variants holds
+		// the sum of its variants' stock
+		var products []*domain.Produk
+		seen := make(map[uint]bool)
+		for _, take := range takes {
+			if take.Variant != nil {
+				if err := takeStock(tx, &domain.ProdukVariant{}, take.Variant.ID, take.Kuantitas); err != nil {
+					return err
+				}
+				if err := tx.Model(&domain.ProdukVariant{}).Where("id = ?", take.Variant.ID).Select("stok").Scan(&take.Variant.Stok).Error; err != nil {
+					return err
+				}
+			} else if err := takeStock(tx, &domain.Produk{}, take.Product.ID, take.Kuantitas); err != nil {
+				return err
+			}
+			if !seen[take.Product.ID] {
+				seen[take.Product.ID] = true
+				products = append(products, take.Product)
+			}
+		}
 		for _, p := range products {
-			if err := tx.Model(&domain.Produk{}).
-				Where("id = ?", p.ID).
-				Update("stok", p.Stok).Error; err != nil {
+			if p.HasVariants() {
+				if err := syncVariantStock(tx, p); err != nil {
+					return err
+				}
+			} else if err := tx.Model(&domain.Produk{}).Where("id = ?", p.ID).Select("stok").Scan(&p.Stok).Error; err != nil {
+				return err
+			}
+			if err := recordProductVersion(tx, p.ID, domain.ProductChangeOrder); err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// Take promotion-priced units from their promotions
+		now := time.Now()
+		for i := range usages {
+			usages[i].TrxID = trx.ID
+			if err := claimPromotion(tx, &usages[i], now); err != nil {
+				return err
+			}
+		}
+
+		return writeOutbox(tx, events)
 	})
 }
 
-func (r *trxRepository) GetAllByUser(userID uint) ([]domain.Trx, error) {
+// takeStock takes qty units from the stock of the model row with id, or
+// returns ErrInsufficientStock when fewer are left.
+func takeStock(tx *gorm.DB, model interface{}, id uint, qty int) error {
+	res := tx.Model(model).
+		Where("id = ? AND stok >= ?", id, qty).
+		UpdateColumn("stok", gorm.Expr("stok - ?", qty))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// GetAllByUser returns the user's trx, newest first: up to limit past
+// keyset, or all of them when limit is 0.
+func (r *trxRepository) GetAllByUser(userID uint, limit int, keyset *Keyset) ([]domain.Trx, error) {
 	var trxs []domain.Trx
-	if err := r.db.
-		Where("id_user = ?", userID).
+	db, err := orderBy(true, "id").apply(r.db.Where("id_user = ?", userID), keyset)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.
 		Preload("Alamat").
 		Preload("DetailTrx.LogProduk.Category").
 		Preload("DetailTrx.LogProduk.Toko").
+		Preload("DetailTrx.LogProduk.Produk", withDeleted).
 		Preload("DetailTrx.LogProduk.Produk.FotoProduk").
 		Preload("DetailTrx.Toko").
 		Find(&trxs).Error; err != nil {
@@ -85,6 +165,55 @@ func (r *trxRepository) GetByIDForUser(userID, trxID uint) (*domain.Trx, error)
 		Preload("Alamat").
 		Preload("DetailTrx.LogProduk.Category").
 		Preload("DetailTrx.LogProduk.Toko").
+		Preload("DetailTrx.LogProduk.Produk", withDeleted).
+		Preload("DetailTrx.LogProduk.Produk.FotoProduk").
+		Preload("DetailTrx.Toko").
+		First(&trx).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &trx, nil
+}
+
+// GetAllByToko returns trx containing items sold by the toko, with
+// detail_trx limited to that toko's items, newest first: up to limit past
+// keyset, or all of them when limit is 0.
+func (r *trxRepository) GetAllByToko(tokoID uint, limit int, keyset *Keyset) ([]domain.Trx, error) {
+	var trxs []domain.Trx
+	db, err := orderBy(true, "id").apply(r.db.Where("id IN (?)", r.db.Model(&domain.DetailTrx{}).Select("id_trx").Where("id_toko = ?", tokoID)), keyset)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	if err := db.
+		Preload("Alamat").
+		Preload("DetailTrx", "id_toko = ?", tokoID).
+		Preload("DetailTrx.LogProduk.Category").
+		Preload("DetailTrx.LogProduk.Toko").
+		Preload("DetailTrx.LogProduk.Produk", withDeleted).
+		Preload("DetailTrx.LogProduk.Produk.FotoProduk").
+		Preload("DetailTrx.Toko").
+		Find(&trxs).Error; err != nil {
+		return nil, err
+	}
+	return trxs, nil
+}
+
+// GetByIDForToko returns a trx containing items sold by the toko, with
+// detail_trx limited to that toko's items.
+func (r *trxRepository) GetByIDForToko(tokoID, trxID uint) (*domain.Trx, error) {
+	var trx domain.Trx
+	if err := r.db.
+		Where("id = ? AND id IN (?)", trxID, r.db.Model(&domain.DetailTrx{}).Select("id_trx").Where("id_toko = ?", tokoID)).
+		Preload("Alamat").
+		Preload("DetailTrx", "id_toko = ?", tokoID).
+		Preload("DetailTrx.LogProduk.Category").
+		Preload("DetailTrx.LogProduk.Toko").
+		Preload("DetailTrx.LogProduk.Produk", withDeleted).
 		Preload("DetailTrx.LogProduk.Produk.FotoProduk").
 		Preload("DetailTrx.Toko").
 		First(&trx).Error; err != nil {
@@ -95,3 +224,58 @@ func (r *trxRepository) GetByIDForUser(userID, trxID uint) (*domain.Trx, error)
 	}
 	return &trx, nil
 }
+
+// GetDetailForUser returns a detail_trx line of one of the user's trx,
+// with the logged product it was bought as.
+func (r *trxRepository) GetDetailForUser(userID, detailID uint) (*domain.DetailTrx, error) {
+	var detail domain.DetailTrx
+	if err := r.db.
+		Joins("JOIN trx ON trx.id = detail_trx.id_trx AND trx.id_user = ?", userID).
+		Preload("LogProduk").
+		First(&detail, "detail_trx.id = ?", detailID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// HasPurchased reports whether the user has a trx containing the product.
+func (r *trxRepository) HasPurchased(userID, produkID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.DetailTrx{}).
+		Joins("JOIN trx ON trx.id = detail_trx.id_trx").
+		Joins("JOIN log_produk ON log_produk.id = detail_trx.id_log_produk").
+		Where("trx.id_user = ? AND log_produk.id_produk = ?", userID, produkID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MarkShipped sets the tracking number on the toko's unshipped lines of a
+// trx and returns how many lines changed.
+func (r *trxRepository) MarkShipped(trxID, tokoID uint, noResi string, shippedAt time.Time, events OutboxFunc) (int64, error) {
+	var updated int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.DetailTrx{}).
+			Where("id_trx = ? AND id_toko = ? AND shipped_at IS NULL", trxID, tokoID).
+			Updates(map[string]interface{}{"no_resi": noResi, "shipped_at": shippedAt})
+		if res.Error != nil {
+			return res.Error
+		}
+		updated = res.RowsAffected
+		if updated == 0 {
+			return nil
+		}
+		return writeOutbox(tx, events)
+	})
+	return updated, err
+}
+
+// withDeleted makes a preload include soft-deleted rows, so past orders
+// keep showing products deleted since.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
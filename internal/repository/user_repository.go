@@ -9,10 +9,12 @@ import (
 
 // UserRepository defines methods to interact with the users table.
 type UserRepository interface {
-	Create(user *domain.User) error
+	Create(user *domain.User, events OutboxFunc) error
 	FindByNoTelp(noTelp string) (*domain.User, error)
+	FindByEmail(email string) (*domain.User, error)
 	FindByID(id uint) (*domain.User, error)
 	Update(user *domain.User) error
+	MarkTOTPStep(userID uint, step int64) error
 	IsEmailOrNoTelpExists(email, noTelp string) (bool, error)
 }
 
@@ -25,13 +27,29 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *domain.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(user *domain.User, events OutboxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return writeOutbox(tx, events)
+	})
 }
 
 func (r *userRepository) FindByNoTelp(noTelp string) (*domain.User, error) {
 	var user domain.User
-	if err := r.db.Where("notelp = ?", noTelp).First(&user).Error; err != nil {
+	if err := r.db.Preload("Roles.Permissions").Where("notelp = ?", noTelp).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Preload("Roles.Permissions").Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -42,7 +60,7 @@ func (r *userRepository) FindByNoTelp(noTelp string) (*domain.User, error) {
 
 func (r *userRepository) FindByID(id uint) (*domain.User, error) {
 	var user domain.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.db.Preload("Roles.Permissions").First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -52,7 +70,24 @@ func (r *userRepository) FindByID(id uint) (*domain.User, error) {
 }
 
 func (r *userRepository) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+	// roles are managed through RoleRepository, never via the user row
+	return r.db.Omit("Roles").Save(user).Error
+}
+
+// MarkTOTPStep records step as the user's last accepted TOTP step; it fails
+// with gorm.ErrRecordNotFound if that step or a later one was already used,
+// e.g. by a concurrent request with the same code.
+func (r *userRepository) MarkTOTPStep(userID uint, step int64) error {
+	result := r.db.Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (r *userRepository) IsEmailOrNoTelpExists(email, noTelp string) (bool, error) {
@@ -63,4 +98,4 @@ func (r *userRepository) IsEmailOrNoTelpExists(email, noTelp string) (bool, erro
 		return false, err
 	}
 	return count > 0, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpointRepository defines DB operations for webhook_endpoint.
+type WebhookEndpointRepository interface {
+	Create(endpoint *domain.WebhookEndpoint) error
+	GetByID(id uint) (*domain.WebhookEndpoint, error)
+	GetAllByToko(tokoID uint) ([]domain.WebhookEndpoint, error)
+	GetActiveByToko(tokoID uint) ([]domain.WebhookEndpoint, error)
+	Update(endpoint *domain.WebhookEndpoint) error
+	Delete(id uint) error
+}
+
+// WebhookDeliveryRepository defines DB operations for webhook_delivery.
+type WebhookDeliveryRepository interface {
+	CreateMany(deliveries []domain.WebhookDelivery) error
+	GetByID(id uint) (*domain.WebhookDelivery, error)
+	GetAllByEndpoint(endpointID uint, limit, page int) ([]domain.WebhookDelivery, error)
+	GetDue(now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	Claim(delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error)
+	Update(delivery *domain.WebhookDelivery) error
+}
+
+type webhookEndpointRepository struct {
+	db *gorm.DB
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookEndpointRepository creates a new WebhookEndpointRepository.
+func NewWebhookEndpointRepository(db *gorm.DB) WebhookEndpointRepository {
+	return &webhookEndpointRepository{db: db}
+}
+
+// NewWebhookDeliveryRepository creates a new WebhookDeliveryRepository.
+func NewWebhookDeliveryRepository(db *gorm.DB) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+func (r *webhookEndpointRepository) Create(endpoint *domain.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+func (r *webhookEndpointRepository) GetByID(id uint) (*domain.WebhookEndpoint, error) {
+	var endpoint domain.WebhookEndpoint
+	if err := r.db.First(&endpoint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *webhookEndpointRepository) GetAllByToko(tokoID uint) ([]domain.WebhookEndpoint, error) {
+	var endpoints []domain.WebhookEndpoint
+	if err := r.db.Where("id_toko = ?", tokoID).Order("id ASC").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *webhookEndpointRepository) GetActiveByToko(tokoID uint) ([]domain.WebhookEndpoint, error) {
+	var endpoints []domain.WebhookEndpoint
+	if err := r.db.Where("id_toko = ? AND active = ?", tokoID, true).Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (r *webhookEndpointRepository) Update(endpoint *domain.WebhookEndpoint) error {
+	return r.db.Save(endpoint).Error
+}
+
+// Delete removes the endpoint together with its delivery log.
+func (r *webhookEndpointRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_endpoint = ?", id).Delete(&domain.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.WebhookEndpoint{}, id).Error
+	})
+}
+
+func (r *webhookDeliveryRepository) CreateMany(deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Omit("Endpoint").Create(&deliveries).Error
+}
+
+func (r *webhookDeliveryRepository) GetByID(id uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	if err := r.db.Preload("Endpoint").First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookDeliveryRepository) GetAllByEndpoint(endpointID uint, limit, page int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	offset := (page - 1) * limit
+
+	if err := r.db.Where("id_endpoint = ?", endpointID).
+		Order("id DESC").Limit(limit).Offset(offset).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetDue returns pending deliveries whose next attempt time has passed.
+func (r *webhookDeliveryRepository) GetDue(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	if err := r.db.Where("status = ? AND next_attempt_at <= ?", domain.WebhookStatusPending, now).
+		Preload("Endpoint").
+		Order("next_attempt_at ASC").Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// Claim leases a due delivery by moving its next attempt to leaseUntil.
+// Only one dispatcher (across replicas) wins the conditional update.
+func (r *webhookDeliveryRepository) Claim(delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	res := r.db.Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, domain.WebhookStatusPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	delivery.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+func (r *webhookDeliveryRepository) Update(delivery *domain.WebhookDelivery) error {
+	return r.db.Omit("Endpoint").Save(delivery).Error
+}
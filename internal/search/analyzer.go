@@ -0,0 +1,183 @@
+// Package search turns product text into index terms and back into
+// highlighted snippets: tokenizing, Indonesian stopwords and stemming,
+// and typo tolerance. Both product search implementations use it, so a
+// document and a query are always analyzed the same way.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTermLength is the longest term kept; longer tokens are dropped.
+const MaxTermLength = 64
+
+// stopwords are common Indonesian (and a few English) words that carry no
+// meaning in a product search.
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true,
+	"dengan": true, "ini": true, "itu": true, "atau": true, "pada": true, "dalam": true,
+	"adalah": true, "juga": true, "tidak": true, "ada": true, "akan": true, "sudah": true,
+	"bisa": true, "oleh": true, "sebagai": true, "karena": true, "saya": true, "kami": true,
+	"kita": true, "anda": true, "mereka": true, "dia": true, "ia": true, "para": true,
+	"lebih": true, "sangat": true, "hanya": true, "agar": true, "jika": true, "maka": true,
+	"saja": true, "pun": true, "bagi": true, "tersebut": true, "serta": true, "nya": true,
+	"the": true, "and": true, "of": true, "for": true, "with": true, "a": true, "an": true,
+}
+
+// Token is one word of a text: its index term and its byte span.
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits text into words of letters and digits, dropping
+// stopwords and stemming the rest.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.ToLower(text[start:end])
+		if !stopwords[word] {
+			if term := Stem(word); term != "" && len(term) <= MaxTermLength {
+				tokens = append(tokens, Token{Term: term, Start: start, End: end})
+			}
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// Terms returns the index terms of text in order, with repeats.
+func Terms(text string) []string {
+	tokens := Tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.Term
+	}
+	return terms
+}
+
+// QueryTerms returns the distinct index terms of a search query in order.
+func QueryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Terms(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// minStemLength is the shortest stem an affix may be removed down to.
+const minStemLength = 4
+
+// Stem reduces a lowercase Indonesian word to an approximate root by
+// removing particles, possessive pronouns, derivational suffixes and up
+// to two prefixes. Without a root dictionary the result is sometimes not
+// a real word; it only has to be the same for a word's inflections.
+func Stem(word string) string {
+	if utf8.RuneCountInString(word) <= minStemLength || !isAlpha(word) {
+		return word
+	}
+	for _, suffix := range []string{"lah", "kah", "tah", "pun"} {
+		if w, ok := trimSuffix(word, suffix); ok {
+			word = w
+			break
+		}
+	}
+	for _, suffix := range []string{"nya", "ku", "mu"} {
+		if w, ok := trimSuffix(word, suffix); ok {
+			word = w
+			break
+		}
+	}
+	for _, suffix := range []string{"kan", "an"} {
+		if w, ok := trimSuffix(word, suffix); ok {
+			word = w
+			break
+		}
+	}
+	for i := 0; i < 2; i++ {
+		w, ok := trimPrefix(word)
+		if !ok {
+			break
+		}
+		word = w
+	}
+	return word
+}
+
+func trimSuffix(word, suffix string) (string, bool) {
+	if strings.HasSuffix(word, suffix) && len(word)-len(suffix) >= minStemLength {
+		return word[:len(word)-len(suffix)], true
+	}
+	return word, false
+}
+
+// trimPrefix removes one prefix, restoring the root's first letter where
+// the prefix absorbed it (menulis -> tulis, memakai -> pakai).
+func trimPrefix(word string) (string, bool) {
+	stem := ""
+	switch {
+	case strings.HasPrefix(word, "meny"), strings.HasPrefix(word, "peny"):
+		stem = "s" + word[4:]
+	case strings.HasPrefix(word, "meng"), strings.HasPrefix(word, "peng"):
+		stem = word[4:]
+	case strings.HasPrefix(word, "mem"), strings.HasPrefix(word, "pem"):
+		if rest := word[3:]; rest != "" && isVowel(rest[0]) {
+			stem = "p" + rest
+		} else {
+			stem = rest
+		}
+	case strings.HasPrefix(word, "men"), strings.HasPrefix(word, "pen"):
+		if rest := word[3:]; rest != "" && isVowel(rest[0]) {
+			stem = "t" + rest
+		} else {
+			stem = rest
+		}
+	case strings.HasPrefix(word, "ber"), strings.HasPrefix(word, "ter"), strings.HasPrefix(word, "per"):
+		stem = word[3:]
+	case strings.HasPrefix(word, "me"), strings.HasPrefix(word, "pe"), strings.HasPrefix(word, "be"):
+		// me-/pe-/be- only precede l, r, w, y, m, n (be- also bekerja)
+		if rest := word[2:]; rest != "" && (strings.IndexByte("lrwymn", rest[0]) >= 0 || strings.HasPrefix(rest, "ker")) {
+			stem = rest
+		}
+	case strings.HasPrefix(word, "di"), strings.HasPrefix(word, "ke"), strings.HasPrefix(word, "se"):
+		stem = word[2:]
+	}
+	if len(stem) < minStemLength {
+		return word, false
+	}
+	return stem, true
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aiueo", c) >= 0
+}
+
+func isAlpha(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
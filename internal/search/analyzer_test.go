@@ -0,0 +1,98 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Token
+	}{
+		{
+			name: "empty",
+			text: "",
+			want: nil,
+		},
+		{
+			name: "punctuation and digits split words",
+			text: "Sepatu Lari, pria & wanita 42",
+			want: []Token{
+				{Term: "patu", Start: 0, End: 6},
+				{Term: "lari", Start: 7, End: 11},
+				{Term: "pria", Start: 13, End: 17},
+				{Term: "wanita", Start: 20, End: 26},
+				{Term: "42", Start: 27, End: 29},
+			},
+		},
+		{
+			name: "stopwords dropped, case folded, words stemmed",
+			text: "Kaos yang NYAMAN dipakai",
+			want: []Token{
+				{Term: "kaos", Start: 0, End: 4},
+				{Term: "nyam", Start: 10, End: 16},
+				{Term: "pakai", Start: 17, End: 24},
+			},
+		},
+		{
+			name: "non-ASCII letters kept with byte offsets",
+			text: "Café ünï",
+			want: []Token{
+				{Term: "café", Start: 0, End: 5},
+				{Term: "ünï", Start: 6, End: 11},
+			},
+		},
+		{
+			name: "only stopwords",
+			text: "yang dan di",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"kopi", "kopi"},
+		{"tas", "tas"},
+		{"bukunya", "buku"},
+		{"makanan", "makan"},
+		{"minuman", "minum"},
+		{"pakailah", "pakai"},
+		{"menulis", "tulis"},
+		{"memakai", "pakai"},
+		{"membeli", "beli"},
+		{"menyapu", "sapu"},
+		{"mengambil", "ambil"},
+		{"penjual", "jual"},
+		{"bermain", "main"},
+		{"bekerja", "kerja"},
+		{"dibelikan", "beli"},
+		{"keberuntungan", "untung"},
+		// inflections of one word share a stem even when it is not a word
+		{"sepatu", "patu"},
+		{"sepatunya", "patu"},
+		// non-letters are left alone
+		{"ukuran42", "ukuran42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := Stem(tt.word); got != tt.want {
+				t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package search
+
+// MaxEdits is how many typos a query term may contain and still match:
+// none for short terms, one from four characters and two from eight.
+func MaxEdits(term string) int {
+	n := len([]rune(term))
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Distance is the number of insertions, deletions, substitutions and
+// adjacent transpositions that turn a into b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// prev2, prev and cur are rows i-2, i-1 and i of the edit matrix.
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// Matches reports whether candidate is term or within its typo allowance.
+func Matches(term, candidate string) bool {
+	if term == candidate {
+		return true
+	}
+	maxEdits := MaxEdits(term)
+	if maxEdits == 0 {
+		return false
+	}
+	diff := len([]rune(term)) - len([]rune(candidate))
+	if diff > maxEdits || -diff > maxEdits {
+		return false
+	}
+	return Distance(term, candidate) <= maxEdits
+}
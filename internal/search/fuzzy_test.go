@@ -0,0 +1,55 @@
+package search
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"sepatu", "sepatu", 0},
+		{"kitten", "sitting", 3},
+		{"ab", "ba", 1},
+		{"kopi", "kpoi", 1},
+		{"sepatu", "spetau", 2},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := Distance(tt.b, tt.a); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		term, candidate string
+		want            bool
+	}{
+		{"tas", "tas", true},
+		// short terms allow no typo
+		{"tas", "tab", false},
+		{"kopi", "kpoi", true},
+		{"kopi", "kapu", false},
+		{"handphone", "hanphone", true},
+		{"handphone", "hnadphon", true},
+		{"handphone", "phone", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term+"/"+tt.candidate, func(t *testing.T) {
+			if got := Matches(tt.term, tt.candidate); got != tt.want {
+				t.Errorf("Matches(%q, %q) = %v, want %v", tt.term, tt.candidate, got, tt.want)
+			}
+		})
+	}
+}
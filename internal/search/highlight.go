@@ -0,0 +1,96 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// Highlight markers wrapped around matching words. The rest of the text is
+// HTML-escaped, so the result is safe to render as HTML.
+const (
+	HighlightPre  = "<em>"
+	HighlightPost = "</em>"
+)
+
+// snippetContext is how many bytes of text are kept before the first match
+// when a snippet is cut from a longer text; at most a third of the snippet.
+const snippetContext = 60
+
+// Highlight marks the words of text that match one of the query terms,
+// typos included. When maxLen > 0 and text is longer, only a snippet of
+// about maxLen bytes starting shortly before the first match is returned.
+// ok is false when nothing in text matched.
+func Highlight(text string, queryTerms []string, maxLen int) (string, bool) {
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		for _, term := range queryTerms {
+			if Matches(term, token.Term) {
+				matches = append(matches, token)
+				break
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(text)
+	if maxLen > 0 && len(text) > maxLen {
+		start = wordStart(text, matches[0].Start-min(snippetContext, maxLen/3))
+		end = wordEnd(text, start, start+maxLen)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, m := range matches {
+		if m.Start < pos {
+			continue
+		}
+		if m.End > end {
+			break
+		}
+		b.WriteString(html.EscapeString(text[pos:m.Start]))
+		b.WriteString(HighlightPre)
+		b.WriteString(html.EscapeString(text[m.Start:m.End]))
+		b.WriteString(HighlightPost)
+		pos = m.End
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
+
+// wordStart moves i forward to the start of the next word, or to 0.
+func wordStart(text string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if j := strings.IndexByte(text[i:], ' '); j >= 0 && i+j+1 < len(text) {
+		return i + j + 1
+	}
+	for i < len(text) && !utf8.RuneStart(text[i]) {
+		i++
+	}
+	return i
+}
+
+// wordEnd moves i back to the end of the previous word after start, or to
+// len(text).
+func wordEnd(text string, start, i int) int {
+	if i >= len(text) {
+		return len(text)
+	}
+	if j := strings.LastIndexByte(text[start:i], ' '); j > 0 {
+		return start + j
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,79 @@
+package search
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	long := "Tas kulit asli buatan tangan pengrajin Garut yang tahan lama dan cocok dipakai " +
+		"sehari-hari ke kantor maupun jalan-jalan bersama keluarga tercinta di akhir pekan"
+
+	tests := []struct {
+		name   string
+		text   string
+		query  string
+		maxLen int
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "no match",
+			text:   "Kaos katun",
+			query:  "sepatu",
+			wantOK: false,
+		},
+		{
+			name:   "matches marked and the rest escaped",
+			text:   "Sepatu lari <ringan> untuk pria",
+			query:  "sepatu ringan",
+			want:   "<em>Sepatu</em> lari &lt;<em>ringan</em>&gt; untuk pria",
+			wantOK: true,
+		},
+		{
+			name:   "inflected form",
+			text:   "Sepatunya nyaman",
+			query:  "sepatu",
+			want:   "<em>Sepatunya</em> nyaman",
+			wantOK: true,
+		},
+		{
+			name:   "typo",
+			text:   "Kopi gayo",
+			query:  "kpoi",
+			want:   "<em>Kopi</em> gayo",
+			wantOK: true,
+		},
+		{
+			name:   "text shorter than maxLen is whole",
+			text:   long,
+			query:  "garut",
+			maxLen: 500,
+			want: "Tas kulit asli buatan tangan pengrajin <em>Garut</em> yang tahan lama dan cocok dipakai " +
+				"sehari-hari ke kantor maupun jalan-jalan bersama keluarga tercinta di akhir pekan",
+			wantOK: true,
+		},
+		{
+			name:   "snippet from the start",
+			text:   long,
+			query:  "tas",
+			maxLen: 40,
+			want:   "<em>Tas</em> kulit asli buatan tangan pengrajin…",
+			wantOK: true,
+		},
+		{
+			name:   "snippet around a late match",
+			text:   long,
+			query:  "keluarga",
+			maxLen: 40,
+			want:   "…bersama <em>keluarga</em> tercinta di akhir pekan",
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Highlight(tt.text, QueryTerms(tt.query), tt.maxLen)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Highlight(%q, %q, %d) = %q, %v, want %q, %v", tt.text, tt.query, tt.maxLen, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
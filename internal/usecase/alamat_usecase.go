@@ -24,29 +24,48 @@ type UpdateAlamatInput struct {
 	DetailAlamat string `json:"detail_alamat"`
 }
 
+// AlamatListResult wraps a cursor-paginated alamat list. Limit is 0 when
+// the whole list was returned.
+type AlamatListResult struct {
+	Limit int             `json:"limit"`
+	Data  []domain.Alamat `json:"data"`
+	PageCursors
+}
+
 // AlamatUsecase handles alamat-related business logic.
 type AlamatUsecase interface {
-	GetMyAlamat(userID uint, filterJudul string) ([]domain.Alamat, error)
+	GetMyAlamat(userID uint, filterJudul string, limit int, cursor string) (*AlamatListResult, error)
 	GetByID(userID uint, id uint) (*domain.Alamat, error)
-	Create(userID uint, in CreateAlamatInput) (*domain.Alamat, error)
-	Update(userID uint, id uint, in UpdateAlamatInput) (*domain.Alamat, error)
-	Delete(userID uint, id uint) error
+	Create(actor Actor, in CreateAlamatInput) (*domain.Alamat, error)
+	Update(actor Actor, id uint, in UpdateAlamatInput) (*domain.Alamat, error)
+	Delete(actor Actor, id uint) error
 }
 
 type alamatUsecase struct {
 	alamatRepo repository.AlamatRepository
+	audit      *auditor
 }
 
 // NewAlamatUsecase creates a new AlamatUsecase.
-func NewAlamatUsecase(alamatRepo repository.AlamatRepository) AlamatUsecase {
-	return &alamatUsecase{alamatRepo: alamatRepo}
+func NewAlamatUsecase(alamatRepo repository.AlamatRepository, auditRepo repository.AuditLogRepository) AlamatUsecase {
+	return &alamatUsecase{alamatRepo: alamatRepo, audit: newAuditor(auditRepo)}
 }
 
 // ErrAlamatNotFound indicates alamat not found.
 var ErrAlamatNotFound = errors.New("alamat not found")
 
-func (uc *alamatUsecase) GetMyAlamat(userID uint, filterJudul string) ([]domain.Alamat, error) {
-	return uc.alamatRepo.GetAllByUser(userID, filterJudul)
+// GetMyAlamat lists the user's alamat; all of them unless a limit or cursor
+// is given.
+func (uc *alamatUsecase) GetMyAlamat(userID uint, filterJudul string, limit int, cursor string) (*AlamatListResult, error) {
+	list, limit, cursors, err := fetchKeysetPage("alamat", limit, cursor, func(n int, keyset *repository.Keyset) ([]domain.Alamat, error) {
+		return uc.alamatRepo.GetAllByUser(userID, filterJudul, n, keyset)
+	}, func(a *domain.Alamat) []string {
+		return idKey(a.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AlamatListResult{Limit: limit, Data: list, PageCursors: cursors}, nil
 }
 
 func (uc *alamatUsecase) GetByID(userID uint, id uint) (*domain.Alamat, error) {
@@ -60,13 +79,13 @@ func (uc *alamatUsecase) GetByID(userID uint, id uint) (*domain.Alamat, error) {
 	return alamat, nil
 }
 
-func (uc *alamatUsecase) Create(userID uint, in CreateAlamatInput) (*domain.Alamat, error) {
+func (uc *alamatUsecase) Create(actor Actor, in CreateAlamatInput) (*domain.Alamat, error) {
 	if in.JudulAlamat == "" || in.NamaPenerima == "" || in.NoTelp == "" || in.DetailAlamat == "" {
 		return nil, errors.New("judul_alamat, nama_penerima, no_telp, detail_alamat wajib diisi")
 	}
 
 	alamat := &domain.Alamat{
-		UserID:       userID,
+		UserID:       actor.UserID,
 		JudulAlamat:  in.JudulAlamat,
 		NamaPenerima: in.NamaPenerima,
 		NoTelp:       in.NoTelp,
@@ -76,18 +95,20 @@ func (uc *alamatUsecase) Create(userID uint, in CreateAlamatInput) (*domain.Alam
 	if err := uc.alamatRepo.Create(alamat); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityAlamat, alamat.ID, nil, auditSnapshot(alamat))
 
 	return alamat, nil
 }
 
-func (uc *alamatUsecase) Update(userID uint, id uint, in UpdateAlamatInput) (*domain.Alamat, error) {
-	alamat, err := uc.alamatRepo.GetByIDForUser(userID, id)
+func (uc *alamatUsecase) Update(actor Actor, id uint, in UpdateAlamatInput) (*domain.Alamat, error) {
+	alamat, err := uc.alamatRepo.GetByIDForUser(actor.UserID, id)
 	if err != nil {
 		return nil, err
 	}
 	if alamat == nil {
 		return nil, ErrAlamatNotFound
 	}
+	before := auditSnapshot(alamat)
 
 	if in.JudulAlamat != "" {
 		alamat.JudulAlamat = in.JudulAlamat
@@ -105,16 +126,26 @@ func (uc *alamatUsecase) Update(userID uint, id uint, in UpdateAlamatInput) (*do
 	if err := uc.alamatRepo.Update(alamat); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityAlamat, alamat.ID, before, auditSnapshot(alamat))
 
 	return alamat, nil
 }
 
-func (uc *alamatUsecase) Delete(userID uint, id uint) error {
-	if err := uc.alamatRepo.DeleteByIDForUser(userID, id); err != nil {
+func (uc *alamatUsecase) Delete(actor Actor, id uint) error {
+	alamat, err := uc.alamatRepo.GetByIDForUser(actor.UserID, id)
+	if err != nil {
+		return err
+	}
+	if alamat == nil {
+		return ErrAlamatNotFound
+	}
+
+	if err := uc.alamatRepo.DeleteByIDForUser(actor.UserID, id); err != nil {
 		if errors.Is(err, ErrAlamatNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
 			return ErrAlamatNotFound
 		}
 		return err
 	}
+	uc.audit.record(actor, domain.AuditActionDelete, AuditEntityAlamat, alamat.ID, auditSnapshot(alamat), nil)
 	return nil
 }
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// APIKeyPrefix starts every issued key so it is recognisable in configs and logs.
+const APIKeyPrefix = "evk_"
+
+// CreateAPIKeyInput represents payload to create a personal API key.
+// TokoID optionally binds the key to one toko; ExpiresInDays 0 never expires.
+type CreateAPIKeyInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	TokoID        uint     `json:"toko_id"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreatedAPIKey carries a new key; Token is shown to the user only once.
+type CreatedAPIKey struct {
+	Key   *domain.APIKey
+	Token string
+}
+
+// APIKeyUsecase manages personal API keys for integrations.
+type APIKeyUsecase interface {
+	GetAll(userID uint) ([]domain.APIKey, error)
+	Create(actor Actor, in CreateAPIKeyInput) (*CreatedAPIKey, error)
+	Revoke(actor Actor, id uint) error
+}
+
+type apiKeyUsecase struct {
+	keyRepo repository.APIKeyRepository
+	access  *tokoAccess
+	audit   *auditor
+}
+
+// NewAPIKeyUsecase creates a new APIKeyUsecase.
+func NewAPIKeyUsecase(keyRepo repository.APIKeyRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository) APIKeyUsecase {
+	return &apiKeyUsecase{keyRepo: keyRepo, access: newTokoAccess(memberRepo), audit: newAuditor(auditRepo)}
+}
+
+var (
+	// ErrAPIKeyNotFound indicates the key does not exist or belongs to another user.
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIScope indicates an empty or unknown scope list.
+	ErrInvalidAPIScope = errors.New("scopes harus berisi products:read, products:write, orders:read atau stock:write")
+)
+
+func (uc *apiKeyUsecase) GetAll(userID uint) ([]domain.APIKey, error) {
+	return uc.keyRepo.GetAllByUser(userID)
+}
+
+func (uc *apiKeyUsecase) Create(actor Actor, in CreateAPIKeyInput) (*CreatedAPIKey, error) {
+	if in.Name == "" {
+		return nil, errors.New("name wajib diisi")
+	}
+	if len(in.Scopes) == 0 {
+		return nil, ErrInvalidAPIScope
+	}
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, s := range in.Scopes {
+		if !domain.ValidAPIScope(s) {
+			return nil, ErrInvalidAPIScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+
+	key := &domain.APIKey{
+		UserID: actor.UserID,
+		Name:   in.Name,
+		Scopes: strings.Join(scopes, ","),
+	}
+	if in.TokoID != 0 {
+		member, err := uc.access.resolve(actor.UserID, in.TokoID, "")
+		if err != nil {
+			return nil, err
+		}
+		key.TokoID = &member.TokoID
+	}
+	if in.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, in.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	secret, err := helper.GenerateToken(24)
+	if err != nil {
+		return nil, err
+	}
+	token := APIKeyPrefix + secret
+	key.Prefix = token[:len(APIKeyPrefix)+8]
+	key.KeyHash = helper.HashToken(token)
+
+	if err := uc.keyRepo.Create(key); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityAPIKey, key.ID, nil, auditSnapshot(key))
+
+	return &CreatedAPIKey{Key: key, Token: token}, nil
+}
+
+func (uc *apiKeyUsecase) Revoke(actor Actor, id uint) error {
+	key, err := uc.keyRepo.GetByIDForUser(actor.UserID, id)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	before := auditSnapshot(key)
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := uc.keyRepo.Update(key); err != nil {
+		return err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityAPIKey, key.ID, before, auditSnapshot(key))
+	return nil
+}
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// Actor identifies who performs a mutating call, for the audit log.
+// Calls made with an API key carry its ID, and TokoID when the key is
+// bound to a single toko.
+type Actor struct {
+	UserID    uint
+	Roles     []string
+	IP        string
+	RequestID string
+	APIKeyID  uint
+	TokoID    uint
+}
+
+// Audited entity types.
+const (
+	AuditEntityProduct   = "product"
+	AuditEntityToko      = "toko"
+	AuditEntityCategory  = "category"
+	AuditEntityAlamat    = "alamat"
+	AuditEntityUser      = "user"
+	AuditEntityTrx       = "trx"
+	AuditEntityAPIKey    = "api_key"
+	AuditEntityReview    = "review"
+	AuditEntityQuestion  = "product_question"
+	AuditEntityAnswer    = "product_answer"
+	AuditEntityPromotion = "promotion"
+)
+
+// redactedFields never have their values written to the audit log.
+var redactedFields = map[string]bool{
+	"KataSandi":  true,
+	"TOTPSecret": true,
+	"KeyHash":    true,
+}
+
+// auditSnapshot flattens an entity into its column fields. Relations
+// (nested structs and lists) are dropped; they are audited on their own.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	timeType := reflect.TypeOf(time.Time{})
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if (ft.Kind() == reflect.Struct && ft != timeType) || ft.Kind() == reflect.Slice {
+			delete(fields, t.Field(i).Name)
+		}
+	}
+	return fields
+}
+
+// auditDiff returns the fields whose value differs between before and after.
+func auditDiff(before, after map[string]interface{}) map[string]interface{} {
+	diff := make(map[string]interface{})
+	for k, to := range after {
+		if k == "UpdatedAt" {
+			continue
+		}
+		from, ok := before[k]
+		if !ok || !reflect.DeepEqual(from, to) {
+			diff[k] = map[string]interface{}{"from": from, "to": to}
+		}
+	}
+	for k, from := range before {
+		if _, ok := after[k]; !ok {
+			diff[k] = map[string]interface{}{"from": from, "to": nil}
+		}
+	}
+	return diff
+}
+
+// redact masks sensitive values in a snapshot or diff.
+func redact(fields map[string]interface{}) {
+	for k := range fields {
+		if !redactedFields[k] {
+			continue
+		}
+		if _, isChange := fields[k].(map[string]interface{}); isChange {
+			fields[k] = map[string]interface{}{"from": "***", "to": "***"}
+		} else {
+			fields[k] = "***"
+		}
+	}
+}
+
+func auditJSON(fields map[string]interface{}) string {
+	if fields == nil {
+		return ""
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// auditor writes audit log entries for usecase mutations.
+type auditor struct {
+	repo repository.AuditLogRepository
+}
+
+func newAuditor(repo repository.AuditLogRepository) *auditor {
+	return &auditor{repo: repo}
+}
+
+// record stores one audit entry. before is nil for creates and after is nil
+// for deletes. A failed write is logged rather than returned because the
+// audited change has already been committed.
+func (a *auditor) record(actor Actor, action, entityType string, entityID uint, before, after map[string]interface{}) {
+	diff := auditDiff(before, after)
+	if action == domain.AuditActionUpdate && len(diff) == 0 {
+		return
+	}
+	redact(before)
+	redact(after)
+	redact(diff)
+
+	entry := &domain.AuditLog{
+		UserID:     actor.UserID,
+		ActorRoles: strings.Join(actor.Roles, ","),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		BeforeData: auditJSON(before),
+		AfterData:  auditJSON(after),
+		Diff:       auditJSON(diff),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+	}
+	if actor.APIKeyID != 0 {
+		entry.APIKeyID = &actor.APIKeyID
+	}
+	if err := a.repo.Create(entry); err != nil {
+		log.Printf("audit: failed to record %s %s #%d: %v", action, entityType, entityID, err)
+	}
+}
+
+// AuditListResult wraps paginated audit log entries.
+type AuditListResult struct {
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+	Data  []domain.AuditLog `json:"data"`
+}
+
+// AuditUsecase handles querying and pruning the audit log.
+type AuditUsecase interface {
+	GetAll(limit, page int, filter repository.AuditLogFilter) (*AuditListResult, error)
+	PruneExpired() (int64, error)
+}
+
+type auditUsecase struct {
+	repo          repository.AuditLogRepository
+	retentionDays int
+}
+
+// NewAuditUsecase creates a new AuditUsecase. Entries older than
+// retentionDays are pruned; 0 keeps them forever.
+func NewAuditUsecase(repo repository.AuditLogRepository, retentionDays int) AuditUsecase {
+	return &auditUsecase{repo: repo, retentionDays: retentionDays}
+}
+
+func (uc *auditUsecase) GetAll(limit, page int, filter repository.AuditLogFilter) (*AuditListResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	entries, err := uc.repo.GetAll(limit, page, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditListResult{
+		Page:  page,
+		Limit: limit,
+		Data:  entries,
+	}, nil
+}
+
+// PruneExpired deletes entries past the retention period.
+func (uc *auditUsecase) PruneExpired() (int64, error) {
+	if uc.retentionDays <= 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -uc.retentionDays)
+	return uc.repo.DeleteBefore(cutoff)
+}
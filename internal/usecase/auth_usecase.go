@@ -2,11 +2,16 @@ package usecase
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/notifier"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+	"gorm.io/gorm"
 )
 
 // RegisterInput represents expected payload for /auth/register.
@@ -25,28 +30,111 @@ type RegisterInput struct {
 type LoginInput struct {
 	NoTelp    string `json:"no_telp"`
 	KataSandi string `json:"kata_sandi"`
+	// IP is the client address, set by the handler.
+	IP string `json:"-"`
+}
+
+// ForgotPasswordInput represents expected payload for /auth/forgot-password.
+// The account is identified by no_telp or email.
+type ForgotPasswordInput struct {
+	NoTelp  string `json:"no_telp"`
+	Email   string `json:"email"`
+	Channel string `json:"channel"`
+	// IP is the client address, set by the handler.
+	IP string `json:"-"`
+}
+
+// ResetPasswordInput represents expected payload for /auth/reset-password.
+type ResetPasswordInput struct {
+	NoTelp        string `json:"no_telp"`
+	Email         string `json:"email"`
+	OTP           string `json:"otp"`
+	KataSandiBaru string `json:"kata_sandi_baru"`
+}
+
+// VerifyContactInput represents expected payload for /auth/verify.
+// Channel is "email" or "sms"; the account is identified by no_telp or email.
+type VerifyContactInput struct {
+	Channel string `json:"channel" query:"channel"`
+	NoTelp  string `json:"no_telp" query:"no_telp"`
+	Email   string `json:"email" query:"email"`
+	OTP     string `json:"otp" query:"otp"`
+}
+
+// ResendVerificationInput represents expected payload for /auth/verify/resend.
+type ResendVerificationInput struct {
+	Channel string `json:"channel"`
+	NoTelp  string `json:"no_telp"`
+	Email   string `json:"email"`
+	// IP is the client address, set by the handler.
+	IP string `json:"-"`
+}
+
+// TwoFactorLoginInput represents expected payload for /auth/login/2fa.
+// Either Code (TOTP) or RecoveryCode must be provided.
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	// IP is the client address, set by the handler.
+	IP string `json:"-"`
 }
 
 // LoginResult is returned after successful login.
 type LoginResult struct {
 	Token string       `json:"token"`
 	User  *domain.User `json:"user"`
+	// TwoFactorRequired means Token is empty and ChallengeToken must be
+	// exchanged at /auth/login/2fa together with a TOTP or recovery code.
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	// EnrollmentRequired means admin endpoints stay blocked until the
+	// account enrolls in 2FA and logs in again.
+	EnrollmentRequired bool `json:"two_factor_enrollment_required"`
 }
 
 // AuthUsecase exposes authentication use cases.
 type AuthUsecase interface {
-	Register(in RegisterInput) error
+	Register(actor Actor, in RegisterInput) error
 	Login(in LoginInput) (*LoginResult, error)
+	LoginTwoFactor(in TwoFactorLoginInput) (*LoginResult, error)
+	ForgotPassword(in ForgotPasswordInput) error
+	ResetPassword(actor Actor, in ResetPasswordInput) error
+	VerifyContact(actor Actor, in VerifyContactInput) error
+	ResendVerification(in ResendVerificationInput) error
 }
 
 type authUsecase struct {
-	userRepo repository.UserRepository
-	tokoRepo repository.TokoRepository
+	userRepo         repository.UserRepository
+	tokoRepo         repository.TokoRepository
+	memberRepo       repository.TokoMemberRepository
+	roleRepo         repository.RoleRepository
+	recoveryRepo     repository.RecoveryCodeRepository
+	apiKeyRepo       repository.APIKeyRepository
+	otp              *otpService
+	guard            *loginGuard
+	audit            *auditor
+	baseURL          string
+	requireAdminTOTP bool
 }
 
 // NewAuthUsecase constructs a new AuthUsecase implementation.
-func NewAuthUsecase(userRepo repository.UserRepository, tokoRepo repository.TokoRepository) AuthUsecase {
-	return &authUsecase{userRepo: userRepo, tokoRepo: tokoRepo}
+// baseURL is used to build verification links sent by email;
+// requireAdminTOTP flags staff without 2FA as needing enrollment.
+func NewAuthUsecase(userRepo repository.UserRepository, tokoRepo repository.TokoRepository, memberRepo repository.TokoMemberRepository, roleRepo repository.RoleRepository, otpRepo repository.OTPRepository, n notifier.Notifier, attemptRepo repository.LoginAttemptRepository, eventRepo repository.SecurityEventRepository, recoveryRepo repository.RecoveryCodeRepository, apiKeyRepo repository.APIKeyRepository, auditRepo repository.AuditLogRepository, baseURL string, requireAdminTOTP bool) AuthUsecase {
+	return &authUsecase{
+		userRepo:         userRepo,
+		tokoRepo:         tokoRepo,
+		memberRepo:       memberRepo,
+		roleRepo:         roleRepo,
+		recoveryRepo:     recoveryRepo,
+		apiKeyRepo:       apiKeyRepo,
+		otp:              newOTPService(otpRepo, n),
+		guard:            newLoginGuard(attemptRepo, eventRepo),
+		audit:            newAuditor(auditRepo),
+		baseURL:          baseURL,
+		requireAdminTOTP: requireAdminTOTP,
+	}
 }
 
 var (
@@ -54,9 +142,17 @@ var (
 	ErrEmailOrPhoneExists = errors.New("email or phone already exists")
 	// ErrInvalidCredentials indicates login credential mismatch.
 	ErrInvalidCredentials = errors.New("no telp atau kata sandi salah")
+	// ErrInvalidChallenge indicates a missing, expired or misused 2FA challenge token.
+	ErrInvalidChallenge = errors.New("challenge token tidak valid atau kadaluarsa")
+	// ErrInvalidTOTP indicates a wrong TOTP or recovery code.
+	ErrInvalidTOTP = errors.New("kode 2fa salah")
+	// ErrAlreadyVerified indicates the contact channel is already verified.
+	ErrAlreadyVerified = errors.New("sudah terverifikasi")
 )
 
-func (uc *authUsecase) Register(in RegisterInput) error {
+// Register creates the user with a toko of their own. actor carries the
+// request metadata; its UserID is filled in once the user exists.
+func (uc *authUsecase) Register(actor Actor, in RegisterInput) error {
 	if in.Nama == "" || in.KataSandi == "" || in.NoTelp == "" || in.Email == "" {
 		return errors.New("nama, kata_sandi, no_telp, dan email wajib diisi")
 	}
@@ -94,9 +190,21 @@ func (uc *authUsecase) Register(in RegisterInput) error {
 		IDKota:       in.IDKota,
 	}
 
-	if err := uc.userRepo.Create(user); err != nil {
+	events := func() ([]domain.OutboxEvent, error) {
+		var b event.Batch
+		b.Add(domain.EventUserRegistered, domain.AggregateUser, user.ID, event.UserRegistered{
+			UserID: user.ID,
+			Nama:   user.Nama,
+			Email:  user.Email,
+			NoTelp: user.NoTelp,
+		})
+		return b.Events()
+	}
+	if err := uc.userRepo.Create(user, events); err != nil {
 		return err
 	}
+	actor.UserID = user.ID
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityUser, user.ID, nil, auditSnapshot(user))
 
 	// Automatically create store for new user
 	toko := &domain.Toko{
@@ -107,6 +215,28 @@ func (uc *authUsecase) Register(in RegisterInput) error {
 	if err := uc.tokoRepo.Create(toko); err != nil {
 		return err
 	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityToko, toko.ID, nil, auditSnapshot(toko))
+
+	owner := &domain.TokoMember{
+		TokoID: toko.ID,
+		UserID: user.ID,
+		Role:   domain.TokoRoleOwner,
+	}
+	if err := uc.memberRepo.Create(owner); err != nil {
+		return err
+	}
+
+	roles, err := uc.roleRepo.GetByNames(domain.DefaultUserRoles)
+	if err != nil {
+		return err
+	}
+	if err := uc.roleRepo.SetUserRoles(user.ID, roles); err != nil {
+		return err
+	}
+
+	// Delivery failures are not fatal: the user can request a resend.
+	_ = uc.sendVerification(user, notifier.ChannelEmail, actor.IP)
+	_ = uc.sendVerification(user, notifier.ChannelSMS, actor.IP)
 
 	return nil
 }
@@ -116,19 +246,91 @@ func (uc *authUsecase) Login(in LoginInput) (*LoginResult, error) {
 		return nil, ErrInvalidCredentials
 	}
 
+	if err := uc.guard.check(in.NoTelp, in.IP); err != nil {
+		return nil, err
+	}
+
 	user, err := uc.userRepo.FindByNoTelp(in.NoTelp)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
+		if err := uc.guard.fail(in.NoTelp, in.IP, nil); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
 	if err := helper.CheckPasswordHash(user.KataSandi, in.KataSandi); err != nil {
+		if err := uc.guard.fail(in.NoTelp, in.IP, user); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := helper.GenerateJWT(user.ID, user.Email, user.IsAdmin)
+	if err := uc.guard.succeed(in.NoTelp); err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabledAt != nil {
+		challenge, err := helper.GenerateChallengeJWT(user.ID, user.SessionVersion)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{
+			User:              user,
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
+	token, err := sessionToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		Token:              token,
+		User:               user,
+		EnrollmentRequired: uc.requireAdminTOTP && isStaff(user),
+	}, nil
+}
+
+func (uc *authUsecase) LoginTwoFactor(in TwoFactorLoginInput) (*LoginResult, error) {
+	claims, err := helper.ParseJWT(in.ChallengeToken)
+	if err != nil || claims.Purpose != helper.JWTPurposeTwoFactor {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := uc.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.SessionVersion != claims.SessionVersion || user.TOTPEnabledAt == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	// wrong codes count towards the same lockout as wrong passwords
+	if err := uc.guard.check(user.NoTelp, in.IP); err != nil {
+		return nil, err
+	}
+
+	ok, err := uc.checkSecondFactor(user, in.Code, in.RecoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := uc.guard.fail(user.NoTelp, in.IP, user); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTOTP
+	}
+
+	if err := uc.guard.succeed(user.NoTelp); err != nil {
+		return nil, err
+	}
+
+	token, err := sessionToken(user)
 	if err != nil {
 		return nil, err
 	}
@@ -137,4 +339,227 @@ func (uc *authUsecase) Login(in LoginInput) (*LoginResult, error) {
 		Token: token,
 		User:  user,
 	}, nil
-}
\ No newline at end of file
+}
+
+// checkSecondFactor validates a TOTP code, or consumes a recovery code.
+func (uc *authUsecase) checkSecondFactor(user *domain.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		return acceptTOTP(uc.userRepo, user, code)
+	}
+	if recoveryCode == "" {
+		return false, nil
+	}
+
+	rc, err := uc.recoveryRepo.FindUnused(user.ID, helper.HashToken(normalizeRecoveryCode(recoveryCode)))
+	if err != nil {
+		return false, err
+	}
+	if rc == nil {
+		return false, nil
+	}
+	if err := uc.recoveryRepo.MarkUsed(rc.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// sessionToken issues a session JWT for user. Since login requires the second
+// factor whenever 2FA is enabled, such sessions are marked as MFA.
+func sessionToken(user *domain.User) (string, error) {
+	return helper.GenerateJWT(helper.JWTClaims{
+		UserID:         user.ID,
+		Email:          user.Email,
+		IsAdmin:        user.IsAdmin,
+		SessionVersion: user.SessionVersion,
+		Roles:          user.RoleNames(),
+		Permissions:    user.PermissionNames(),
+		MFA:            user.TOTPEnabledAt != nil,
+	})
+}
+
+// isStaff reports whether user holds a back-office role.
+func isStaff(user *domain.User) bool {
+	if user.IsAdmin {
+		return true
+	}
+	for _, name := range user.RoleNames() {
+		for _, staff := range domain.StaffRoles {
+			if name == staff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findByNoTelpOrEmail resolves the account referenced by a public auth request.
+func (uc *authUsecase) findByNoTelpOrEmail(noTelp, email string) (*domain.User, error) {
+	if noTelp != "" {
+		return uc.userRepo.FindByNoTelp(noTelp)
+	}
+	if email != "" {
+		return uc.userRepo.FindByEmail(email)
+	}
+	return nil, errors.New("no_telp atau email wajib diisi")
+}
+
+func (uc *authUsecase) ForgotPassword(in ForgotPasswordInput) error {
+	if err := uc.otp.allowIP(in.IP); err != nil {
+		return err
+	}
+
+	user, err := uc.findByNoTelpOrEmail(in.NoTelp, in.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		// do not reveal whether the account exists
+		return nil
+	}
+
+	channel := in.Channel
+	if channel == "" {
+		channel = notifier.ChannelSMS
+		if in.NoTelp == "" {
+			channel = notifier.ChannelEmail
+		}
+	}
+
+	return uc.otp.issue(user, OTPPurposeResetPassword, channel, in.IP, func(code string) (string, string) {
+		return "Reset kata sandi",
+			fmt.Sprintf("Kode OTP reset kata sandi Anda: %s. Berlaku %d menit. Abaikan jika Anda tidak memintanya.", code, int(otpTTL.Minutes()))
+	})
+}
+
+func (uc *authUsecase) ResetPassword(actor Actor, in ResetPasswordInput) error {
+	if in.OTP == "" || in.KataSandiBaru == "" {
+		return errors.New("otp dan kata_sandi_baru wajib diisi")
+	}
+
+	user, err := uc.findByNoTelpOrEmail(in.NoTelp, in.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidOTP
+	}
+
+	if err := uc.otp.verify(user.ID, OTPPurposeResetPassword, in.OTP); err != nil {
+		return err
+	}
+
+	hashedPassword, err := helper.HashPassword(in.KataSandiBaru)
+	if err != nil {
+		return err
+	}
+
+	// the account may have been taken over; its API keys go with the old password
+	if err := uc.apiKeyRepo.RevokeAllByUser(user.ID, time.Now()); err != nil {
+		return err
+	}
+
+	before := auditSnapshot(user)
+	user.KataSandi = hashedPassword
+	// revoke every token issued before the reset
+	user.SessionVersion++
+
+	if err := uc.userRepo.Update(user); err != nil {
+		return err
+	}
+	actor.UserID = user.ID
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
+	return nil
+}
+
+// verificationPurpose maps a delivery channel to its OTP purpose.
+func verificationPurpose(channel string) (string, error) {
+	switch channel {
+	case notifier.ChannelEmail:
+		return OTPPurposeVerifyEmail, nil
+	case notifier.ChannelSMS:
+		return OTPPurposeVerifyPhone, nil
+	default:
+		return "", ErrInvalidChannel
+	}
+}
+
+// sendVerification issues a verification code for the channel; emails also
+// carry a one-click link to GET /auth/verify.
+func (uc *authUsecase) sendVerification(user *domain.User, channel, ip string) error {
+	purpose, err := verificationPurpose(channel)
+	if err != nil {
+		return err
+	}
+
+	return uc.otp.issue(user, purpose, channel, ip, func(code string) (string, string) {
+		body := fmt.Sprintf("Kode verifikasi Anda: %s. Berlaku %d menit.", code, int(otpTTL.Minutes()))
+		if channel == notifier.ChannelEmail {
+			link := fmt.Sprintf("%s/auth/verify?channel=%s&email=%s&otp=%s",
+				uc.baseURL, channel, url.QueryEscape(user.Email), code)
+			body += " Atau buka tautan berikut: " + link
+		}
+		return "Verifikasi akun", body
+	})
+}
+
+func (uc *authUsecase) VerifyContact(actor Actor, in VerifyContactInput) error {
+	purpose, err := verificationPurpose(in.Channel)
+	if err != nil {
+		return err
+	}
+
+	user, err := uc.findByNoTelpOrEmail(in.NoTelp, in.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidOTP
+	}
+
+	if err := uc.otp.verify(user.ID, purpose, in.OTP); err != nil {
+		return err
+	}
+
+	before := auditSnapshot(user)
+	now := time.Now()
+	if in.Channel == notifier.ChannelEmail {
+		user.EmailVerifiedAt = &now
+	} else {
+		user.NoTelpVerifiedAt = &now
+	}
+
+	if err := uc.userRepo.Update(user); err != nil {
+		return err
+	}
+	actor.UserID = user.ID
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
+	return nil
+}
+
+func (uc *authUsecase) ResendVerification(in ResendVerificationInput) error {
+	if _, err := verificationPurpose(in.Channel); err != nil {
+		return err
+	}
+	if err := uc.otp.allowIP(in.IP); err != nil {
+		return err
+	}
+
+	user, err := uc.findByNoTelpOrEmail(in.NoTelp, in.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		// do not reveal whether the account exists
+		return nil
+	}
+
+	if (in.Channel == notifier.ChannelEmail && user.EmailVerifiedAt != nil) ||
+		(in.Channel == notifier.ChannelSMS && user.NoTelpVerifiedAt != nil) {
+		return ErrAlreadyVerified
+	}
+
+	return uc.sendVerification(user, in.Channel, in.IP)
+}
@@ -7,17 +7,18 @@ import "github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/dom
 type CategoryUsecase interface {
 	GetAll() ([]domain.Category, error)
 	GetByID(id uint) (*domain.Category, error)
-	Create(name string) (*domain.Category, error)
-	Update(id uint, name string) (*domain.Category, error)
-	Delete(id uint) error
+	Create(actor Actor, name string) (*domain.Category, error)
+	Update(actor Actor, id uint, name string) (*domain.Category, error)
+	Delete(actor Actor, id uint) error
 }
 
 type categoryUsecase struct {
-	repo repository.CategoryRepository
+	repo  repository.CategoryRepository
+	audit *auditor
 }
 
-func NewCategoryUsecase(repo repository.CategoryRepository) CategoryUsecase {
-	return &categoryUsecase{repo: repo}
+func NewCategoryUsecase(repo repository.CategoryRepository, auditRepo repository.AuditLogRepository) CategoryUsecase {
+	return &categoryUsecase{repo: repo, audit: newAuditor(auditRepo)}
 }
 
 func (uc *categoryUsecase) GetAll() ([]domain.Category, error) {
@@ -28,15 +29,16 @@ func (uc *categoryUsecase) GetByID(id uint) (*domain.Category, error) {
 	return uc.repo.GetByID(id)
 }
 
-func (uc *categoryUsecase) Create(name string) (*domain.Category, error) {
+func (uc *categoryUsecase) Create(actor Actor, name string) (*domain.Category, error) {
 	category := &domain.Category{Nama: name}
 	if err := uc.repo.Create(category); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityCategory, category.ID, nil, auditSnapshot(category))
 	return category, nil
 }
 
-func (uc *categoryUsecase) Update(id uint, name string) (*domain.Category, error) {
+func (uc *categoryUsecase) Update(actor Actor, id uint, name string) (*domain.Category, error) {
 	category, err := uc.repo.GetByID(id)
 	if err != nil {
 		return nil, err
@@ -45,13 +47,27 @@ func (uc *categoryUsecase) Update(id uint, name string) (*domain.Category, error
 		return nil, nil
 	}
 
+	before := auditSnapshot(category)
 	category.Nama = name
 	if err := uc.repo.Update(category); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityCategory, category.ID, before, auditSnapshot(category))
 	return category, nil
 }
 
-func (uc *categoryUsecase) Delete(id uint) error {
-	return uc.repo.Delete(id)
+func (uc *categoryUsecase) Delete(actor Actor, id uint) error {
+	category, err := uc.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return nil
+	}
+
+	if err := uc.repo.Delete(id); err != nil {
+		return err
+	}
+	uc.audit.record(actor, domain.AuditActionDelete, AuditEntityCategory, category.ID, auditSnapshot(category), nil)
+	return nil
 }
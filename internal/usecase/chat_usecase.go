@@ -0,0 +1,438 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	chatMaxBody      = 2000
+	chatPreviewLen   = 100
+	chatStreamBatch  = 100
+	chatSignalBuffer = 16
+)
+
+// StartConversationInput represents payload to open a chat with a toko,
+// optionally about one of its products or one of the buyer's trx.
+type StartConversationInput struct {
+	TokoID    uint   `json:"toko_id" form:"toko_id"`
+	ProductID uint   `json:"product_id" form:"product_id"`
+	TrxID     uint   `json:"trx_id" form:"trx_id"`
+	Message   string `json:"message" form:"message"`
+}
+
+// ConversationView is a conversation as seen by one participant: Side is
+// the viewer's side and UnreadCount the messages from the other side they
+// have not read.
+type ConversationView struct {
+	Conversation domain.Conversation
+	Side         string
+	UnreadCount  int64
+}
+
+// ChatReceipt tells the other side that messages up to UpToID were read.
+type ChatReceipt struct {
+	ConversationID uint      `json:"conversation_id"`
+	ReaderSide     string    `json:"reader_side"`
+	UpToID         uint      `json:"up_to_id"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+// ChatSignal wakes up a live chat connection. A nil Receipt means new
+// messages are available through GetNewMessages.
+type ChatSignal struct {
+	Receipt *ChatReceipt
+}
+
+// ChatUsecase handles buyer–toko conversations.
+type ChatUsecase interface {
+	StartConversation(userID uint, in StartConversationInput, attachments []string) (*ConversationView, *domain.ChatMessage, error)
+	GetConversations(userID, tokoID uint, limit, page int) ([]ConversationView, error)
+	GetConversation(userID, id uint) (*ConversationView, error)
+	GetMessages(userID, conversationID, beforeID uint, limit int) ([]domain.ChatMessage, error)
+	SendMessage(userID, conversationID uint, body string, attachments []string) (*domain.ChatMessage, error)
+	MarkRead(userID, conversationID, upToID uint) (*ChatReceipt, error)
+	GetNewMessages(userID, afterID uint) ([]domain.ChatMessage, error)
+	LatestMessageID(userID uint) (uint, error)
+	Listen(userID uint) (<-chan ChatSignal, func())
+}
+
+type chatUsecase struct {
+	conversationRepo repository.ConversationRepository
+	messageRepo      repository.ChatMessageRepository
+	tokoRepo         repository.TokoRepository
+	productRepo      repository.ProductRepository
+	trxRepo          repository.TrxRepository
+	memberRepo       repository.TokoMemberRepository
+	access           *tokoAccess
+	hub              *chatHub
+}
+
+// NewChatUsecase creates a new ChatUsecase.
+func NewChatUsecase(conversationRepo repository.ConversationRepository, messageRepo repository.ChatMessageRepository, tokoRepo repository.TokoRepository, productRepo repository.ProductRepository, trxRepo repository.TrxRepository, memberRepo repository.TokoMemberRepository) ChatUsecase {
+	return &chatUsecase{
+		conversationRepo: conversationRepo,
+		messageRepo:      messageRepo,
+		tokoRepo:         tokoRepo,
+		productRepo:      productRepo,
+		trxRepo:          trxRepo,
+		memberRepo:       memberRepo,
+		access:           newTokoAccess(memberRepo),
+		hub:              newChatHub(),
+	}
+}
+
+var (
+	// ErrConversationNotFound indicates the conversation does not exist or the user is not part of it.
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrChatOwnToko indicates a toko member trying to open a chat with their own toko.
+	ErrChatOwnToko = errors.New("tidak bisa chat dengan toko sendiri")
+	// ErrInvalidChatMessage indicates an empty or too long message.
+	ErrInvalidChatMessage = errors.New("pesan wajib diisi (maksimal 2000 karakter) atau lampirkan gambar")
+)
+
+// StartConversation opens the user's conversation with a toko, or reuses
+// the open one, and sends the first message. A product it is about must be
+// published; a trx must be the user's and include the toko.
+func (uc *chatUsecase) StartConversation(userID uint, in StartConversationInput, attachments []string) (*ConversationView, *domain.ChatMessage, error) {
+	if in.TokoID == 0 {
+		return nil, nil, errors.New("toko_id wajib diisi")
+	}
+	toko, err := uc.tokoRepo.GetByID(in.TokoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if toko == nil {
+		return nil, nil, ErrTokoNotFound
+	}
+	member, err := uc.memberRepo.GetByTokoAndUser(toko.ID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if member != nil {
+		return nil, nil, ErrChatOwnToko
+	}
+
+	var produkID, trxID *uint
+	if in.ProductID != 0 {
+		produk, err := uc.productRepo.GetByID(in.ProductID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if produk == nil || produk.TokoID != toko.ID || produk.Status != domain.ProductStatusPublished {
+			return nil, nil, ErrProductNotFound
+		}
+		produkID = &produk.ID
+	}
+	if in.TrxID != 0 {
+		trx, err := uc.trxRepo.GetByIDForUser(userID, in.TrxID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if trx == nil || !trxHasToko(trx, toko.ID) {
+			return nil, nil, ErrTrxNotFound
+		}
+		trxID = &trx.ID
+	}
+
+	conversation, err := uc.conversationRepo.Find(userID, toko.ID, produkID, trxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if conversation == nil {
+		conversation = &domain.Conversation{
+			UserID:   userID,
+			TokoID:   toko.ID,
+			ProdukID: produkID,
+			TrxID:    trxID,
+		}
+		if err := uc.conversationRepo.Create(conversation); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var message *domain.ChatMessage
+	if strings.TrimSpace(in.Message) != "" || len(attachments) > 0 {
+		message, err = uc.send(conversation, userID, domain.ChatSideBuyer, in.Message, attachments)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	view, err := uc.GetConversation(userID, conversation.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return view, message, nil
+}
+
+// GetConversations lists the buyer's conversations, or those of a toko the
+// user is a member of when tokoID is set.
+func (uc *chatUsecase) GetConversations(userID, tokoID uint, limit, page int) ([]ConversationView, error) {
+	var (
+		conversations []domain.Conversation
+		side          = domain.ChatSideBuyer
+		err           error
+	)
+	if tokoID == 0 {
+		conversations, err = uc.conversationRepo.GetAllByUser(userID, limit, page)
+	} else {
+		member, rerr := uc.access.resolve(userID, tokoID, "")
+		if rerr != nil {
+			return nil, rerr
+		}
+		side = domain.ChatSideToko
+		conversations, err = uc.conversationRepo.GetAllByToko(member.TokoID, limit, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]ConversationView, 0, len(conversations))
+	for _, c := range conversations {
+		unread, err := uc.messageRepo.CountUnread(c.ID, otherChatSide(side))
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, ConversationView{Conversation: c, Side: side, UnreadCount: unread})
+	}
+	return views, nil
+}
+
+func (uc *chatUsecase) GetConversation(userID, id uint) (*ConversationView, error) {
+	conversation, side, err := uc.getParticipating(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := uc.messageRepo.CountUnread(conversation.ID, otherChatSide(side))
+	if err != nil {
+		return nil, err
+	}
+	return &ConversationView{Conversation: *conversation, Side: side, UnreadCount: unread}, nil
+}
+
+// GetMessages returns history older than beforeID (0 for the newest), newest first.
+func (uc *chatUsecase) GetMessages(userID, conversationID, beforeID uint, limit int) ([]domain.ChatMessage, error) {
+	conversation, _, err := uc.getParticipating(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.messageRepo.GetByConversation(conversation.ID, beforeID, limit)
+}
+
+func (uc *chatUsecase) SendMessage(userID, conversationID uint, body string, attachments []string) (*domain.ChatMessage, error) {
+	conversation, side, err := uc.getParticipating(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.send(conversation, userID, side, body, attachments)
+}
+
+// MarkRead marks the other side's messages up to upToID (0 for all) as
+// read and tells the other side through a receipt.
+func (uc *chatUsecase) MarkRead(userID, conversationID, upToID uint) (*ChatReceipt, error) {
+	conversation, side, err := uc.getParticipating(userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if upToID == 0 {
+		latest, err := uc.messageRepo.GetByConversation(conversation.ID, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) == 0 {
+			return &ChatReceipt{ConversationID: conversation.ID, ReaderSide: side, ReadAt: time.Now()}, nil
+		}
+		upToID = latest[0].ID
+	}
+
+	receipt := &ChatReceipt{
+		ConversationID: conversation.ID,
+		ReaderSide:     side,
+		UpToID:         upToID,
+		ReadAt:         time.Now(),
+	}
+	updated, err := uc.messageRepo.MarkRead(conversation.ID, otherChatSide(side), upToID, receipt.ReadAt)
+	if err != nil {
+		return nil, err
+	}
+	if updated > 0 {
+		uc.signal(conversation, ChatSignal{Receipt: receipt})
+	}
+	return receipt, nil
+}
+
+// GetNewMessages returns messages newer than afterID in any of the user's
+// conversations, oldest first, for live delivery.
+func (uc *chatUsecase) GetNewMessages(userID, afterID uint) ([]domain.ChatMessage, error) {
+	tokoIDs, err := uc.memberTokoIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.messageRepo.GetForParticipantSince(userID, tokoIDs, afterID, chatStreamBatch)
+}
+
+func (uc *chatUsecase) LatestMessageID(userID uint) (uint, error) {
+	tokoIDs, err := uc.memberTokoIDs(userID)
+	if err != nil {
+		return 0, err
+	}
+	return uc.messageRepo.LatestIDForParticipant(userID, tokoIDs)
+}
+
+// Listen returns a channel of signals for the user's live connections on
+// this instance, and a func to stop listening. Connections should also
+// poll GetNewMessages, since other instances do not signal; receipts from
+// other instances are only visible through the REST history.
+func (uc *chatUsecase) Listen(userID uint) (<-chan ChatSignal, func()) {
+	return uc.hub.listen(userID)
+}
+
+func (uc *chatUsecase) send(conversation *domain.Conversation, userID uint, side, body string, attachments []string) (*domain.ChatMessage, error) {
+	body = strings.TrimSpace(body)
+	if (body == "" && len(attachments) == 0) || utf8.RuneCountInString(body) > chatMaxBody {
+		return nil, ErrInvalidChatMessage
+	}
+
+	message := &domain.ChatMessage{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		SenderSide:     side,
+		Body:           body,
+		Attachments:    strings.Join(attachments, ","),
+		CreatedAt:      time.Now(),
+	}
+	if err := uc.messageRepo.Create(message, chatPreview(body)); err != nil {
+		return nil, err
+	}
+
+	uc.signal(conversation, ChatSignal{})
+	return message, nil
+}
+
+// signal wakes up the live connections of the buyer and the toko's members.
+func (uc *chatUsecase) signal(conversation *domain.Conversation, sig ChatSignal) {
+	userIDs := []uint{conversation.UserID}
+	if members, err := uc.memberRepo.GetAllByToko(conversation.TokoID); err == nil {
+		for _, m := range members {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+	uc.hub.notify(userIDs, sig)
+}
+
+// getParticipating loads a conversation with the user's side in it.
+func (uc *chatUsecase) getParticipating(userID, id uint) (*domain.Conversation, string, error) {
+	conversation, err := uc.conversationRepo.GetByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if conversation == nil {
+		return nil, "", ErrConversationNotFound
+	}
+	if conversation.UserID == userID {
+		return conversation, domain.ChatSideBuyer, nil
+	}
+	if _, err := uc.access.resolve(userID, conversation.TokoID, ""); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, "", ErrConversationNotFound
+		}
+		return nil, "", err
+	}
+	return conversation, domain.ChatSideToko, nil
+}
+
+func (uc *chatUsecase) memberTokoIDs(userID uint) ([]uint, error) {
+	members, err := uc.memberRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.TokoID)
+	}
+	return ids, nil
+}
+
+func otherChatSide(side string) string {
+	if side == domain.ChatSideBuyer {
+		return domain.ChatSideToko
+	}
+	return domain.ChatSideBuyer
+}
+
+// chatPreview shortens a message for the conversation list.
+func chatPreview(body string) string {
+	if body == "" {
+		return "[gambar]"
+	}
+	if utf8.RuneCountInString(body) <= chatPreviewLen {
+		return body
+	}
+	return string([]rune(body)[:chatPreviewLen]) + "…"
+}
+
+func trxHasToko(trx *domain.Trx, tokoID uint) bool {
+	for _, d := range trx.DetailTrx {
+		if d.TokoID == tokoID {
+			return true
+		}
+	}
+	return false
+}
+
+// chatHub fans chat signals out to live connections on this instance.
+type chatHub struct {
+	mu        sync.Mutex
+	listeners map[uint]map[chan ChatSignal]struct{}
+}
+
+func newChatHub() *chatHub {
+	return &chatHub{listeners: make(map[uint]map[chan ChatSignal]struct{})}
+}
+
+func (h *chatHub) listen(userID uint) (<-chan ChatSignal, func()) {
+	ch := make(chan ChatSignal, chatSignalBuffer)
+
+	h.mu.Lock()
+	if h.listeners[userID] == nil {
+		h.listeners[userID] = make(map[chan ChatSignal]struct{})
+	}
+	h.listeners[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.listeners[userID], ch)
+		if len(h.listeners[userID]) == 0 {
+			delete(h.listeners, userID)
+		}
+	}
+}
+
+// notify sends sig to every connection of the users without blocking; a
+// connection that is too far behind misses it and catches up by polling.
+func (h *chatHub) notify(userIDs []uint, sig ChatSignal) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seen := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		for ch := range h.listeners[userID] {
+			select {
+			case ch <- sig:
+			default:
+			}
+		}
+	}
+}
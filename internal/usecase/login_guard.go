@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// Security event types stored in security_event.type.
+const (
+	SecurityEventLoginLockout = "login_lockout"
+)
+
+const (
+	// failures are counted within loginFailureWindow of the previous one
+	loginFailureWindow = time.Hour
+	// account lockout starts after this many failures, per IP after ipLockThreshold
+	accountLockThreshold = 5
+	ipLockThreshold      = 20
+	// lockout doubles with every failure past the threshold, up to loginMaxLockout
+	loginBaseLockout = time.Minute
+	loginMaxLockout  = time.Hour
+)
+
+// ErrAccountLocked indicates login is temporarily blocked after repeated failures.
+var ErrAccountLocked = errors.New("terlalu banyak percobaan login, coba lagi nanti")
+
+// LoginLockedError carries when a lockout ends; it matches ErrAccountLocked.
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string { return ErrAccountLocked.Error() }
+
+func (e *LoginLockedError) Is(target error) bool { return target == ErrAccountLocked }
+
+// loginGuard applies per-account and per-IP exponential backoff to logins.
+type loginGuard struct {
+	attemptRepo repository.LoginAttemptRepository
+	eventRepo   repository.SecurityEventRepository
+}
+
+func newLoginGuard(attemptRepo repository.LoginAttemptRepository, eventRepo repository.SecurityEventRepository) *loginGuard {
+	return &loginGuard{attemptRepo: attemptRepo, eventRepo: eventRepo}
+}
+
+func accountKey(noTelp string) string { return "account:" + noTelp }
+
+func ipKey(ip string) string { return "ip:" + ip }
+
+// lockoutFor returns the lockout duration after failures, or 0 below threshold.
+func lockoutFor(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+	d := loginBaseLockout
+	for i := threshold; i < failures && d < loginMaxLockout; i++ {
+		d *= 2
+	}
+	if d > loginMaxLockout {
+		d = loginMaxLockout
+	}
+	return d
+}
+
+// check returns a LoginLockedError if the account or IP is locked out.
+func (g *loginGuard) check(noTelp, ip string) error {
+	now := time.Now()
+	for _, key := range []string{accountKey(noTelp), ipKey(ip)} {
+		attempt, err := g.attemptRepo.Get(key)
+		if err != nil {
+			return err
+		}
+		if attempt != nil && attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			return &LoginLockedError{Until: *attempt.LockedUntil}
+		}
+	}
+	return nil
+}
+
+// fail records a failed login for the account and IP, locking either once its
+// threshold is reached. user is nil when no_telp matched no account.
+func (g *loginGuard) fail(noTelp, ip string, user *domain.User) error {
+	now := time.Now()
+	keys := []struct {
+		key       string
+		threshold int
+	}{
+		{accountKey(noTelp), accountLockThreshold},
+		{ipKey(ip), ipLockThreshold},
+	}
+
+	for _, k := range keys {
+		attempt, err := g.attemptRepo.RecordFailure(k.key, now, loginFailureWindow)
+		if err != nil {
+			return err
+		}
+
+		lockout := lockoutFor(attempt.Failures, k.threshold)
+		if lockout == 0 {
+			continue
+		}
+
+		until := now.Add(lockout)
+		if err := g.attemptRepo.Lock(k.key, until); err != nil {
+			return err
+		}
+
+		event := &domain.SecurityEvent{
+			Type:   SecurityEventLoginLockout,
+			IP:     ip,
+			Detail: fmt.Sprintf("%s locked until %s after %d failed attempts", k.key, until.Format(time.RFC3339), attempt.Failures),
+		}
+		if user != nil {
+			event.UserID = &user.ID
+		}
+		if err := g.eventRepo.Create(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// succeed clears the account counter; the IP counter is kept so one valid
+// login does not reset an IP spraying many accounts.
+func (g *loginGuard) succeed(noTelp string) error {
+	return g.attemptRepo.Reset(accountKey(noTelp))
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+func TestLockoutFor(t *testing.T) {
+	tests := []struct {
+		failures, threshold int
+		want                time.Duration
+	}{
+		{0, accountLockThreshold, 0},
+		{4, accountLockThreshold, 0},
+		{5, accountLockThreshold, time.Minute},
+		{6, accountLockThreshold, 2 * time.Minute},
+		{7, accountLockThreshold, 4 * time.Minute},
+		{10, accountLockThreshold, 32 * time.Minute},
+		{11, accountLockThreshold, loginMaxLockout},
+		{100, accountLockThreshold, loginMaxLockout},
+		{19, ipLockThreshold, 0},
+		{20, ipLockThreshold, time.Minute},
+		{22, ipLockThreshold, 4 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutFor(tt.failures, tt.threshold); got != tt.want {
+			t.Errorf("lockoutFor(%d, %d) = %v, want %v", tt.failures, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+type fakeSecurityEventRepo struct {
+	events []domain.SecurityEvent
+}
+
+func (r *fakeSecurityEventRepo) Create(event *domain.SecurityEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+type loginTry struct {
+	noTelp, ip string
+}
+
+func TestLoginGuard(t *testing.T) {
+	const attacker, other = "203.0.113.7", "198.51.100.2"
+	repeat := func(n int, try loginTry) []loginTry {
+		tries := make([]loginTry, n)
+		for i := range tries {
+			tries[i] = try
+		}
+		return tries
+	}
+	spray := make([]loginTry, ipLockThreshold)
+	for i := range spray {
+		spray[i] = loginTry{fmt.Sprintf("08%02d", i), attacker}
+	}
+
+	tests := []struct {
+		name       string
+		failures   []loginTry
+		succeeded  string
+		after      []loginTry
+		locked     []loginTry
+		free       []loginTry
+		wantEvents int
+	}{
+		{
+			name:     "below the account threshold",
+			failures: repeat(accountLockThreshold-1, loginTry{"0811", attacker}),
+			free:     []loginTry{{"0811", attacker}},
+		},
+		{
+			name:       "account threshold locks the account from any IP",
+			failures:   repeat(accountLockThreshold, loginTry{"0811", attacker}),
+			locked:     []loginTry{{"0811", attacker}, {"0811", other}},
+			free:       []loginTry{{"0812", other}},
+			wantEvents: 1,
+		},
+		{
+			name:       "spraying accounts locks the IP",
+			failures:   spray,
+			locked:     []loginTry{{"0899", attacker}},
+			free:       []loginTry{{"0800", other}},
+			wantEvents: 1,
+		},
+		{
+			name:      "a successful login resets the account counter",
+			failures:  repeat(accountLockThreshold-1, loginTry{"0811", other}),
+			succeeded: "0811",
+			after:     []loginTry{{"0811", other}},
+			free:      []loginTry{{"0811", other}},
+		},
+		{
+			name:       "a successful login keeps the IP counter",
+			failures:   spray[:ipLockThreshold-1],
+			succeeded:  "0800",
+			after:      []loginTry{{"0811", attacker}},
+			locked:     []loginTry{{"0899", attacker}},
+			wantEvents: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &fakeSecurityEventRepo{}
+			guard := newLoginGuard(repository.NewMemoryLoginAttemptRepository(), events)
+
+			for _, f := range tt.failures {
+				if err := guard.fail(f.noTelp, f.ip, nil); err != nil {
+					t.Fatalf("fail(%s, %s): %v", f.noTelp, f.ip, err)
+				}
+			}
+			if tt.succeeded != "" {
+				if err := guard.succeed(tt.succeeded); err != nil {
+					t.Fatalf("succeed(%s): %v", tt.succeeded, err)
+				}
+			}
+			for _, f := range tt.after {
+				if err := guard.fail(f.noTelp, f.ip, nil); err != nil {
+					t.Fatalf("fail(%s, %s): %v", f.noTelp, f.ip, err)
+				}
+			}
+
+			for _, l := range tt.locked {
+				err := guard.check(l.noTelp, l.ip)
+				var locked *LoginLockedError
+				if !errors.As(err, &locked) || !errors.Is(err, ErrAccountLocked) {
+					t.Errorf("check(%s, %s) = %v, want a lockout", l.noTelp, l.ip, err)
+				} else if until := time.Until(locked.Until); until <= 0 || until > loginBaseLockout {
+					t.Errorf("check(%s, %s) locked for %v, want up to %v", l.noTelp, l.ip, until, loginBaseLockout)
+				}
+			}
+			for _, f := range tt.free {
+				if err := guard.check(f.noTelp, f.ip); err != nil {
+					t.Errorf("check(%s, %s) = %v, want nil", f.noTelp, f.ip, err)
+				}
+			}
+			if len(events.events) != tt.wantEvents {
+				t.Errorf("security events = %d, want %d", len(events.events), tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/notifier"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// notificationStreamBatch caps how many notifications one stream read returns.
+const notificationStreamBatch = 50
+
+// NotificationListResult wraps a page of notifications with the unread count.
+type NotificationListResult struct {
+	Page        int                   `json:"page"`
+	Limit       int                   `json:"limit"`
+	UnreadCount int64                 `json:"unread_count"`
+	Data        []domain.Notification `json:"data"`
+}
+
+// NotificationSetting is whether one notification type goes out on one channel.
+type NotificationSetting struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// NotificationUsecase manages the user's notification center. HandleEvent
+// subscribes it to domain events on the event bus.
+type NotificationUsecase interface {
+	GetAll(userID uint, limit, page int, unreadOnly bool) (*NotificationListResult, error)
+	CountUnread(userID uint) (int64, error)
+	GetSince(userID, afterID uint) ([]domain.Notification, error)
+	MarkRead(userID, id uint) error
+	MarkAllRead(userID uint) (int64, error)
+	GetPreferences(userID uint) ([]NotificationSetting, error)
+	UpdatePreferences(userID uint, settings []NotificationSetting) ([]NotificationSetting, error)
+	Listen(userID uint) (<-chan struct{}, func())
+	HandleEvent(e event.Event) error
+}
+
+type notificationUsecase struct {
+	notifRepo         repository.NotificationRepository
+	prefRepo          repository.NotificationPreferenceRepository
+	memberRepo        repository.TokoMemberRepository
+	userRepo          repository.UserRepository
+	notifier          notifier.Notifier
+	lowStockThreshold int
+	hub               *notificationHub
+}
+
+// NewNotificationUsecase creates a new NotificationUsecase. Sellers get a
+// low stock notification when a product's stock drops to lowStockThreshold.
+func NewNotificationUsecase(notifRepo repository.NotificationRepository, prefRepo repository.NotificationPreferenceRepository, memberRepo repository.TokoMemberRepository, userRepo repository.UserRepository, n notifier.Notifier, lowStockThreshold int) NotificationUsecase {
+	return &notificationUsecase{
+		notifRepo:         notifRepo,
+		prefRepo:          prefRepo,
+		memberRepo:        memberRepo,
+		userRepo:          userRepo,
+		notifier:          n,
+		lowStockThreshold: lowStockThreshold,
+		hub:               newNotificationHub(),
+	}
+}
+
+var (
+	// ErrNotificationNotFound indicates the notification does not exist or belongs to another user.
+	ErrNotificationNotFound = errors.New("notification not found")
+	// ErrInvalidNotificationSetting indicates an unknown notification type or channel.
+	ErrInvalidNotificationSetting = errors.New("tipe atau channel notifikasi tidak dikenal")
+)
+
+func (uc *notificationUsecase) GetAll(userID uint, limit, page int, unreadOnly bool) (*NotificationListResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	notifications, err := uc.notifRepo.GetAllByUser(userID, limit, page, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := uc.notifRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationListResult{
+		Page:        page,
+		Limit:       limit,
+		UnreadCount: unread,
+		Data:        notifications,
+	}, nil
+}
+
+func (uc *notificationUsecase) CountUnread(userID uint) (int64, error) {
+	return uc.notifRepo.CountUnread(userID)
+}
+
+// GetSince returns notifications newer than afterID, oldest first, for streaming.
+func (uc *notificationUsecase) GetSince(userID, afterID uint) ([]domain.Notification, error) {
+	return uc.notifRepo.GetSince(userID, afterID, notificationStreamBatch)
+}
+
+func (uc *notificationUsecase) MarkRead(userID, id uint) error {
+	found, err := uc.notifRepo.MarkRead(userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (uc *notificationUsecase) MarkAllRead(userID uint) (int64, error) {
+	return uc.notifRepo.MarkAllRead(userID, time.Now())
+}
+
+// GetPreferences returns every type and channel with the user's choice,
+// or the default when the user has not changed it.
+func (uc *notificationUsecase) GetPreferences(userID uint) ([]NotificationSetting, error) {
+	enabled, err := uc.preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]NotificationSetting, 0, len(domain.NotificationTypes)*len(domain.NotificationChannels))
+	for _, t := range domain.NotificationTypes {
+		for _, ch := range domain.NotificationChannels {
+			settings = append(settings, NotificationSetting{Type: t, Channel: ch, Enabled: enabled(t, ch)})
+		}
+	}
+	return settings, nil
+}
+
+func (uc *notificationUsecase) UpdatePreferences(userID uint, settings []NotificationSetting) ([]NotificationSetting, error) {
+	prefs := make([]domain.NotificationPreference, 0, len(settings))
+	for _, s := range settings {
+		if !containsString(domain.NotificationTypes, s.Type) || !containsString(domain.NotificationChannels, s.Channel) {
+			return nil, ErrInvalidNotificationSetting
+		}
+		prefs = append(prefs, domain.NotificationPreference{
+			UserID:  userID,
+			Type:    s.Type,
+			Channel: s.Channel,
+			Enabled: s.Enabled,
+		})
+	}
+
+	if err := uc.prefRepo.Upsert(prefs); err != nil {
+		return nil, err
+	}
+	return uc.GetPreferences(userID)
+}
+
+// Listen returns a channel that receives a signal whenever a notification
+// is stored for the user on this instance, and a func to stop listening.
+// Streams should also poll GetSince, since other instances do not signal.
+func (uc *notificationUsecase) Listen(userID uint) (<-chan struct{}, func()) {
+	return uc.hub.listen(userID)
+}
+
+// HandleEvent turns domain events into notifications for the buyer or the
+// toko members responsible for them. Other events are ignored.
+func (uc *notificationUsecase) HandleEvent(e event.Event) error {
+	switch e.Type {
+	case domain.EventTrxCreated:
+		var trx event.TrxCreated
+		if err := e.Decode(&trx); err != nil {
+			return err
+		}
+		data := map[string]interface{}{"trx_id": trx.TrxID}
+		if err := uc.deliver(trx.UserID, 0, e.ID, domain.NotifOrderPlaced, "Pesanan dibuat",
+			fmt.Sprintf("Pesanan %s sebesar Rp%d berhasil dibuat.", trx.KodeInvoice, trx.HargaTotal), data); err != nil {
+			return err
+		}
+
+		items := make(map[uint]int)
+		var tokoIDs []uint
+		for _, item := range trx.Items {
+			if _, ok := items[item.TokoID]; !ok {
+				tokoIDs = append(tokoIDs, item.TokoID)
+			}
+			items[item.TokoID] += item.Kuantitas
+		}
+		for _, tokoID := range tokoIDs {
+			data := map[string]interface{}{"trx_id": trx.TrxID, "toko_id": tokoID}
+			body := fmt.Sprintf("Pesanan %s berisi %d barang dari toko Anda.", trx.KodeInvoice, items[tokoID])
+			if err := uc.deliverToToko(tokoID, domain.TokoCapOrders, e.ID, domain.NotifOrderPlaced, "Pesanan baru", body, data); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case domain.EventOrderShipped:
+		var shipped event.OrderShipped
+		if err := e.Decode(&shipped); err != nil {
+			return err
+		}
+		data := map[string]interface{}{"trx_id": shipped.TrxID, "toko_id": shipped.TokoID, "no_resi": shipped.NoResi}
+		return uc.deliver(shipped.UserID, 0, e.ID, domain.NotifOrderShipped, "Pesanan dikirim",
+			fmt.Sprintf("Pesanan %s telah dikirim dengan no resi %s.", shipped.KodeInvoice, shipped.NoResi), data)
+
+	case domain.EventStockChanged:
+		var sc event.StockChanged
+		if err := e.Decode(&sc); err != nil {
+			return err
+		}
+		// Only notify when the stock crosses the threshold, not on every sale below it
+		if sc.StokSebelum <= uc.lowStockThreshold || sc.Stok > uc.lowStockThreshold {
+			return nil
+		}
+		data := map[string]interface{}{"product_id": sc.ProductID, "toko_id": sc.TokoID, "stok": sc.Stok}
+		return uc.deliverToToko(sc.TokoID, domain.TokoCapProducts, e.ID, domain.NotifLowStock, "Stok menipis",
+			fmt.Sprintf("Stok %s tinggal %d.", sc.NamaProduk, sc.Stok), data)
+
+	case domain.EventProductApproved:
+		var pm event.ProductModerated
+		if err := e.Decode(&pm); err != nil {
+			return err
+		}
+		data := map[string]interface{}{"product_id": pm.ProductID, "toko_id": pm.TokoID, "status": pm.Status}
+		body := fmt.Sprintf("Produk %s telah disetujui dan tayang.", pm.NamaProduk)
+		if pm.PublishAt != nil {
+			data["publish_at"] = pm.PublishAt
+			body = fmt.Sprintf("Produk %s telah disetujui dan akan tayang pada %s.", pm.NamaProduk, pm.PublishAt.Format("02-01-2006 15:04"))
+		}
+		return uc.deliverToToko(pm.TokoID, domain.TokoCapProducts, e.ID, domain.NotifProductApproved, "Produk disetujui", body, data)
+
+	case domain.EventProductRejected:
+		var pm event.ProductModerated
+		if err := e.Decode(&pm); err != nil {
+			return err
+		}
+		data := map[string]interface{}{"product_id": pm.ProductID, "toko_id": pm.TokoID, "reason": pm.Reason}
+		return uc.deliverToToko(pm.TokoID, domain.TokoCapProducts, e.ID, domain.NotifProductRejected, "Produk ditolak",
+			fmt.Sprintf("Produk %s ditolak: %s", pm.NamaProduk, pm.Reason), data)
+	}
+	return nil
+}
+
+// deliverToToko notifies every member of the toko whose role grants capability.
+func (uc *notificationUsecase) deliverToToko(tokoID uint, capability string, eventID uint, notifType, title, body string, data map[string]interface{}) error {
+	members, err := uc.memberRepo.GetAllByToko(tokoID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if !domain.TokoRoleAllows(m.Role, capability) {
+			continue
+		}
+		if err := uc.deliver(m.UserID, tokoID, eventID, notifType, title, body, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver stores the notification in the inbox and sends it by email or
+// SMS, following the user's preferences. tokoID is the toko the user is
+// notified for, 0 for their own activity. A notification already stored for
+// this event and toko is not sent again. Email and SMS failures are logged only.
+func (uc *notificationUsecase) deliver(userID, tokoID, eventID uint, notifType, title, body string, data map[string]interface{}) error {
+	enabled, err := uc.preferences(userID)
+	if err != nil {
+		return err
+	}
+
+	if enabled(notifType, domain.NotifChannelInApp) {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		created, err := uc.notifRepo.Create(&domain.Notification{
+			UserID:  userID,
+			EventID: eventID,
+			Type:    notifType,
+			TokoID:  tokoID,
+			Title:   title,
+			Body:    body,
+			Data:    string(raw),
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			return nil
+		}
+		uc.hub.notify(userID)
+	}
+
+	email, sms := enabled(notifType, domain.NotifChannelEmail), enabled(notifType, domain.NotifChannelSMS)
+	if !email && !sms {
+		return nil
+	}
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	if email && user.Email != "" {
+		if err := uc.notifier.Send(notifier.Message{Channel: notifier.ChannelEmail, To: user.Email, Subject: title, Body: body}); err != nil {
+			log.Printf("notification: failed to email user %d: %v", userID, err)
+		}
+	}
+	if sms && user.NoTelp != "" {
+		if err := uc.notifier.Send(notifier.Message{Channel: notifier.ChannelSMS, To: user.NoTelp, Subject: title, Body: body}); err != nil {
+			log.Printf("notification: failed to text user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// preferences loads the user's settings and returns a lookup that falls
+// back to domain.NotificationDefaultEnabled.
+func (uc *notificationUsecase) preferences(userID uint) (func(notifType, channel string) bool, error) {
+	prefs, err := uc.prefRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(prefs))
+	for _, p := range prefs {
+		set[p.Type+"/"+p.Channel] = p.Enabled
+	}
+	return func(notifType, channel string) bool {
+		if v, ok := set[notifType+"/"+channel]; ok {
+			return v
+		}
+		return domain.NotificationDefaultEnabled(notifType, channel)
+	}, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationHub wakes up open notification streams on this instance.
+type notificationHub struct {
+	mu        sync.Mutex
+	listeners map[uint]map[chan struct{}]struct{}
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{listeners: make(map[uint]map[chan struct{}]struct{})}
+}
+
+func (h *notificationHub) listen(userID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.listeners[userID] == nil {
+		h.listeners[userID] = make(map[chan struct{}]struct{})
+	}
+	h.listeners[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.listeners[userID], ch)
+		if len(h.listeners[userID]) == 0 {
+			delete(h.listeners, userID)
+		}
+	}
+}
+
+// notify signals every stream of the user without blocking; a stream that
+// has a signal pending already will read the new notification too.
+func (h *notificationHub) notify(userID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.listeners[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
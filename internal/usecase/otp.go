@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/notifier"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// OTP purposes stored in user_otp.purpose.
+const (
+	OTPPurposeResetPassword = "reset_password"
+	OTPPurposeVerifyEmail   = "verify_email"
+	OTPPurposeVerifyPhone   = "verify_phone"
+)
+
+const (
+	otpDigits      = 6
+	otpTTL         = 10 * time.Minute
+	otpMaxAttempts = 5
+	// at most otpSendLimit codes per purpose within otpSendWindow, and
+	// otpIPSendLimit codes for any account requested from one IP
+	otpSendLimit   = 3
+	otpIPSendLimit = 10
+	otpSendWindow  = 15 * time.Minute
+)
+
+var (
+	// ErrInvalidOTP indicates the OTP is wrong, expired or already used.
+	ErrInvalidOTP = errors.New("kode otp tidak valid atau kadaluarsa")
+	// ErrOTPRateLimited indicates too many OTP requests in a short time.
+	ErrOTPRateLimited = errors.New("terlalu banyak permintaan otp, coba lagi nanti")
+	// ErrInvalidChannel indicates an unsupported delivery channel.
+	ErrInvalidChannel = errors.New("channel harus email atau sms")
+)
+
+// otpService issues and verifies one-time codes delivered through a Notifier.
+type otpService struct {
+	otpRepo  repository.OTPRepository
+	notifier notifier.Notifier
+}
+
+func newOTPService(otpRepo repository.OTPRepository, n notifier.Notifier) *otpService {
+	return &otpService{otpRepo: otpRepo, notifier: n}
+}
+
+// targetFor returns the user's address for the given channel.
+func targetFor(user *domain.User, channel string) (string, error) {
+	switch channel {
+	case notifier.ChannelEmail:
+		return user.Email, nil
+	case notifier.ChannelSMS:
+		return user.NoTelp, nil
+	default:
+		return "", ErrInvalidChannel
+	}
+}
+
+// allowIP returns ErrOTPRateLimited once ip has requested otpIPSendLimit
+// codes within otpSendWindow, whichever accounts they were for. It is
+// checked before the account is looked up so the answer does not reveal
+// whether the account exists.
+func (s *otpService) allowIP(ip string) error {
+	sent, err := s.otpRepo.CountByIPSince(ip, time.Now().Add(-otpSendWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= otpIPSendLimit {
+		return ErrOTPRateLimited
+	}
+	return nil
+}
+
+// issue generates a new code for the purpose, invalidates older ones and sends it.
+// ip is the requesting client; compose builds the message subject and body
+// from the plain code.
+func (s *otpService) issue(user *domain.User, purpose, channel, ip string, compose func(code string) (string, string)) error {
+	target, err := targetFor(user, channel)
+	if err != nil {
+		return err
+	}
+
+	sent, err := s.otpRepo.CountSince(user.ID, purpose, time.Now().Add(-otpSendWindow))
+	if err != nil {
+		return err
+	}
+	if sent >= otpSendLimit {
+		return ErrOTPRateLimited
+	}
+
+	code, err := helper.GenerateOTP(otpDigits)
+	if err != nil {
+		return err
+	}
+
+	if err := s.otpRepo.InvalidateAll(user.ID, purpose); err != nil {
+		return err
+	}
+
+	otp := &domain.UserOTP{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Channel:   channel,
+		Target:    target,
+		IP:        ip,
+		CodeHash:  helper.HashToken(code),
+		ExpiresAt: time.Now().Add(otpTTL),
+	}
+	if err := s.otpRepo.Create(otp); err != nil {
+		return err
+	}
+
+	subject, body := compose(code)
+	return s.notifier.Send(notifier.Message{
+		Channel: channel,
+		To:      target,
+		Subject: subject,
+		Body:    body,
+	})
+}
+
+// verify consumes the active code for the purpose if it matches.
+func (s *otpService) verify(userID uint, purpose, code string) error {
+	otp, err := s.otpRepo.GetActive(userID, purpose)
+	if err != nil {
+		return err
+	}
+	if otp == nil || code == "" {
+		return ErrInvalidOTP
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.CodeHash), []byte(helper.HashToken(code))) != 1 {
+		otp.Attempts++
+		if otp.Attempts >= otpMaxAttempts {
+			now := time.Now()
+			otp.UsedAt = &now
+		}
+		if err := s.otpRepo.Update(otp); err != nil {
+			return err
+		}
+		return ErrInvalidOTP
+	}
+
+	now := time.Now()
+	otp.UsedAt = &now
+	return s.otpRepo.Update(otp)
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// ErrInvalidCursor indicates a cursor that was tampered with or belongs to
+// another list or sort order.
+var ErrInvalidCursor = repository.ErrInvalidKeyset
+
+// PageCursors are the opaque cursors of the pages after and before a list
+// page; each is empty when there is no such page.
+type PageCursors struct {
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+// decodeKeyset resolves a cursor of the list named by scope; an empty
+// cursor gives nil, the first page.
+func decodeKeyset(scope, cursor string) (*repository.Keyset, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	c, err := helper.DecodeCursor(scope, cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &repository.Keyset{Values: c.Values, Before: c.Before}, nil
+}
+
+// keysetPage trims rows, read with one row more than limit, to the page in
+// list order and reports whether pages follow and precede it. Without a
+// keyset, the rows are the first page.
+func keysetPage[T any](rows []T, limit int, keyset *repository.Keyset) (page []T, hasNext, hasPrev bool) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if keyset == nil {
+		return rows, more, false
+	}
+	if keyset.Before {
+		slices.Reverse(rows)
+		return rows, true, more
+	}
+	return rows, more, true
+}
+
+// pageCursors signs the cursors around a page: after its last row and
+// before its first. keyValues returns the sort key values of a row.
+func pageCursors[T any](scope string, rows []T, hasNext, hasPrev bool, keyValues func(*T) []string) PageCursors {
+	var cursors PageCursors
+	if len(rows) == 0 {
+		return cursors
+	}
+	if hasNext {
+		cursors.NextCursor = helper.EncodeCursor(scope, helper.Cursor{Values: keyValues(&rows[len(rows)-1])})
+	}
+	if hasPrev {
+		cursors.PrevCursor = helper.EncodeCursor(scope, helper.Cursor{Values: keyValues(&rows[0]), Before: true})
+	}
+	return cursors
+}
+
+// fetchKeysetPage reads a page of a list that is returned whole, with page
+// limit 0, unless a limit or cursor is given. fetch reads up to n rows past
+// keyset, all of them when n is 0.
+func fetchKeysetPage[T any](scope string, limit int, cursor string, fetch func(n int, keyset *repository.Keyset) ([]T, error), keyValues func(*T) []string) (rows []T, pageLimit int, cursors PageCursors, err error) {
+	keyset, err := decodeKeyset(scope, cursor)
+	if err != nil {
+		return nil, 0, cursors, err
+	}
+	if limit <= 0 {
+		if keyset == nil {
+			rows, err = fetch(0, nil)
+			return rows, 0, cursors, err
+		}
+		limit = 10
+	}
+
+	if rows, err = fetch(limit+1, keyset); err != nil {
+		return nil, 0, cursors, err
+	}
+	rows, hasNext, hasPrev := keysetPage(rows, limit, keyset)
+	return rows, limit, pageCursors(scope, rows, hasNext, hasPrev, keyValues), nil
+}
+
+// idKey is the key of a list ordered by ID only.
+func idKey(id uint) []string {
+	return []string{strconv.FormatUint(uint64(id), 10)}
+}
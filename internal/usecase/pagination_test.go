@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+func TestKeysetPage(t *testing.T) {
+	after := &repository.Keyset{Values: []string{"3"}}
+	before := &repository.Keyset{Values: []string{"7"}, Before: true}
+
+	tests := []struct {
+		name     string
+		rows     []int
+		limit    int
+		keyset   *repository.Keyset
+		want     []int
+		wantNext bool
+		wantPrev bool
+	}{
+		{"first page, more follow", []int{1, 2, 3, 4}, 3, nil, []int{1, 2, 3}, true, false},
+		{"first page, last one", []int{1, 2}, 3, nil, []int{1, 2}, false, false},
+		{"empty list", nil, 3, nil, nil, false, false},
+		{"after cursor, more follow", []int{4, 5, 6, 7}, 3, after, []int{4, 5, 6}, true, true},
+		{"after cursor, last page", []int{4, 5}, 3, after, []int{4, 5}, false, true},
+		// rows before a cursor are read in reverse order
+		{"before cursor, more precede", []int{6, 5, 4, 3}, 3, before, []int{4, 5, 6}, true, true},
+		{"before cursor, first page", []int{6, 5}, 3, before, []int{5, 6}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := append([]int(nil), tt.rows...)
+			page, hasNext, hasPrev := keysetPage(rows, tt.limit, tt.keyset)
+			if !reflect.DeepEqual(page, tt.want) || hasNext != tt.wantNext || hasPrev != tt.wantPrev {
+				t.Errorf("keysetPage(%v, %d) = %v, %v, %v, want %v, %v, %v",
+					tt.rows, tt.limit, page, hasNext, hasPrev, tt.want, tt.wantNext, tt.wantPrev)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	// defaultPriceSeriesDays is the length of a price series without a start date.
+	defaultPriceSeriesDays = 30
+	// maxPriceSeriesDays caps the length of a price series.
+	maxPriceSeriesDays = 366
+)
+
+// ErrInvalidDateRange indicates a price series that ends before it starts
+// or spans more than maxPriceSeriesDays.
+var ErrInvalidDateRange = errors.New("rentang tanggal tidak valid, maksimal 366 hari")
+
+// ProductHistoryResult is a page of a product's versions, newest first.
+type ProductHistoryResult struct {
+	Limit int                    `json:"limit"`
+	Data  []domain.ProdukHistory `json:"data"`
+	PageCursors
+}
+
+// DailyPrice is a product's prices and stock at the end of one day, with
+// the lowest and highest consumer price it had during the day.
+type DailyPrice struct {
+	Date             time.Time
+	HargaReseller    int
+	HargaKonsumen    int
+	MinHargaKonsumen int
+	MaxHargaKonsumen int
+	Stok             int
+}
+
+// GetHistory lists the versions of a product's name, prices and stock.
+// Members of the product's toko may read it; so may moderators, for any
+// product.
+func (uc *productUsecase) GetHistory(actor Actor, productID uint, moderator bool, limit int, cursor string) (*ProductHistoryResult, error) {
+	product, err := uc.historyProduct(actor, productID, moderator)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := "product_history:" + strconv.FormatUint(uint64(product.ID), 10)
+	versions, pageLimit, cursors, err := fetchKeysetPage(scope, limit, cursor, func(n int, keyset *repository.Keyset) ([]domain.ProdukHistory, error) {
+		return uc.historyRepo.GetByProduct(product.ID, n, keyset)
+	}, func(v *domain.ProdukHistory) []string {
+		return []string{strconv.Itoa(v.Version)}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ProductHistoryResult{Limit: pageLimit, Data: versions, PageCursors: cursors}, nil
+}
+
+// GetPriceSeries returns one DailyPrice per day from from through to, both
+// dates in local time, for charts. A zero to is today and a zero from is
+// defaultPriceSeriesDays before to. Days before the product's first
+// version are left out.
+func (uc *productUsecase) GetPriceSeries(actor Actor, productID uint, moderator bool, from, to time.Time) ([]DailyPrice, error) {
+	product, err := uc.historyProduct(actor, productID, moderator)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	to = startOfDay(to)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, 1-defaultPriceSeriesDays)
+	}
+	from = startOfDay(from)
+	if to.Before(from) || to.After(from.AddDate(0, 0, maxPriceSeriesDays-1)) {
+		return nil, ErrInvalidDateRange
+	}
+
+	versions, err := uc.historyRepo.GetBetween(product.ID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return dailyPrices(versions, from, to), nil
+}
+
+// BackfillHistory gives products changed before history was kept their
+// current state as first version.
+func (uc *productUsecase) BackfillHistory() (int64, error) {
+	return uc.historyRepo.Backfill()
+}
+
+// historyProduct returns the product whose history actor asks for.
+func (uc *productUsecase) historyProduct(actor Actor, productID uint, moderator bool) (*domain.Produk, error) {
+	if !moderator {
+		return uc.getManagedProduct(actor, productID)
+	}
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// dailyPrices folds versions, oldest first and starting with the one in
+// effect at from if any, into a DailyPrice for each day from from through to.
+func dailyPrices(versions []domain.ProdukHistory, from, to time.Time) []DailyPrice {
+	var (
+		series  []DailyPrice
+		current *domain.ProdukHistory
+	)
+	next := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		point := DailyPrice{Date: day}
+		if current != nil {
+			point.MinHargaKonsumen = current.HargaKonsumen
+			point.MaxHargaKonsumen = current.HargaKonsumen
+		}
+		for ; next < len(versions) && versions[next].CreatedAt.Before(end); next++ {
+			if current == nil {
+				point.MinHargaKonsumen = versions[next].HargaKonsumen
+				point.MaxHargaKonsumen = versions[next].HargaKonsumen
+			}
+			current = &versions[next]
+			point.MinHargaKonsumen = min(point.MinHargaKonsumen, current.HargaKonsumen)
+			point.MaxHargaKonsumen = max(point.MaxHargaKonsumen, current.HargaKonsumen)
+		}
+		if current == nil {
+			continue
+		}
+		point.HargaReseller = current.HargaReseller
+		point.HargaKonsumen = current.HargaKonsumen
+		point.Stok = current.Stok
+		series = append(series, point)
+	}
+	return series
+}
+
+// startOfDay is midnight, local time, of t's day.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.In(time.Local).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
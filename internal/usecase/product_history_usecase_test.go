@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func TestDailyPrices(t *testing.T) {
+	day := func(d, h int) time.Time {
+		return time.Date(2024, time.March, d, h, 0, 0, 0, time.Local)
+	}
+	version := func(at time.Time, reseller, konsumen, stok int) domain.ProdukHistory {
+		return domain.ProdukHistory{CreatedAt: at, HargaReseller: reseller, HargaKonsumen: konsumen, Stok: stok}
+	}
+
+	tests := []struct {
+		name     string
+		versions []domain.ProdukHistory
+		from, to time.Time
+		want     []DailyPrice
+	}{
+		{
+			name:     "no versions",
+			versions: nil,
+			from:     day(1, 0),
+			to:       day(3, 0),
+			want:     nil,
+		},
+		{
+			name:     "days before the first version are skipped",
+			versions: []domain.ProdukHistory{version(day(2, 10), 8000, 10000, 5)},
+			from:     day(1, 0),
+			to:       day(3, 0),
+			want: []DailyPrice{
+				{Date: day(2, 0), HargaReseller: 8000, HargaKonsumen: 10000, MinHargaKonsumen: 10000, MaxHargaKonsumen: 10000, Stok: 5},
+				{Date: day(3, 0), HargaReseller: 8000, HargaKonsumen: 10000, MinHargaKonsumen: 10000, MaxHargaKonsumen: 10000, Stok: 5},
+			},
+		},
+		{
+			name: "version in effect at from carries over",
+			versions: []domain.ProdukHistory{
+				version(day(1, 0).AddDate(0, 0, -10), 7000, 9000, 3),
+			},
+			from: day(1, 0),
+			to:   day(1, 0),
+			want: []DailyPrice{
+				{Date: day(1, 0), HargaReseller: 7000, HargaKonsumen: 9000, MinHargaKonsumen: 9000, MaxHargaKonsumen: 9000, Stok: 3},
+			},
+		},
+		{
+			name: "several changes in a day give its range and closing price",
+			versions: []domain.ProdukHistory{
+				version(day(1, 9), 8000, 10000, 5),
+				version(day(2, 9), 8000, 12000, 4),
+				version(day(2, 12), 7000, 8000, 4),
+				version(day(2, 18), 8000, 11000, 2),
+			},
+			from: day(1, 0),
+			to:   day(3, 0),
+			want: []DailyPrice{
+				{Date: day(1, 0), HargaReseller: 8000, HargaKonsumen: 10000, MinHargaKonsumen: 10000, MaxHargaKonsumen: 10000, Stok: 5},
+				{Date: day(2, 0), HargaReseller: 8000, HargaKonsumen: 11000, MinHargaKonsumen: 8000, MaxHargaKonsumen: 12000, Stok: 2},
+				{Date: day(3, 0), HargaReseller: 8000, HargaKonsumen: 11000, MinHargaKonsumen: 11000, MaxHargaKonsumen: 11000, Stok: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dailyPrices(tt.versions, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dailyPrices =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,633 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	// importLease is how long a worker owns a running job without saving
+	// progress before another instance may take it over.
+	importLease = 2 * time.Minute
+	// maxImportImageBytes caps one image taken from an import zip.
+	maxImportImageBytes = 5 << 20
+	// exportPageSize is how many products an export reads at a time.
+	exportPageSize = 100
+	// maxImportPhotoURL is the longest photo URL foto_produk can store.
+	maxImportPhotoURL = 255
+)
+
+// importColumns are the columns of an import or export file. An import
+// needs every column except deskripsi and photos; photos are separated by
+// "|" and are either http(s) URLs or names of files in the images zip.
+// Exports add a leading id column; an imported row with an id updates that
+// product of the toko instead of creating a new one, so an export can be
+// edited and imported again.
+var importColumns = []string{"nama_produk", "category_id", "harga_reseller", "harga_konsumen", "stok", "deskripsi", "photos"}
+
+var importRequiredColumns = []string{"nama_produk", "category_id", "harga_reseller", "harga_konsumen", "stok"}
+
+// ImageStore saves images that did not arrive as multipart uploads and
+// returns their stored filenames.
+type ImageStore interface {
+	SaveImage(name string, data []byte) (string, error)
+}
+
+// ImportFile is an uploaded import file or images zip.
+type ImportFile struct {
+	Filename string
+	Data     []byte
+}
+
+// ExportFile is a generated product export.
+type ExportFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// ProductImportUsecase defines bulk product import and export.
+type ProductImportUsecase interface {
+	Submit(actor Actor, tokoID uint, file ImportFile, images *ImportFile) (*domain.ImportJob, error)
+	GetJob(actor Actor, id uint) (*domain.ImportJob, error)
+	ErrorReport(actor Actor, id uint) ([]byte, error)
+	Export(actor Actor, tokoID uint, format string) (*ExportFile, error)
+	ProcessDue() (int, error)
+}
+
+type productImportUsecase struct {
+	jobRepo     repository.ImportJobRepository
+	productUC   ProductUsecase
+	productRepo repository.ProductRepository
+	access      *tokoAccess
+	images      ImageStore
+	maxRows     int
+}
+
+// NewProductImportUsecase creates a new ProductImportUsecase. Rows are
+// created and updated through productUC so they get the same validation,
+// auditing and events as single products; maxRows caps the rows of one
+// file.
+func NewProductImportUsecase(jobRepo repository.ImportJobRepository, productUC ProductUsecase, productRepo repository.ProductRepository, memberRepo repository.TokoMemberRepository, images ImageStore, maxRows int) ProductImportUsecase {
+	return &productImportUsecase{
+		jobRepo:     jobRepo,
+		productUC:   productUC,
+		productRepo: productRepo,
+		access:      newTokoAccess(memberRepo),
+		images:      images,
+		maxRows:     maxRows,
+	}
+}
+
+var (
+	// ErrImportJobNotFound indicates the import job does not exist or belongs to another toko.
+	ErrImportJobNotFound = errors.New("import job not found")
+	// ErrInvalidImportFormat indicates a file that is neither CSV nor XLSX.
+	ErrInvalidImportFormat = errors.New("format file harus csv atau xlsx")
+	// ErrInvalidImportFile indicates an import file or images zip that cannot be read.
+	ErrInvalidImportFile = errors.New("file import tidak valid")
+	// ErrImportTooManyRows indicates an import file over the row limit.
+	ErrImportTooManyRows = errors.New("jumlah baris import melebihi batas")
+)
+
+// importRow is one non-blank data row and its line in the file.
+type importRow struct {
+	line  int
+	cells []string
+}
+
+func (uc *productImportUsecase) Submit(actor Actor, tokoID uint, file ImportFile, images *ImportFile) (*domain.ImportJob, error) {
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	format := importFormat(file.Filename)
+	if format == "" {
+		return nil, ErrInvalidImportFormat
+	}
+	_, rows, err := readImportFile(format, file.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: tidak ada baris produk", ErrInvalidImportFile)
+	}
+	if uc.maxRows > 0 && len(rows) > uc.maxRows {
+		return nil, fmt.Errorf("%w (maksimal %d)", ErrImportTooManyRows, uc.maxRows)
+	}
+
+	job := &domain.ImportJob{
+		TokoID:     member.TokoID,
+		UserID:     actor.UserID,
+		ActorRoles: strings.Join(actor.Roles, ","),
+		IP:         actor.IP,
+		RequestID:  actor.RequestID,
+		Format:     format,
+		Filename:   filepath.Base(file.Filename),
+		File:       file.Data,
+		Status:     domain.ImportStatusPending,
+		TotalRows:  len(rows),
+	}
+	if actor.APIKeyID != 0 {
+		apiKeyID := actor.APIKeyID
+		job.APIKeyID = &apiKeyID
+	}
+	if images != nil && len(images.Data) > 0 {
+		if _, err := zip.NewReader(bytes.NewReader(images.Data), int64(len(images.Data))); err != nil {
+			return nil, fmt.Errorf("%w: zip gambar tidak dapat dibaca", ErrInvalidImportFile)
+		}
+		job.Images = images.Data
+	}
+
+	if err := uc.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+	job.File, job.Images = nil, nil
+	return job, nil
+}
+
+func (uc *productImportUsecase) GetJob(actor Actor, id uint) (*domain.ImportJob, error) {
+	job, err := uc.jobRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrImportJobNotFound
+	}
+	if actor.TokoID != 0 && actor.TokoID != job.TokoID {
+		return nil, ErrImportJobNotFound
+	}
+	if _, err := uc.access.resolve(actor.UserID, job.TokoID, domain.TokoCapProducts); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ErrorReport returns the job's failed rows as CSV.
+func (uc *productImportUsecase) ErrorReport(actor Actor, id uint) ([]byte, error) {
+	job, err := uc.GetJob(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	rowErrors, err := uc.jobRepo.GetErrors(job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"line", "nama_produk", "message"})
+	for _, e := range rowErrors {
+		_ = w.Write([]string{strconv.Itoa(e.Line), e.NamaProduk, e.Message})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Export returns every product of the toko in the import columns, so the
+// file can be edited and imported again.
+func (uc *productImportUsecase) Export(actor Actor, tokoID uint, format string) (*ExportFile, error) {
+	if format == "" {
+		format = domain.ImportFormatCSV
+	}
+	if format != domain.ImportFormatCSV && format != domain.ImportFormatXLSX {
+		return nil, ErrInvalidImportFormat
+	}
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	records := [][]string{append([]string{"id"}, importColumns...)}
+	for page := 1; ; page++ {
+		// the toko's whole catalogue, unpublished and archived products too
+		products, err := uc.productRepo.GetAll(exportPageSize, page, ProductFilter{TokoID: member.TokoID, IncludeArchived: true, Statuses: domain.ProductStatuses})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			photos := make([]string, 0, len(p.FotoProduk))
+			for _, photo := range p.FotoProduk {
+				photos = append(photos, photo.URL)
+			}
+			records = append(records, []string{
+				strconv.FormatUint(uint64(p.ID), 10),
+				p.NamaProduk,
+				strconv.FormatUint(uint64(p.CategoryID), 10),
+				p.HargaReseller,
+				p.HargaKonsumen,
+				strconv.Itoa(p.Stok),
+				p.Deskripsi,
+				strings.Join(photos, "|"),
+			})
+		}
+		if len(products) < exportPageSize {
+			break
+		}
+	}
+
+	filename := fmt.Sprintf("produk-toko-%d.%s", member.TokoID, format)
+	if format == domain.ImportFormatXLSX {
+		data, err := writeXLSX(records)
+		if err != nil {
+			return nil, err
+		}
+		return &ExportFile{
+			Filename:    filename,
+			ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			Data:        data,
+		}, nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return &ExportFile{Filename: filename, ContentType: "text/csv", Data: buf.Bytes()}, nil
+}
+
+// ProcessDue runs pending jobs, and running jobs whose worker stopped
+// renewing its lease, until none are left. It returns how many jobs it
+// finished.
+func (uc *productImportUsecase) ProcessDue() (int, error) {
+	finished := 0
+	for {
+		now := time.Now()
+		job, err := uc.jobRepo.GetClaimable(now)
+		if err != nil {
+			return finished, err
+		}
+		if job == nil {
+			return finished, nil
+		}
+		claimed, err := uc.jobRepo.Claim(job, now, now.Add(importLease))
+		if err != nil {
+			return finished, err
+		}
+		if !claimed {
+			// Another instance took it first
+			continue
+		}
+		if err := uc.process(job); err != nil {
+			return finished, err
+		}
+		finished++
+	}
+}
+
+// process imports the job's rows from ProcessedRows on. A row that fails
+// is recorded in the error report and does not stop the job. A crash after
+// a product is created but before progress is saved imports that row again
+// when the job is taken over.
+func (uc *productImportUsecase) process(job *domain.ImportJob) error {
+	file, images, err := uc.jobRepo.GetFiles(job.ID)
+	if err != nil {
+		return err
+	}
+
+	header, rows, err := readImportFile(job.Format, file)
+	if err != nil {
+		return uc.finish(job, err)
+	}
+	var zipFiles map[string]*zip.File
+	if len(images) > 0 {
+		if zipFiles, err = readImageZip(images); err != nil {
+			return uc.finish(job, err)
+		}
+	}
+	job.TotalRows = len(rows)
+
+	actor := Actor{
+		UserID:    job.UserID,
+		IP:        job.IP,
+		RequestID: job.RequestID,
+	}
+	if job.ActorRoles != "" {
+		actor.Roles = strings.Split(job.ActorRoles, ",")
+	}
+	if job.APIKeyID != nil {
+		actor.APIKeyID = *job.APIKeyID
+	}
+
+	for i := job.ProcessedRows; i < len(rows); i++ {
+		row := rows[i]
+		var rowError *domain.ImportJobError
+		if err := uc.importRow(actor, job.TokoID, header, row, zipFiles); err != nil {
+			rowError = &domain.ImportJobError{
+				JobID:      job.ID,
+				Line:       row.line,
+				NamaProduk: truncate(importCell(header, row, "nama_produk"), 255),
+				Message:    truncate(err.Error(), 500),
+			}
+			job.FailedRows++
+		} else {
+			job.SuccessRows++
+		}
+		job.ProcessedRows++
+		if err := uc.jobRepo.SaveProgress(job, rowError, time.Now().Add(importLease)); err != nil {
+			return err
+		}
+	}
+
+	return uc.finish(job, nil)
+}
+
+// finish marks the job completed, or failed with cause when its files
+// could not be read.
+func (uc *productImportUsecase) finish(job *domain.ImportJob, cause error) error {
+	now := time.Now()
+	job.Status = domain.ImportStatusCompleted
+	if cause != nil {
+		job.Status = domain.ImportStatusFailed
+		job.LastError = truncate(cause.Error(), 500)
+	}
+	job.FinishedAt = &now
+	return uc.jobRepo.Finish(job)
+}
+
+// importRow creates the product of one row, or updates it when the row
+// has an id.
+func (uc *productImportUsecase) importRow(actor Actor, tokoID uint, header map[string]int, row importRow, zipFiles map[string]*zip.File) error {
+	var existing *domain.Produk
+	if v := importCell(header, row, "id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return errors.New("invalid id")
+		}
+		if existing, err = uc.productRepo.GetByIDForToko(tokoID, uint(id)); err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("produk %d tidak ditemukan di toko ini", id)
+		}
+	}
+
+	in := CreateProductInput{
+		TokoID:     tokoID,
+		NamaProduk: importCell(header, row, "nama_produk"),
+		Deskripsi:  importCell(header, row, "deskripsi"),
+	}
+	for _, field := range []struct {
+		column string
+		dst    *int
+	}{
+		{"harga_reseller", &in.HargaReseller},
+		{"harga_konsumen", &in.HargaKonsumen},
+		{"stok", &in.Stok},
+	} {
+		v, err := strconv.Atoi(importCell(header, row, field.column))
+		if err != nil {
+			return fmt.Errorf("invalid %s", field.column)
+		}
+		*field.dst = v
+	}
+	categoryID, err := strconv.ParseUint(importCell(header, row, "category_id"), 10, 32)
+	if err != nil {
+		return errors.New("invalid category_id")
+	}
+	in.CategoryID = uint(categoryID)
+
+	// Validate before saving images so failed rows leave no files behind
+	if err := validateCreateProduct(in); err != nil {
+		return err
+	}
+
+	// An updated product keeps the photos it has, as exported
+	current := make(map[string]bool)
+	if existing != nil {
+		for _, photo := range existing.FotoProduk {
+			current[photo.URL] = true
+		}
+	}
+	var photos []string
+	for _, name := range strings.Split(importCell(header, row, "photos"), "|") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if current[name] {
+			photos = append(photos, name)
+			continue
+		}
+		if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+			if len(name) > maxImportPhotoURL {
+				return fmt.Errorf("url foto terlalu panjang: %s", truncate(name, 50))
+			}
+			photos = append(photos, name)
+			continue
+		}
+		filename, err := uc.saveZipImage(zipFiles, name)
+		if err != nil {
+			return err
+		}
+		photos = append(photos, filename)
+	}
+
+	if existing == nil {
+		_, err = uc.productUC.Create(actor, in, photos)
+		return err
+	}
+
+	// Photos are only replaced when the row lists other ones
+	if samePhotos(existing.FotoProduk, photos) {
+		photos = nil
+	}
+	_, err = uc.productUC.Update(actor, existing.ID, UpdateProductInput{
+		NamaProduk:    &in.NamaProduk,
+		CategoryID:    &in.CategoryID,
+		HargaReseller: &in.HargaReseller,
+		HargaKonsumen: &in.HargaKonsumen,
+		Stok:          &in.Stok,
+		Deskripsi:     &in.Deskripsi,
+	}, photos)
+	return err
+}
+
+// samePhotos reports whether names lists the URLs of photos, in order.
+func samePhotos(photos []domain.FotoProduk, names []string) bool {
+	if len(photos) != len(names) {
+		return false
+	}
+	for i := range photos {
+		if photos[i].URL != names[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// saveZipImage stores the image called name from the images zip.
+func (uc *productImportUsecase) saveZipImage(zipFiles map[string]*zip.File, name string) (string, error) {
+	f, ok := zipFiles[path.Base(filepath.ToSlash(name))]
+	if !ok {
+		return "", fmt.Errorf("foto %s tidak ditemukan di zip", name)
+	}
+	if f.UncompressedSize64 > maxImportImageBytes {
+		return "", fmt.Errorf("foto %s melebihi %d MB", name, maxImportImageBytes>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("foto %s tidak dapat dibaca", name)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxImportImageBytes+1))
+	if err != nil || len(data) > maxImportImageBytes {
+		return "", fmt.Errorf("foto %s tidak dapat dibaca", name)
+	}
+	return uc.images.SaveImage(path.Base(f.Name), data)
+}
+
+// importFormat returns the file format for filename's extension, or "".
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return domain.ImportFormatCSV
+	case ".xlsx":
+		return domain.ImportFormatXLSX
+	}
+	return ""
+}
+
+// readImportFile parses an import file into its header (column name to
+// index) and non-blank data rows.
+func readImportFile(format string, data []byte) (map[string]int, []importRow, error) {
+	var records [][]string
+	switch format {
+	case domain.ImportFormatCSV:
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		var err error
+		if records, err = r.ReadAll(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+	case domain.ImportFormatXLSX:
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil, fmt.Errorf("%w: tidak ada sheet", ErrInvalidImportFile)
+		}
+		if records, err = f.GetRows(sheets[0]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+	default:
+		return nil, nil, ErrInvalidImportFormat
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%w: header tidak ditemukan", ErrInvalidImportFile)
+	}
+
+	header := make(map[string]int)
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := header[name]; name != "" && !dup {
+			header[name] = i
+		}
+	}
+	for _, column := range importRequiredColumns {
+		if _, ok := header[column]; !ok {
+			return nil, nil, fmt.Errorf("%w: kolom %s wajib ada", ErrInvalidImportFile, column)
+		}
+	}
+
+	var rows []importRow
+	for i, cells := range records[1:] {
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue
+		}
+		rows = append(rows, importRow{line: i + 2, cells: cells})
+	}
+	return header, rows, nil
+}
+
+// readImageZip indexes the files of an images zip by base name.
+func readImageZip(data []byte) (map[string]*zip.File, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: zip gambar tidak dapat dibaca", ErrInvalidImportFile)
+	}
+	files := make(map[string]*zip.File, len(r.File))
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		files[path.Base(f.Name)] = f
+	}
+	return files, nil
+}
+
+// importCell returns the trimmed value of column in row, or "" when the
+// row is shorter than the header.
+func importCell(header map[string]int, row importRow, column string) string {
+	i, ok := header[column]
+	if !ok || i >= len(row.cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.cells[i])
+}
+
+// writeXLSX writes records to the first sheet of a new workbook.
+func writeXLSX(records [][]string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	for i, record := range records {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(record))
+		for j, v := range record {
+			values[j] = v
+		}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return nil, err
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truncate shortens s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
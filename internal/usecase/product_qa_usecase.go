@@ -0,0 +1,386 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const qaMaxBody = 1000
+
+// QuestionListResult wraps a paginated list of a product's questions.
+type QuestionListResult struct {
+	Page  int
+	Limit int
+	Data  []domain.ProductQuestion
+}
+
+// ReportedQAResult wraps a paginated list of reported questions or
+// answers, depending on TargetType.
+type ReportedQAResult struct {
+	Page       int
+	Limit      int
+	TargetType string
+	Questions  []domain.ProductQuestion
+	Answers    []domain.ProductAnswer
+}
+
+// ProductQAUsecase defines product Q&A business logic.
+type ProductQAUsecase interface {
+	GetQuestions(actor Actor, productID uint, limit, page int) (*QuestionListResult, error)
+	Ask(actor Actor, productID uint, body string) (*domain.ProductQuestion, error)
+	Answer(actor Actor, questionID uint, body string) (*domain.ProductAnswer, error)
+	Upvote(actor Actor, targetType string, id uint) (int, error)
+	RemoveUpvote(actor Actor, targetType string, id uint) (int, error)
+	Report(actor Actor, targetType string, id uint, reason string) error
+	Hide(actor Actor, targetType string, id uint, hidden bool) error
+	Moderate(actor Actor, targetType string, id uint, hidden bool) error
+	GetReported(targetType string, limit, page int) (*ReportedQAResult, error)
+}
+
+type productQAUsecase struct {
+	qaRepo        repository.ProductQARepository
+	productRepo   repository.ProductRepository
+	trxRepo       repository.TrxRepository
+	memberRepo    repository.TokoMemberRepository
+	access        *tokoAccess
+	audit         *auditor
+	hideThreshold int
+}
+
+// NewProductQAUsecase creates a new ProductQAUsecase. Questions and
+// answers are hidden automatically once hideThreshold users report them;
+// 0 leaves hiding to moderators.
+func NewProductQAUsecase(qaRepo repository.ProductQARepository, productRepo repository.ProductRepository, trxRepo repository.TrxRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository, hideThreshold int) ProductQAUsecase {
+	return &productQAUsecase{
+		qaRepo:        qaRepo,
+		productRepo:   productRepo,
+		trxRepo:       trxRepo,
+		memberRepo:    memberRepo,
+		access:        newTokoAccess(memberRepo),
+		audit:         newAuditor(auditRepo),
+		hideThreshold: hideThreshold,
+	}
+}
+
+var (
+	// ErrQuestionNotFound indicates the question does not exist or is hidden.
+	ErrQuestionNotFound = errors.New("question not found")
+	// ErrAnswerNotFound indicates the answer does not exist or is hidden.
+	ErrAnswerNotFound = errors.New("answer not found")
+	// ErrAnswerNotAllowed indicates the user is neither from the toko nor a buyer of the product.
+	ErrAnswerNotAllowed = errors.New("hanya toko atau pembeli produk ini yang bisa menjawab")
+	// ErrInvalidQATarget indicates a target type other than question or answer.
+	ErrInvalidQATarget = errors.New("target harus question atau answer")
+	// ErrInvalidQABody indicates an empty or too long question or answer.
+	ErrInvalidQABody = errors.New("isi wajib diisi (maksimal 1000 karakter)")
+)
+
+// GetQuestions lists the visible questions of a published product; members
+// of its toko may list them whatever the product's status.
+func (uc *productQAUsecase) GetQuestions(actor Actor, productID uint, limit, page int) (*QuestionListResult, error) {
+	product, err := uc.visibleProduct(actor, productID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := uc.qaRepo.GetVisibleByProduct(product.ID, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	return &QuestionListResult{
+		Page:  page,
+		Limit: limit,
+		Data:  questions,
+	}, nil
+}
+
+// Ask posts a public question on a product that is published and on
+// sale; members of its toko may post on any of its products.
+func (uc *productQAUsecase) Ask(actor Actor, productID uint, body string) (*domain.ProductQuestion, error) {
+	body, err := qaBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := uc.visibleProduct(actor, productID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &domain.ProductQuestion{
+		ProdukID: product.ID,
+		TokoID:   product.TokoID,
+		UserID:   actor.UserID,
+		Body:     body,
+	}
+	if err := uc.qaRepo.CreateQuestion(question); err != nil {
+		return nil, err
+	}
+	return uc.getQuestion(question.ID, false)
+}
+
+// visibleProduct loads a product whose questions actor may see, see
+// tokoAccess.canSee. Other products are reported as not found.
+func (uc *productQAUsecase) visibleProduct(actor Actor, productID uint, onSale bool) (*domain.Produk, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	visible, err := uc.access.canSee(actor, product, onSale)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+// Answer posts an answer to a visible question. Members of the owning toko
+// answer as the toko; other users must have bought the product.
+func (uc *productQAUsecase) Answer(actor Actor, questionID uint, body string) (*domain.ProductAnswer, error) {
+	body, err := qaBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	question, err := uc.getQuestion(questionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	answeredAs := domain.AnsweredAsToko
+	member, err := uc.memberRepo.GetByTokoAndUser(question.TokoID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		purchased, err := uc.trxRepo.HasPurchased(actor.UserID, question.ProdukID)
+		if err != nil {
+			return nil, err
+		}
+		if !purchased {
+			return nil, ErrAnswerNotAllowed
+		}
+		answeredAs = domain.AnsweredAsBuyer
+	}
+
+	answer := &domain.ProductAnswer{
+		QuestionID: question.ID,
+		UserID:     actor.UserID,
+		AnsweredAs: answeredAs,
+		Body:       body,
+	}
+	if err := uc.qaRepo.CreateAnswer(answer); err != nil {
+		return nil, err
+	}
+	return uc.getAnswer(answer.ID, false)
+}
+
+// Upvote adds the user's upvote to a visible question or answer and
+// returns its upvote count. Upvoting twice counts once.
+func (uc *productQAUsecase) Upvote(actor Actor, targetType string, id uint) (int, error) {
+	if _, err := uc.target(targetType, id, false); err != nil {
+		return 0, err
+	}
+	if _, err := uc.qaRepo.AddVote(&domain.QAVote{UserID: actor.UserID, TargetType: targetType, TargetID: id}); err != nil {
+		return 0, err
+	}
+	t, err := uc.target(targetType, id, true)
+	if err != nil {
+		return 0, err
+	}
+	return t.upvotes, nil
+}
+
+// RemoveUpvote withdraws the user's upvote and returns the upvote count.
+func (uc *productQAUsecase) RemoveUpvote(actor Actor, targetType string, id uint) (int, error) {
+	if _, err := uc.target(targetType, id, false); err != nil {
+		return 0, err
+	}
+	if _, err := uc.qaRepo.RemoveVote(actor.UserID, targetType, id); err != nil {
+		return 0, err
+	}
+	t, err := uc.target(targetType, id, true)
+	if err != nil {
+		return 0, err
+	}
+	return t.upvotes, nil
+}
+
+// Report flags a visible question or answer for moderation. Each user's
+// report counts once.
+func (uc *productQAUsecase) Report(actor Actor, targetType string, id uint, reason string) error {
+	if _, err := uc.target(targetType, id, false); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > 255 {
+		reason = string([]rune(reason)[:255])
+	}
+	_, err := uc.qaRepo.AddReport(&domain.QAReport{
+		UserID:     actor.UserID,
+		TargetType: targetType,
+		TargetID:   id,
+		Reason:     reason,
+	}, uc.hideThreshold, time.Now())
+	return err
+}
+
+// Hide hides or shows a question or answer on one of the toko's products.
+func (uc *productQAUsecase) Hide(actor Actor, targetType string, id uint, hidden bool) error {
+	t, err := uc.target(targetType, id, true)
+	if err != nil {
+		return err
+	}
+	if _, err := uc.access.resolve(actor.UserID, t.tokoID, domain.TokoCapProducts); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return ErrTokoAccessDenied
+		}
+		return err
+	}
+	return uc.setHidden(actor, t, hidden)
+}
+
+// Moderate hides or shows any question or answer. Callers must be staff
+// allowed to moderate products.
+func (uc *productQAUsecase) Moderate(actor Actor, targetType string, id uint, hidden bool) error {
+	t, err := uc.target(targetType, id, true)
+	if err != nil {
+		return err
+	}
+	return uc.setHidden(actor, t, hidden)
+}
+
+func (uc *productQAUsecase) GetReported(targetType string, limit, page int) (*ReportedQAResult, error) {
+	if targetType != domain.QATargetQuestion && targetType != domain.QATargetAnswer {
+		return nil, ErrInvalidQATarget
+	}
+	questions, answers, err := uc.qaRepo.GetReported(targetType, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	return &ReportedQAResult{
+		Page:       page,
+		Limit:      limit,
+		TargetType: targetType,
+		Questions:  questions,
+		Answers:    answers,
+	}, nil
+}
+
+func (uc *productQAUsecase) setHidden(actor Actor, t *qaTarget, hidden bool) error {
+	if hidden == (t.hiddenAt != nil) {
+		return nil
+	}
+	var hiddenAt *time.Time
+	if hidden {
+		now := time.Now()
+		hiddenAt = &now
+	}
+	if err := uc.qaRepo.SetHidden(t.targetType, t.id, hiddenAt); err != nil {
+		return err
+	}
+
+	entity := AuditEntityQuestion
+	if t.targetType == domain.QATargetAnswer {
+		entity = AuditEntityAnswer
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, entity, t.id,
+		map[string]interface{}{"HiddenAt": t.hiddenAt},
+		map[string]interface{}{"HiddenAt": hiddenAt})
+	return nil
+}
+
+// qaTarget is the part of a question or answer that votes, reports and
+// moderation need.
+type qaTarget struct {
+	targetType string
+	id         uint
+	tokoID     uint
+	upvotes    int
+	hiddenAt   *time.Time
+}
+
+// target loads a question or answer. Hidden ones, and answers to hidden
+// questions, are only returned when includeHidden is set.
+func (uc *productQAUsecase) target(targetType string, id uint, includeHidden bool) (*qaTarget, error) {
+	switch targetType {
+	case domain.QATargetQuestion:
+		q, err := uc.getQuestion(id, includeHidden)
+		if err != nil {
+			return nil, err
+		}
+		return &qaTarget{targetType: targetType, id: q.ID, tokoID: q.TokoID, upvotes: q.Upvotes, hiddenAt: q.HiddenAt}, nil
+	case domain.QATargetAnswer:
+		a, err := uc.getAnswer(id, includeHidden)
+		if err != nil {
+			return nil, err
+		}
+		q, err := uc.getQuestion(a.QuestionID, includeHidden)
+		if err != nil {
+			if errors.Is(err, ErrQuestionNotFound) {
+				return nil, ErrAnswerNotFound
+			}
+			return nil, err
+		}
+		return &qaTarget{targetType: targetType, id: a.ID, tokoID: q.TokoID, upvotes: a.Upvotes, hiddenAt: a.HiddenAt}, nil
+	default:
+		return nil, ErrInvalidQATarget
+	}
+}
+
+func (uc *productQAUsecase) getQuestion(id uint, includeHidden bool) (*domain.ProductQuestion, error) {
+	question, err := uc.qaRepo.GetQuestionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if question == nil || (question.HiddenAt != nil && !includeHidden) {
+		return nil, ErrQuestionNotFound
+	}
+	return question, nil
+}
+
+func (uc *productQAUsecase) getAnswer(id uint, includeHidden bool) (*domain.ProductAnswer, error) {
+	answer, err := uc.qaRepo.GetAnswerByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if answer == nil || (answer.HiddenAt != nil && !includeHidden) {
+		return nil, ErrAnswerNotFound
+	}
+	return answer, nil
+}
+
+// qaBody trims a question or answer and checks its length.
+func qaBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > qaMaxBody {
+		return "", ErrInvalidQABody
+	}
+	return body, nil
+}
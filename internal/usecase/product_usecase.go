@@ -4,29 +4,77 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/search"
 )
 
 // Re-export ProductFilter so delivery layer can use it without depending on repository.
 type ProductFilter = repository.ProductFilter
 
+// ProductFacets re-exports the facet counts of a product listing.
+type ProductFacets = repository.ProductFacets
+
+// Product sort orders, see the repository for each.
+const (
+	ProductSortRating      = repository.ProductSortRating
+	ProductSortPriceAsc    = repository.ProductSortPriceAsc
+	ProductSortPriceDesc   = repository.ProductSortPriceDesc
+	ProductSortNewest      = repository.ProductSortNewest
+	ProductSortBestSelling = repository.ProductSortBestSelling
+)
+
+// ProductSorts lists the valid ProductFilter.Sort values.
+var ProductSorts = repository.ProductSorts
+
 // ProductListResult wraps paginated product list.
 type ProductListResult struct {
 	Page  int             `json:"page"`
 	Limit int             `json:"limit"`
 	Data  []domain.Produk `json:"data"`
+	// Total is how many products match the filter across all pages.
+	Total int64 `json:"total"`
+	// TotalApproximate is set when a search matched more products than
+	// maxSearchMatches; Total and Facets then only cover the best of them.
+	TotalApproximate bool           `json:"total_approximate,omitempty"`
+	Facets           *ProductFacets `json:"facets"`
+	PageCursors
+	// Highlights maps product ID to its fields with the words matching a
+	// search query marked; only set for searches.
+	Highlights map[uint]map[string]string `json:"highlights,omitempty"`
 }
 
+const (
+	// maxSearchHits caps how many ranked products a search query pages
+	// through; the total and facets count further matches.
+	maxSearchHits = 1000
+	// maxSearchMatches caps how many search matches the total and facets
+	// count, which keeps their ID list bounded.
+	maxSearchMatches = 10000
+	// highlightSnippetLength is the length of a highlighted description snippet.
+	highlightSnippetLength = 160
+	// searchRebuildPageSize is how many products a search index rebuild reads at a time.
+	searchRebuildPageSize = 100
+)
+
 // CreateProductInput represents required fields to create a product.
+// TokoID is optional; by default the user's own toko is used. Status is
+// ProductStatusDraft to keep the product unpublished, or empty to publish
+// it, at PublishAt when set.
 type CreateProductInput struct {
+	TokoID        uint
 	NamaProduk    string
 	CategoryID    uint
 	HargaReseller int
 	HargaKonsumen int
 	Stok          int
 	Deskripsi     string
+	Status        string
+	PublishAt     *time.Time
 }
 
 // UpdateProductInput represents optional fields to update a product.
@@ -43,70 +91,242 @@ type UpdateProductInput struct {
 type ProductUsecase interface {
 	GetAll(limit, page int, filter ProductFilter) (*ProductListResult, error)
 	GetByID(id uint) (*domain.Produk, error)
-	Create(userID uint, in CreateProductInput, photoFilenames []string) (*domain.Produk, error)
-	Update(userID uint, productID uint, in UpdateProductInput, photoFilenames []string) (*domain.Produk, error)
-	Delete(userID uint, productID uint) error
+	GetBySlug(tokoID uint, slug string) (*domain.Produk, error)
+	Create(actor Actor, in CreateProductInput, photoFilenames []string) (*domain.Produk, error)
+	Update(actor Actor, productID uint, in UpdateProductInput, photoFilenames []string) (*domain.Produk, error)
+	Delete(actor Actor, productID uint) error
+	Archive(actor Actor, productID uint) (*domain.Produk, error)
+	Unarchive(actor Actor, productID uint) (*domain.Produk, error)
+	UpdateStock(actor Actor, productID uint, stok int) (*domain.Produk, error)
+	GetTokoProducts(actor Actor, tokoID uint, limit, page int, cursor, status string) (*ProductListResult, error)
+	Publish(actor Actor, productID uint, publishAt *time.Time) (*domain.Produk, error)
+	Unpublish(actor Actor, productID uint) (*domain.Produk, error)
+	GetModerationQueue(limit, page int, cursor string) (*ProductListResult, error)
+	Approve(actor Actor, productID uint) (*domain.Produk, error)
+	Reject(actor Actor, productID uint, reason string) (*domain.Produk, error)
+	PublishDue() (int, error)
+	SetVariants(actor Actor, productID uint, in SetVariantsInput) (*domain.Produk, error)
+	UpdateVariantStock(actor Actor, variantID uint, stok int) (*domain.Produk, error)
+	GetHistory(actor Actor, productID uint, moderator bool, limit int, cursor string) (*ProductHistoryResult, error)
+	GetPriceSeries(actor Actor, productID uint, moderator bool, from, to time.Time) ([]DailyPrice, error)
+	BackfillHistory() (int64, error)
+	HandleEvent(e event.Event) error
+	RebuildSearchIndex() (int, error)
 }
 
 type productUsecase struct {
-	productRepo repository.ProductRepository
-	fotoRepo    repository.FotoProdukRepository
-	tokoRepo    repository.TokoRepository
+	productRepo       repository.ProductRepository
+	fotoRepo          repository.FotoProdukRepository
+	userRepo          repository.UserRepository
+	searchRepo        repository.ProductSearchRepository
+	historyRepo       repository.ProductHistoryRepository
+	promos            *promotions
+	access            *tokoAccess
+	audit             *auditor
+	verifyReq         VerificationRequirement
+	requireModeration bool
 }
 
-// NewProductUsecase creates a new ProductUsecase.
-func NewProductUsecase(productRepo repository.ProductRepository, fotoRepo repository.FotoProdukRepository, tokoRepo repository.TokoRepository) ProductUsecase {
-	return &productUsecase{productRepo: productRepo, fotoRepo: fotoRepo, tokoRepo: tokoRepo}
+// NewProductUsecase creates a new ProductUsecase. verifyReq controls which
+// contact channels a seller must have verified before creating products;
+// with requireModeration, products a seller publishes wait for a moderator.
+func NewProductUsecase(productRepo repository.ProductRepository, fotoRepo repository.FotoProdukRepository, memberRepo repository.TokoMemberRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository, searchRepo repository.ProductSearchRepository, historyRepo repository.ProductHistoryRepository, promotionRepo repository.PromotionRepository, verifyReq VerificationRequirement, requireModeration bool) ProductUsecase {
+	return &productUsecase{productRepo: productRepo, fotoRepo: fotoRepo, userRepo: userRepo, searchRepo: searchRepo, historyRepo: historyRepo, promos: newPromotions(promotionRepo), access: newTokoAccess(memberRepo), audit: newAuditor(auditRepo), verifyReq: verifyReq, requireModeration: requireModeration}
 }
 
+const (
+	// publishBatchSize is how many due scheduled products one PublishDue call publishes.
+	publishBatchSize = 100
+)
+
 var (
 	// ErrProductNotFound indicates product not found.
 	ErrProductNotFound = errors.New("product not found")
+	// ErrProductHasVariants indicates a stock change on a product whose stock is kept per variant.
+	ErrProductHasVariants = errors.New("stok produk bervarian diatur per varian")
+	// ErrInvalidProductStatus indicates a status other than draft or published asked for by a seller.
+	ErrInvalidProductStatus = errors.New("status harus draft atau published")
+	// ErrProductStatusConflict indicates a publication change the product's current status does not allow.
+	ErrProductStatusConflict = errors.New("status produk tidak mengizinkan aksi ini")
+	// ErrRejectionReasonRequired indicates a rejection without a reason.
+	ErrRejectionReasonRequired = errors.New("alasan penolakan wajib diisi")
+	// ErrProductSlugAmbiguous indicates a slug looked up across tokos that
+	// products of several tokos have.
+	ErrProductSlugAmbiguous = repository.ErrSlugAmbiguous
 )
 
+// GetAll lists products. With a search query, only matching products are
+// listed, by relevance unless another sort is asked for, with highlights.
+// A cursor from a previous page replaces page.
 func (uc *productUsecase) GetAll(limit, page int, filter ProductFilter) (*ProductListResult, error) {
-	products, err := uc.productRepo.GetAll(limit, page, filter)
-	if err != nil {
-		return nil, err
-	}
-
 	if limit <= 0 {
 		limit = 10
 	}
 	if page <= 0 {
 		page = 1
 	}
+	scope := "product:" + filter.Sort + ":" + filter.Query
+	keyset, err := decodeKeyset(scope, filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	filter.Keyset = keyset
+	result := &ProductListResult{Page: page, Limit: limit, Data: []domain.Produk{}, Facets: &ProductFacets{}}
+
+	var queryTerms []string
+	// all is filter over the best maxSearchMatches search matches; filter
+	// itself keeps only the best maxSearchHits, which are all that can be
+	// paged through
+	all := filter
+	if filter.Query != "" {
+		queryTerms = search.QueryTerms(filter.Query)
+		if len(queryTerms) == 0 {
+			return result, nil
+		}
+		hits, err := uc.searchRepo.Search(filter.Query, maxSearchMatches+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(hits) == 0 {
+			return result, nil
+		}
+		if len(hits) > maxSearchMatches {
+			hits = hits[:maxSearchMatches]
+			result.TotalApproximate = true
+		}
+		all.IDs = make([]uint, len(hits))
+		for i, hit := range hits {
+			all.IDs[i] = hit.ProductID
+		}
+		filter.IDs = all.IDs[:min(len(all.IDs), maxSearchHits)]
+	}
 
-	return &ProductListResult{
-		Page:  page,
-		Limit: limit,
-		Data:  products,
-	}, nil
+	fetchLimit, fetchPage := limit, page
+	if keyset != nil {
+		// One row more tells whether another page follows
+		fetchLimit, fetchPage = limit+1, 1
+	}
+	products, err := uc.productRepo.GetAll(fetchLimit, fetchPage, filter)
+	if err != nil {
+		return nil, err
+	}
+	pageable, err := uc.productRepo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+	result.Total = pageable
+	if len(all.IDs) > len(filter.IDs) {
+		if result.Total, err = uc.productRepo.Count(all); err != nil {
+			return nil, err
+		}
+	}
+	hasNext, hasPrev := int64(page*limit) < pageable, page > 1
+	if keyset != nil {
+		products, hasNext, hasPrev = keysetPage(products, limit, keyset)
+	}
+	result.Data = products
+	result.PageCursors = pageCursors(scope, products, hasNext, hasPrev, func(p *domain.Produk) []string {
+		return repository.ProductKeyValues(p, filter)
+	})
+	if result.Facets, err = uc.productRepo.GetFacets(all); err != nil {
+		return nil, err
+	}
+	if err := uc.promos.attach(products); err != nil {
+		return nil, err
+	}
+
+	if queryTerms != nil {
+		result.Highlights = make(map[uint]map[string]string, len(products))
+		for _, p := range products {
+			fields := make(map[string]string)
+			if h, ok := search.Highlight(p.NamaProduk, queryTerms, 0); ok {
+				fields["nama_produk"] = h
+			}
+			if h, ok := search.Highlight(p.Deskripsi, queryTerms, highlightSnippetLength); ok {
+				fields["deskripsi"] = h
+			}
+			if len(fields) > 0 {
+				result.Highlights[p.ID] = fields
+			}
+		}
+	}
+
+	return result, nil
 }
 
+// GetByID returns a published product.
 func (uc *productUsecase) GetByID(id uint) (*domain.Produk, error) {
 	product, err := uc.productRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if product == nil || product.Status != domain.ProductStatusPublished {
+		return nil, ErrProductNotFound
+	}
+	if err := uc.promos.attachTo([]*domain.Produk{product}); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetBySlug returns the product with slug, in the given toko unless tokoID
+// is 0. A slug the product had before a rename also finds it; the caller
+// tells by comparing it with the product's current slug. With slugs unique
+// per toko, tokoID 0 gives ErrProductSlugAmbiguous when several tokos
+// have the slug.
+func (uc *productUsecase) GetBySlug(tokoID uint, slug string) (*domain.Produk, error) {
+	product, err := uc.productRepo.GetBySlug(tokoID, slug)
+	if err != nil {
+		return nil, err
+	}
 	if product == nil {
+		if product, err = uc.productRepo.GetByFormerSlug(tokoID, slug); err != nil {
+			return nil, err
+		}
+	}
+	if product == nil || product.Status != domain.ProductStatusPublished {
 		return nil, ErrProductNotFound
 	}
+	if err := uc.promos.attachTo([]*domain.Produk{product}); err != nil {
+		return nil, err
+	}
 	return product, nil
 }
 
-func (uc *productUsecase) Create(userID uint, in CreateProductInput, photoFilenames []string) (*domain.Produk, error) {
-	if in.NamaProduk == "" || in.CategoryID == 0 || in.HargaReseller <= 0 || in.HargaKonsumen <= 0 || in.Stok < 0 {
-		return nil, errors.New("nama_produk, category_id, harga_reseller, harga_konsumen, stok wajib diisi")
+func (uc *productUsecase) Create(actor Actor, in CreateProductInput, photoFilenames []string) (*domain.Produk, error) {
+	if err := validateCreateProduct(in); err != nil {
+		return nil, err
+	}
+	publishAt := futureTime(in.PublishAt)
+	var status string
+	switch in.Status {
+	case domain.ProductStatusDraft:
+		status = domain.ProductStatusDraft
+	case "", domain.ProductStatusPublished:
+		status = uc.publishStatus(publishAt)
+	default:
+		return nil, ErrInvalidProductStatus
+	}
+
+	user, err := uc.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := checkVerified(user, uc.verifyReq); err != nil {
+		return nil, err
 	}
 
-	// Ensure user has a toko
-	toko, err := uc.tokoRepo.GetByUserID(userID)
+	// Resolve the toko the user manages products for
+	tokoID, err := actingToko(actor, in.TokoID)
 	if err != nil {
 		return nil, err
 	}
-	if toko == nil {
-		return nil, errors.New("toko not found for user")
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapProducts)
+	if err != nil {
+		return nil, err
 	}
 
 	product := &domain.Produk{
@@ -116,13 +336,16 @@ func (uc *productUsecase) Create(userID uint, in CreateProductInput, photoFilena
 		HargaKonsumen: strconv.Itoa(in.HargaKonsumen),
 		Stok:          in.Stok,
 		Deskripsi:     in.Deskripsi,
-		TokoID:        toko.ID,
+		TokoID:        member.TokoID,
 		CategoryID:    in.CategoryID,
+		Status:        status,
+		PublishAt:     publishAt,
 	}
 
-	if err := uc.productRepo.Create(product); err != nil {
+	if err := uc.productRepo.Create(product, productEvents(domain.EventProductCreated, product, product.Stok)); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityProduct, product.ID, nil, auditSnapshot(product))
 
 	// Save product photos
 	if len(photoFilenames) > 0 {
@@ -144,23 +367,17 @@ func (uc *productUsecase) Create(userID uint, in CreateProductInput, photoFilena
 	return product, nil
 }
 
-func (uc *productUsecase) Update(userID uint, productID uint, in UpdateProductInput, photoFilenames []string) (*domain.Produk, error) {
-	// Ensure user has a toko
-	toko, err := uc.tokoRepo.GetByUserID(userID)
-	if err != nil {
-		return nil, err
-	}
-	if toko == nil {
-		return nil, errors.New("toko not found for user")
-	}
-
-	product, err := uc.productRepo.GetByIDForToko(toko.ID, productID)
+// Update changes a product. Under moderation, a published or scheduled
+// product whose name, category, description or photos change goes back to
+// the moderation queue.
+func (uc *productUsecase) Update(actor Actor, productID uint, in UpdateProductInput, photoFilenames []string) (*domain.Produk, error) {
+	product, err := uc.getManagedProduct(actor, productID)
 	if err != nil {
 		return nil, err
 	}
-	if product == nil {
-		return nil, ErrProductNotFound
-	}
+	before := auditSnapshot(product)
+	stokBefore := product.Stok
+	nama, categoryID, deskripsi := product.NamaProduk, product.CategoryID, product.Deskripsi
 
 	if in.NamaProduk != nil && *in.NamaProduk != "" {
 		product.NamaProduk = *in.NamaProduk
@@ -175,16 +392,35 @@ func (uc *productUsecase) Update(userID uint, productID uint, in UpdateProductIn
 	if in.HargaKonsumen != nil {
 		product.HargaKonsumen = strconv.Itoa(*in.HargaKonsumen)
 	}
-	if in.Stok != nil {
+	if in.Stok != nil && *in.Stok != product.Stok {
+		if product.HasVariants() {
+			return nil, ErrProductHasVariants
+		}
 		product.Stok = *in.Stok
 	}
 	if in.Deskripsi != nil {
 		product.Deskripsi = *in.Deskripsi
 	}
 
-	if err := uc.productRepo.Update(product); err != nil {
+	contentChanged := product.NamaProduk != nama || product.CategoryID != categoryID ||
+		product.Deskripsi != deskripsi || len(photoFilenames) > 0
+	if uc.requireModeration && contentChanged {
+		// queued before the new content is saved, so it is never live unchecked
+		if err := uc.resubmit(product); err != nil {
+			return nil, err
+		}
+	}
+
+	// stock is only written when given; a product with variants holds
+	// their sum, which only changes with the variants
+	save := uc.productRepo.Update
+	if in.Stok != nil && !product.HasVariants() {
+		save = uc.productRepo.UpdateWithStock
+	}
+	if err := save(product, productEvents(domain.EventProductUpdated, product, stokBefore)); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
 
 	// If new photos are provided, replace existing photos
 	if len(photoFilenames) > 0 {
@@ -210,35 +446,370 @@ func (uc *productUsecase) Update(userID uint, productID uint, in UpdateProductIn
 	return product, nil
 }
 
-func (uc *productUsecase) Delete(userID uint, productID uint) error {
-	// Ensure user has a toko
-	toko, err := uc.tokoRepo.GetByUserID(userID)
+func (uc *productUsecase) Delete(actor Actor, productID uint) error {
+	product, err := uc.getManagedProduct(actor, productID)
 	if err != nil {
 		return err
 	}
-	if toko == nil {
-		return errors.New("toko not found for user")
+
+	if err := uc.productRepo.Delete(product.ID, productEvents(domain.EventProductDeleted, product, product.Stok)); err != nil {
+		return err
 	}
+	uc.audit.record(actor, domain.AuditActionDelete, AuditEntityProduct, product.ID, auditSnapshot(product), nil)
+
+	return nil
+}
+
+// Archive takes a product off sale: it leaves public listings and search
+// and cannot be bought, but its page and past orders stay.
+func (uc *productUsecase) Archive(actor Actor, productID uint) (*domain.Produk, error) {
+	return uc.setArchived(actor, productID, true)
+}
+
+// Unarchive puts an archived product back on sale.
+func (uc *productUsecase) Unarchive(actor Actor, productID uint) (*domain.Produk, error) {
+	return uc.setArchived(actor, productID, false)
+}
 
-	product, err := uc.productRepo.GetByIDForToko(toko.ID, productID)
+func (uc *productUsecase) setArchived(actor Actor, productID uint, archived bool) (*domain.Produk, error) {
+	product, err := uc.getManagedProduct(actor, productID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if (product.ArchivedAt != nil) == archived {
+		return product, nil
+	}
+	before := auditSnapshot(product)
+
+	product.ArchivedAt = nil
+	if archived {
+		now := time.Now()
+		product.ArchivedAt = &now
+	}
+	if err := uc.productRepo.Update(product, productEvents(domain.EventProductUpdated, product, product.Stok)); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
+
+	return product, nil
+}
+
+// UpdateStock sets a product's stock, e.g. from a seller's ERP sync.
+func (uc *productUsecase) UpdateStock(actor Actor, productID uint, stok int) (*domain.Produk, error) {
+	if stok < 0 {
+		return nil, errors.New("stok tidak boleh negatif")
+	}
+
+	product, err := uc.getManagedProduct(actor, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.HasVariants() {
+		return nil, ErrProductHasVariants
+	}
+	before := auditSnapshot(product)
+	stokBefore := product.Stok
+
+	product.Stok = stok
+	if err := uc.productRepo.UpdateWithStock(product, productEvents(domain.EventProductUpdated, product, stokBefore)); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
+
+	return product, nil
+}
+
+// GetTokoProducts lists the products of a toko the actor is a member of.
+// tokoID 0 picks the actor's first toko.
+// Unpublished and archived products are listed too; status narrows the
+// list to one publication status.
+func (uc *productUsecase) GetTokoProducts(actor Actor, tokoID uint, limit, page int, cursor, status string) (*ProductListResult, error) {
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := domain.ProductStatuses
+	if status != "" {
+		statuses = []string{status}
+	}
+	return uc.GetAll(limit, page, ProductFilter{TokoID: member.TokoID, IncludeArchived: true, Statuses: statuses, Cursor: cursor})
+}
+
+// Publish submits a draft, rejected or scheduled product for publication
+// at publishAt, or right away when it is not in the future. Under
+// moderation it waits in the queue first.
+func (uc *productUsecase) Publish(actor Actor, productID uint, publishAt *time.Time) (*domain.Produk, error) {
+	product, err := uc.getManagedProduct(actor, productID)
+	if err != nil {
+		return nil, err
+	}
+	before := auditSnapshot(product)
+
+	product.PublishAt = futureTime(publishAt)
+	product.Status = uc.publishStatus(product.PublishAt)
+	product.RejectionReason = ""
+	from := []string{domain.ProductStatusDraft, domain.ProductStatusRejected, domain.ProductStatusScheduled}
+	return uc.changeStatus(actor, product, before, from, productEvents(domain.EventProductUpdated, product, product.Stok))
+}
+
+// Unpublish takes a product back to draft, also out of the moderation
+// queue or a schedule.
+func (uc *productUsecase) Unpublish(actor Actor, productID uint) (*domain.Produk, error) {
+	product, err := uc.getManagedProduct(actor, productID)
+	if err != nil {
+		return nil, err
+	}
+	before := auditSnapshot(product)
+
+	product.Status = domain.ProductStatusDraft
+	product.PublishAt = nil
+	from := []string{domain.ProductStatusPending, domain.ProductStatusPublished, domain.ProductStatusRejected, domain.ProductStatusScheduled}
+	return uc.changeStatus(actor, product, before, from, productEvents(domain.EventProductUpdated, product, product.Stok))
+}
+
+// GetModerationQueue lists the products waiting for a moderator, oldest first.
+func (uc *productUsecase) GetModerationQueue(limit, page int, cursor string) (*ProductListResult, error) {
+	return uc.GetAll(limit, page, ProductFilter{IncludeArchived: true, Statuses: []string{domain.ProductStatusPending}, Cursor: cursor})
+}
+
+// Approve publishes a pending product, or schedules it when the seller
+// asked for a later publish time.
+func (uc *productUsecase) Approve(actor Actor, productID uint) (*domain.Produk, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
 	}
 	if product == nil {
-		return ErrProductNotFound
+		return nil, ErrProductNotFound
 	}
+	before := auditSnapshot(product)
 
-	if err := uc.fotoRepo.DeleteByProdukID(product.ID); err != nil {
-		return err
+	product.PublishAt = futureTime(product.PublishAt)
+	product.Status = domain.ProductStatusPublished
+	if product.PublishAt != nil {
+		product.Status = domain.ProductStatusScheduled
+	}
+	product.RejectionReason = ""
+	return uc.changeStatus(actor, product, before, []string{domain.ProductStatusPending}, productModerationEvents(domain.EventProductApproved, product))
+}
+
+// Reject turns down a pending product, or takes down a published or
+// scheduled one, telling the seller why.
+func (uc *productUsecase) Reject(actor Actor, productID uint, reason string) (*domain.Produk, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	before := auditSnapshot(product)
+
+	product.Status = domain.ProductStatusRejected
+	product.PublishAt = nil
+	product.RejectionReason = truncate(reason, 500)
+	from := []string{domain.ProductStatusPending, domain.ProductStatusPublished, domain.ProductStatusScheduled}
+	return uc.changeStatus(actor, product, before, from, productModerationEvents(domain.EventProductRejected, product))
+}
+
+// PublishDue publishes scheduled products whose time has come and returns
+// how many it published.
+func (uc *productUsecase) PublishDue() (int, error) {
+	due, err := uc.productRepo.GetDueScheduled(time.Now(), publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for i := range due {
+		product := &due[i]
+		product.Status = domain.ProductStatusPublished
+		changed, err := uc.productRepo.SetStatus(product, []string{domain.ProductStatusScheduled}, productEvents(domain.EventProductUpdated, product, product.Stok))
+		if err != nil {
+			return published, err
+		}
+		if changed {
+			published++
+		}
+	}
+	return published, nil
+}
+
+// changeStatus stores a publication change if the product is still in one
+// of the from statuses.
+func (uc *productUsecase) changeStatus(actor Actor, product *domain.Produk, before map[string]interface{}, from []string, events repository.OutboxFunc) (*domain.Produk, error) {
+	changed, err := uc.productRepo.SetStatus(product, from, events)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrProductStatusConflict
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
+	return product, nil
+}
+
+// resubmit moves a published or scheduled product back to the moderation
+// queue; a scheduled one keeps its publish time for when it is approved.
+func (uc *productUsecase) resubmit(product *domain.Produk) error {
+	if product.Status != domain.ProductStatusPublished && product.Status != domain.ProductStatusScheduled {
+		return nil
+	}
+	status := product.Status
+	product.Status = domain.ProductStatusPending
+	from := []string{domain.ProductStatusPublished, domain.ProductStatusScheduled}
+	changed, err := uc.productRepo.SetStatus(product, from, productEvents(domain.EventProductUpdated, product, product.Stok))
+	if err != nil || !changed {
+		// not queued: failed, or a moderator or the seller changed the status first
+		product.Status = status
+	}
+	return err
+}
+
+// publishStatus is where a product goes when its seller publishes it:
+// the moderation queue, or else a schedule for a publishAt, or public.
+func (uc *productUsecase) publishStatus(publishAt *time.Time) string {
+	switch {
+	case uc.requireModeration:
+		return domain.ProductStatusPending
+	case publishAt != nil:
+		return domain.ProductStatusScheduled
+	default:
+		return domain.ProductStatusPublished
+	}
+}
+
+// futureTime returns t if it is in the future, else nil.
+func futureTime(t *time.Time) *time.Time {
+	if t == nil || !t.After(time.Now()) {
+		return nil
 	}
+	return t
+}
+
+// HandleEvent keeps the search index in step with product changes. The
+// product is reloaded, so a late or repeated event indexes its current state.
+func (uc *productUsecase) HandleEvent(e event.Event) error {
+	switch e.Type {
+	case domain.EventProductCreated, domain.EventProductUpdated:
+		product, err := uc.productRepo.GetByID(e.AggregateID)
+		if err != nil {
+			return err
+		}
+		if product == nil {
+			return uc.searchRepo.Remove(e.AggregateID)
+		}
+		return uc.searchRepo.Index(product)
+	case domain.EventProductDeleted:
+		return uc.searchRepo.Remove(e.AggregateID)
+	}
+	return nil
+}
 
-	return uc.productRepo.Delete(product.ID)
+// RebuildSearchIndex indexes every product, picking up category and toko
+// renames, and returns how many were indexed.
+func (uc *productUsecase) RebuildSearchIndex() (int, error) {
+	indexed := 0
+	for page := 1; ; page++ {
+		products, err := uc.productRepo.GetAll(searchRebuildPageSize, page, ProductFilter{})
+		if err != nil {
+			return indexed, err
+		}
+		for i := range products {
+			if err := uc.searchRepo.Index(&products[i]); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+		if len(products) < searchRebuildPageSize {
+			return indexed, nil
+		}
+	}
 }
 
-// slugify converts a product name into a URL-friendly slug.
+// productEvents builds the outbox events for a product change: eventType,
+// plus a stock change when stokBefore differs from the current stock.
+func productEvents(eventType string, product *domain.Produk, stokBefore int) repository.OutboxFunc {
+	return func() ([]domain.OutboxEvent, error) {
+		var b event.Batch
+		b.Add(eventType, domain.AggregateProduct, product.ID, event.NewProductChanged(product))
+		if stokBefore != product.Stok {
+			b.Add(domain.EventStockChanged, domain.AggregateProduct, product.ID, event.StockChanged{
+				ProductID:   product.ID,
+				TokoID:      product.TokoID,
+				NamaProduk:  product.NamaProduk,
+				StokSebelum: stokBefore,
+				Stok:        product.Stok,
+			})
+		}
+		return b.Events()
+	}
+}
+
+// productModerationEvents builds the outbox events for a moderation
+// decision: the product change, and eventType with the decision.
+func productModerationEvents(eventType string, product *domain.Produk) repository.OutboxFunc {
+	return func() ([]domain.OutboxEvent, error) {
+		var b event.Batch
+		b.Add(domain.EventProductUpdated, domain.AggregateProduct, product.ID, event.NewProductChanged(product))
+		b.Add(eventType, domain.AggregateProduct, product.ID, event.ProductModerated{
+			ProductID:  product.ID,
+			TokoID:     product.TokoID,
+			NamaProduk: product.NamaProduk,
+			Status:     product.Status,
+			PublishAt:  product.PublishAt,
+			Reason:     product.RejectionReason,
+		})
+		return b.Events()
+	}
+}
+
+// getManagedProduct loads a product the actor may edit as a member of its toko.
+// Non-members get ErrProductNotFound so other tokos' products are not revealed.
+func (uc *productUsecase) getManagedProduct(actor Actor, productID uint) (*domain.Produk, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	if actor.TokoID != 0 && actor.TokoID != product.TokoID {
+		return nil, ErrProductNotFound
+	}
+
+	if _, err := uc.access.resolve(actor.UserID, product.TokoID, domain.TokoCapProducts); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// validateCreateProduct checks the fields every new product needs.
+func validateCreateProduct(in CreateProductInput) error {
+	if in.NamaProduk == "" || in.CategoryID == 0 || in.HargaReseller <= 0 || in.HargaKonsumen <= 0 || in.Stok < 0 {
+		return errors.New("nama_produk, category_id, harga_reseller, harga_konsumen, stok wajib diisi")
+	}
+	return nil
+}
+
+// slugify converts a product name into the base of its slug; the
+// repository adds a suffix when another product has it.
 func slugify(s string) string {
-	s = strings.ToLower(strings.TrimSpace(s))
-	s = strings.ReplaceAll(s, " ", "-")
-	// naive implementation is enough for this case
-	return s
+	if slug := helper.Slugify(s); slug != "" {
+		return slug
+	}
+	// names in scripts without a transliteration
+	return "produk"
 }
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// fakeSearchRepo matches every query with products 1 to matches, best first.
+type fakeSearchRepo struct {
+	repository.ProductSearchRepository
+	matches int
+}
+
+func (r *fakeSearchRepo) Search(query string, limit int) ([]repository.SearchHit, error) {
+	n := r.matches
+	if limit > 0 {
+		n = min(n, limit)
+	}
+	hits := make([]repository.SearchHit, n)
+	for i := range hits {
+		hits[i] = repository.SearchHit{ProductID: uint(i + 1), Score: float64(n - i)}
+	}
+	return hits, nil
+}
+
+// fakeListingRepo counts the products of the filter's ID list and records
+// the list lengths it was asked to count and facet.
+type fakeListingRepo struct {
+	repository.ProductRepository
+	counted, faceted []int
+}
+
+func (r *fakeListingRepo) GetAll(limit, page int, filter ProductFilter) ([]domain.Produk, error) {
+	return nil, nil
+}
+
+func (r *fakeListingRepo) Count(filter ProductFilter) (int64, error) {
+	r.counted = append(r.counted, len(filter.IDs))
+	return int64(len(filter.IDs)), nil
+}
+
+func (r *fakeListingRepo) GetFacets(filter ProductFilter) (*ProductFacets, error) {
+	r.faceted = append(r.faceted, len(filter.IDs))
+	return &ProductFacets{}, nil
+}
+
+func TestProductSearchTotal(t *testing.T) {
+	tests := []struct {
+		name            string
+		matches         int
+		wantTotal       int64
+		wantApproximate bool
+		wantFaceted     int
+	}{
+		{"fewer than the pageable hits", 40, 40, false, 40},
+		{"more than the pageable hits", maxSearchHits + 500, maxSearchHits + 500, false, maxSearchHits + 500},
+		{"exactly the counted matches", maxSearchMatches, maxSearchMatches, false, maxSearchMatches},
+		{"more than the counted matches", maxSearchMatches + 1, maxSearchMatches, true, maxSearchMatches},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeListingRepo{}
+			uc := &productUsecase{
+				productRepo: products,
+				searchRepo:  &fakeSearchRepo{matches: tt.matches},
+				promos:      newPromotions(&fakePromotionRepo{}),
+			}
+
+			result, err := uc.GetAll(10, 1, ProductFilter{Query: "kopi"})
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if result.Total != tt.wantTotal || result.TotalApproximate != tt.wantApproximate {
+				t.Errorf("total = %d (approximate %v), want %d (approximate %v)", result.Total, result.TotalApproximate, tt.wantTotal, tt.wantApproximate)
+			}
+			if len(products.faceted) != 1 || products.faceted[0] != tt.wantFaceted {
+				t.Errorf("faceted ID lists = %v, want [%d]", products.faceted, tt.wantFaceted)
+			}
+			for _, n := range products.counted {
+				if n > maxSearchMatches {
+					t.Errorf("counted an ID list of %d, want at most %d", n, maxSearchMatches)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+const (
+	maxProductOptions  = 3
+	maxProductVariants = 100
+)
+
+// ProductOptionInput is an option type of a product with its allowed values.
+type ProductOptionInput struct {
+	Nama   string   `json:"nama"`
+	Values []string `json:"values"`
+}
+
+// ProductVariantInput is one sellable combination of option values.
+// Attributes maps every option name to one of its values; nil prices use
+// the product's.
+type ProductVariantInput struct {
+	SKU           string            `json:"sku"`
+	Attributes    map[string]string `json:"attributes"`
+	HargaReseller *int              `json:"harga_reseller"`
+	HargaKonsumen *int              `json:"harga_konsumen"`
+	Stok          int               `json:"stok"`
+	Foto          string            `json:"foto"`
+}
+
+// SetVariantsInput replaces a product's options and variants. Empty
+// options and variants turn the product back into a single-stock product.
+type SetVariantsInput struct {
+	Options  []ProductOptionInput  `json:"options"`
+	Variants []ProductVariantInput `json:"variants"`
+}
+
+var (
+	// ErrVariantNotFound indicates variant not found.
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrInvalidVariants indicates options or variants that do not fit together.
+	ErrInvalidVariants = errors.New("varian tidak valid")
+)
+
+// SetVariants replaces the product's options and variants. The product's
+// stock becomes the sum of the variants' stock.
+func (uc *productUsecase) SetVariants(actor Actor, productID uint, in SetVariantsInput) (*domain.Produk, error) {
+	product, err := uc.getManagedProduct(actor, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	options, variants, err := buildVariants(in)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditSnapshot(product)
+	stokBefore := product.Stok
+	if len(variants) > 0 {
+		product.Stok = 0
+		for _, v := range variants {
+			product.Stok += v.Stok
+		}
+	}
+
+	if err := uc.productRepo.ReplaceVariants(product, options, variants, productEvents(domain.EventProductUpdated, product, stokBefore)); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
+
+	return uc.GetByID(product.ID)
+}
+
+// UpdateVariantStock sets one variant's stock, e.g. from a seller's ERP sync.
+func (uc *productUsecase) UpdateVariantStock(actor Actor, variantID uint, stok int) (*domain.Produk, error) {
+	if stok < 0 {
+		return nil, errors.New("stok tidak boleh negatif")
+	}
+
+	variant, err := uc.productRepo.GetVariantByID(variantID)
+	if err != nil {
+		return nil, err
+	}
+	if variant == nil {
+		return nil, ErrVariantNotFound
+	}
+	product, err := uc.getManagedProduct(actor, variant.ProdukID)
+	if err != nil {
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+
+	before := auditSnapshot(product)
+	stokBefore := product.Stok
+	variant.Stok = stok
+
+	if err := uc.productRepo.UpdateVariantStock(product, variant, productEvents(domain.EventProductUpdated, product, stokBefore)); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, product.ID, before, auditSnapshot(product))
+
+	return uc.GetByID(product.ID)
+}
+
+// buildVariants validates in and converts it to rows. Every variant must
+// pick exactly one value of every option, and no two variants may share a
+// SKU or a combination.
+func buildVariants(in SetVariantsInput) ([]domain.ProdukOption, []domain.ProdukVariant, error) {
+	if len(in.Options) == 0 && len(in.Variants) == 0 {
+		return nil, nil, nil
+	}
+	if len(in.Options) == 0 || len(in.Variants) == 0 {
+		return nil, nil, fmt.Errorf("%w: options dan variants wajib diisi bersama", ErrInvalidVariants)
+	}
+	if len(in.Options) > maxProductOptions {
+		return nil, nil, fmt.Errorf("%w: maksimal %d option", ErrInvalidVariants, maxProductOptions)
+	}
+	if len(in.Variants) > maxProductVariants {
+		return nil, nil, fmt.Errorf("%w: maksimal %d varian", ErrInvalidVariants, maxProductVariants)
+	}
+
+	allowed := make(map[string]map[string]bool, len(in.Options))
+	options := make([]domain.ProdukOption, 0, len(in.Options))
+	for i, o := range in.Options {
+		nama := strings.TrimSpace(o.Nama)
+		if nama == "" || len(o.Values) == 0 {
+			return nil, nil, fmt.Errorf("%w: option wajib punya nama dan values", ErrInvalidVariants)
+		}
+		if allowed[nama] != nil {
+			return nil, nil, fmt.Errorf("%w: option %q duplikat", ErrInvalidVariants, nama)
+		}
+		allowed[nama] = make(map[string]bool, len(o.Values))
+
+		option := domain.ProdukOption{Nama: nama, Posisi: i}
+		for j, v := range o.Values {
+			nilai := strings.TrimSpace(v)
+			if nilai == "" || allowed[nama][nilai] {
+				return nil, nil, fmt.Errorf("%w: value option %q kosong atau duplikat", ErrInvalidVariants, nama)
+			}
+			allowed[nama][nilai] = true
+			option.Values = append(option.Values, domain.ProdukOptionValue{Nilai: nilai, Posisi: j})
+		}
+		options = append(options, option)
+	}
+
+	skus := make(map[string]bool, len(in.Variants))
+	combos := make(map[string]bool, len(in.Variants))
+	variants := make([]domain.ProdukVariant, 0, len(in.Variants))
+	for _, v := range in.Variants {
+		sku := strings.TrimSpace(v.SKU)
+		if sku == "" || skus[sku] {
+			return nil, nil, fmt.Errorf("%w: sku kosong atau duplikat", ErrInvalidVariants)
+		}
+		skus[sku] = true
+		if v.Stok < 0 {
+			return nil, nil, fmt.Errorf("%w: stok varian %s tidak boleh negatif", ErrInvalidVariants, sku)
+		}
+
+		attrs := make(map[string]string, len(v.Attributes))
+		for nama, nilai := range v.Attributes {
+			nama, nilai = strings.TrimSpace(nama), strings.TrimSpace(nilai)
+			if !allowed[nama][nilai] {
+				return nil, nil, fmt.Errorf("%w: varian %s memakai %s=%s yang tidak ada di options", ErrInvalidVariants, sku, nama, nilai)
+			}
+			attrs[nama] = nilai
+		}
+		if len(attrs) != len(options) {
+			return nil, nil, fmt.Errorf("%w: varian %s wajib memilih satu value untuk setiap option", ErrInvalidVariants, sku)
+		}
+		// json.Marshal sorts map keys, so equal combinations encode equally
+		encoded, err := json.Marshal(attrs)
+		if err != nil {
+			return nil, nil, err
+		}
+		if combos[string(encoded)] {
+			return nil, nil, fmt.Errorf("%w: kombinasi varian %s duplikat", ErrInvalidVariants, sku)
+		}
+		combos[string(encoded)] = true
+
+		variant := domain.ProdukVariant{
+			SKU:        sku,
+			Attributes: string(encoded),
+			Stok:       v.Stok,
+			FotoURL:    strings.TrimSpace(v.Foto),
+		}
+		if v.HargaReseller != nil {
+			if *v.HargaReseller <= 0 {
+				return nil, nil, fmt.Errorf("%w: harga_reseller varian %s harus > 0", ErrInvalidVariants, sku)
+			}
+			variant.HargaReseller = strconv.Itoa(*v.HargaReseller)
+		}
+		if v.HargaKonsumen != nil {
+			if *v.HargaKonsumen <= 0 {
+				return nil, nil, fmt.Errorf("%w: harga_konsumen varian %s harus > 0", ErrInvalidVariants, sku)
+			}
+			variant.HargaKonsumen = strconv.Itoa(*v.HargaKonsumen)
+		}
+		variants = append(variants, variant)
+	}
+
+	return options, variants, nil
+}
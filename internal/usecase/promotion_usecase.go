@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// CreatePromotionInput represents payload to schedule a promotion on
+// ProductID or, for the toko's products, on CategoryID. TokoID is
+// optional; by default the user's own toko is used. A zero StartAt starts
+// the promotion right away.
+type CreatePromotionInput struct {
+	TokoID     uint      `json:"toko_id"`
+	ProductID  uint      `json:"product_id"`
+	CategoryID uint      `json:"category_id"`
+	Nama       string    `json:"nama"`
+	Type       string    `json:"type"`
+	Value      int       `json:"value"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	MaxPerUser int       `json:"max_per_user"`
+	SaleStock  int       `json:"sale_stock"`
+	FlashSale  bool      `json:"flash_sale"`
+}
+
+// FlashSaleResult is a page of products in a running flash sale, ending
+// soonest first; each product's Promotion is set.
+type FlashSaleResult struct {
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+	Data  []domain.Produk `json:"data"`
+}
+
+// PromotionUsecase defines promotion-related business logic.
+type PromotionUsecase interface {
+	GetAll(actor Actor, tokoID uint) ([]domain.Promotion, error)
+	Create(actor Actor, in CreatePromotionInput) (*domain.Promotion, error)
+	End(actor Actor, id uint) (*domain.Promotion, error)
+	GetFlashSales(limit, page int) (*FlashSaleResult, error)
+}
+
+type promotionUsecase struct {
+	promotionRepo repository.PromotionRepository
+	productRepo   repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	access        *tokoAccess
+	audit         *auditor
+}
+
+// NewPromotionUsecase creates a new PromotionUsecase.
+func NewPromotionUsecase(promotionRepo repository.PromotionRepository, productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository) PromotionUsecase {
+	return &promotionUsecase{promotionRepo: promotionRepo, productRepo: productRepo, categoryRepo: categoryRepo, access: newTokoAccess(memberRepo), audit: newAuditor(auditRepo)}
+}
+
+var (
+	// ErrPromotionNotFound indicates promotion not found.
+	ErrPromotionNotFound = errors.New("promo tidak ditemukan")
+	// ErrInvalidPromotionTarget indicates a promotion not on exactly one product or one category.
+	ErrInvalidPromotionTarget = errors.New("promo harus untuk satu produk atau satu kategori")
+	// ErrInvalidPromotionDiscount indicates a discount type or value that cuts nothing or everything.
+	ErrInvalidPromotionDiscount = errors.New("potongan promo tidak valid")
+	// ErrInvalidPromotionPeriod indicates a promotion ending before it starts or in the past.
+	ErrInvalidPromotionPeriod = errors.New("periode promo tidak valid")
+	// ErrInvalidPromotionLimit indicates a negative sale stock or per-user limit.
+	ErrInvalidPromotionLimit = errors.New("stok promo dan batas per pembeli tidak boleh negatif")
+	// ErrFlashSaleNeedsProduct indicates a flash sale on a category.
+	ErrFlashSaleNeedsProduct = errors.New("flash sale harus untuk satu produk")
+	// ErrPromotionNameRequired indicates a promotion without a name.
+	ErrPromotionNameRequired = errors.New("nama promo wajib diisi")
+	// ErrCategoryNotFound indicates a promotion on a category that does not exist.
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrPromotionPriceOnVariants indicates a sale price on a product sold per variant.
+	ErrPromotionPriceOnVariants = errors.New("harga promo tetap tidak berlaku untuk produk bervarian, gunakan potongan persen")
+)
+
+// GetAll lists the promotions of a toko the actor works for, latest start
+// first, ended ones included.
+func (uc *promotionUsecase) GetAll(actor Actor, tokoID uint) ([]domain.Promotion, error) {
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapProducts)
+	if err != nil {
+		return nil, err
+	}
+	return uc.promotionRepo.GetAllByToko(member.TokoID)
+}
+
+// Create schedules a promotion on one of the toko's products or on the
+// toko's products in a category.
+func (uc *promotionUsecase) Create(actor Actor, in CreatePromotionInput) (*domain.Promotion, error) {
+	in.Nama = strings.TrimSpace(in.Nama)
+	if in.Nama == "" {
+		return nil, ErrPromotionNameRequired
+	}
+	if (in.ProductID == 0) == (in.CategoryID == 0) {
+		return nil, ErrInvalidPromotionTarget
+	}
+	if in.FlashSale && in.ProductID == 0 {
+		return nil, ErrFlashSaleNeedsProduct
+	}
+	if in.MaxPerUser < 0 || in.SaleStock < 0 {
+		return nil, ErrInvalidPromotionLimit
+	}
+	now := time.Now()
+	if in.StartAt.IsZero() {
+		in.StartAt = now
+	}
+	if !in.EndAt.After(in.StartAt) || !in.EndAt.After(now) {
+		return nil, ErrInvalidPromotionPeriod
+	}
+
+	tokoID, err := actingToko(actor, in.TokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := &domain.Promotion{
+		TokoID:     member.TokoID,
+		Nama:       truncate(in.Nama, 100),
+		Type:       in.Type,
+		Value:      in.Value,
+		StartAt:    in.StartAt,
+		EndAt:      in.EndAt,
+		FlashSale:  in.FlashSale,
+		MaxPerUser: in.MaxPerUser,
+		SaleStock:  in.SaleStock,
+		CreatedBy:  actor.UserID,
+	}
+
+	if in.ProductID != 0 {
+		product, err := uc.productRepo.GetByIDForToko(member.TokoID, in.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, ErrProductNotFound
+		}
+		// variants may be priced apart; one sale price cannot suit them all
+		if in.Type == domain.PromotionTypePrice && product.HasVariants() {
+			return nil, ErrPromotionPriceOnVariants
+		}
+		harga, _ := strconv.Atoi(product.HargaKonsumen)
+		if err := validateDiscount(in.Type, in.Value, harga); err != nil {
+			return nil, err
+		}
+		promotion.ProdukID = &product.ID
+	} else {
+		category, err := uc.categoryRepo.GetByID(in.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil {
+			return nil, ErrCategoryNotFound
+		}
+		// a single sale price suits one product, not a whole category
+		if in.Type != domain.PromotionTypePercent {
+			return nil, ErrInvalidPromotionDiscount
+		}
+		if err := validateDiscount(in.Type, in.Value, 0); err != nil {
+			return nil, err
+		}
+		promotion.CategoryID = &category.ID
+	}
+
+	if err := uc.promotionRepo.Create(promotion); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityPromotion, promotion.ID, nil, auditSnapshot(promotion))
+
+	return promotion, nil
+}
+
+// End stops a promotion now, or cancels it before it starts. It stays
+// listed for the toko and in the orders priced at it.
+func (uc *promotionUsecase) End(actor Actor, id uint) (*domain.Promotion, error) {
+	promotion, err := uc.promotionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if promotion == nil {
+		return nil, ErrPromotionNotFound
+	}
+	if actor.TokoID != 0 && actor.TokoID != promotion.TokoID {
+		return nil, ErrPromotionNotFound
+	}
+	if _, err := uc.access.resolve(actor.UserID, promotion.TokoID, domain.TokoCapProducts); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !promotion.EndAt.After(now) {
+		return promotion, nil
+	}
+	before := auditSnapshot(promotion)
+	if promotion.StartAt.After(now) {
+		promotion.StartAt = now
+	}
+	promotion.EndAt = now
+	if err := uc.promotionRepo.Update(promotion); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityPromotion, promotion.ID, before, auditSnapshot(promotion))
+
+	return promotion, nil
+}
+
+// GetFlashSales lists the products of running flash sales, each once with
+// the flash sale it is listed for as its Promotion.
+func (uc *promotionUsecase) GetFlashSales(limit, page int) (*FlashSaleResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	sales, err := uc.promotionRepo.GetRunningFlashSales(time.Now(), limit, page)
+	if err != nil {
+		return nil, err
+	}
+	if len(sales) == 0 {
+		return &FlashSaleResult{Page: page, Limit: limit, Data: []domain.Produk{}}, nil
+	}
+	ids := make([]uint, 0, len(sales))
+	saleByProduct := make(map[uint]*domain.Promotion, len(sales))
+	for i := range sales {
+		ids = append(ids, *sales[i].ProdukID)
+		saleByProduct[*sales[i].ProdukID] = &sales[i]
+	}
+
+	products, err := uc.productRepo.GetAll(len(ids), 1, repository.ProductFilter{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].Promotion = saleByProduct[products[i].ID]
+	}
+
+	return &FlashSaleResult{Page: page, Limit: limit, Data: products}, nil
+}
+
+// validateDiscount checks that a discount cuts a consumer price of harga,
+// when known, without making it free.
+func validateDiscount(discountType string, value, harga int) error {
+	switch {
+	case !slices.Contains(domain.PromotionTypes, discountType):
+		return ErrInvalidPromotionDiscount
+	case discountType == domain.PromotionTypePercent && (value < 1 || value > 99):
+		return ErrInvalidPromotionDiscount
+	case discountType == domain.PromotionTypePrice && (value < 1 || (harga > 0 && value >= harga)):
+		return ErrInvalidPromotionDiscount
+	}
+	return nil
+}
+
+// promotions finds the running promotion products sell at.
+type promotions struct {
+	repo repository.PromotionRepository
+}
+
+func newPromotions(repo repository.PromotionRepository) *promotions {
+	return &promotions{repo: repo}
+}
+
+// attach sets the Promotion of each product to its best running promotion.
+func (p *promotions) attach(products []domain.Produk) error {
+	ptrs := make([]*domain.Produk, len(products))
+	for i := range products {
+		ptrs[i] = &products[i]
+	}
+	return p.attachTo(ptrs)
+}
+
+// attachTo is attach for products held by pointer. A product is priced
+// at its base consumer price; its variants, which only percent promotions
+// cover, rank the same way.
+func (p *promotions) attachTo(products []*domain.Produk) error {
+	now := time.Now()
+	running, err := p.repo.GetRunningFor(products, now)
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		harga, _ := strconv.Atoi(product.HargaKonsumen)
+		product.Promotion = bestPromotion(product, harga, running, now)
+	}
+	return nil
+}
+
+// running returns the promotions running at now that cover product.
+func (p *promotions) running(product *domain.Produk, now time.Time) ([]domain.Promotion, error) {
+	return p.repo.GetRunningFor([]*domain.Produk{product}, now)
+}
+
+// bestPromotion returns, of promos, the one running at now that gives
+// product, or its variant, priced at harga the lowest consumer price, or
+// nil if none covers it.
+func bestPromotion(product *domain.Produk, harga int, promos []domain.Promotion, now time.Time) *domain.Promotion {
+	ranked := rankPromotions(product, harga, promos, now)
+	if len(ranked) == 0 {
+		return nil
+	}
+	// copied, so products do not share one promotion
+	best := *ranked[0]
+	return &best
+}
+
+// rankPromotions returns the promos running at now that cover product,
+// lowest price at harga first.
+func rankPromotions(product *domain.Produk, harga int, promos []domain.Promotion, now time.Time) []*domain.Promotion {
+	var ranked []*domain.Promotion
+	for i := range promos {
+		if promos[i].Applies(product) && promos[i].Running(now) {
+			ranked = append(ranked, &promos[i])
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b *domain.Promotion) int {
+		return a.Price(harga) - b.Price(harga)
+	})
+	return ranked
+}
+
+// promotionAllowance tracks how many more units one buyer may get at each
+// promotion while an order is priced, within the promotions' sale stock
+// and per-user limits.
+type promotionAllowance struct {
+	repo   repository.PromotionRepository
+	userID uint
+	// left is -1 for no limit
+	left map[uint]int
+}
+
+// allowance starts tracking what userID may still buy at promotions.
+func (p *promotions) allowance(userID uint) *promotionAllowance {
+	return &promotionAllowance{repo: p.repo, userID: userID, left: make(map[uint]int)}
+}
+
+// load looks up the units left at those of promos not seen yet.
+func (a *promotionAllowance) load(promos []domain.Promotion) error {
+	var perUser []uint
+	for i := range promos {
+		promo := &promos[i]
+		if _, ok := a.left[promo.ID]; ok {
+			continue
+		}
+		a.left[promo.ID] = -1
+		if promo.SaleStock > 0 {
+			a.left[promo.ID] = max(promo.SaleStock-promo.Sold, 0)
+		}
+		if promo.MaxPerUser > 0 {
+			perUser = append(perUser, promo.ID)
+		}
+	}
+	if len(perUser) == 0 {
+		return nil
+	}
+	bought, err := a.repo.GetBoughtByUser(a.userID, perUser)
+	if err != nil {
+		return err
+	}
+	for i := range promos {
+		promo := &promos[i]
+		if !slices.Contains(perUser, promo.ID) {
+			continue
+		}
+		userLeft := max(promo.MaxPerUser-bought[promo.ID], 0)
+		if a.left[promo.ID] < 0 || userLeft < a.left[promo.ID] {
+			a.left[promo.ID] = userLeft
+		}
+	}
+	return nil
+}
+
+// take reserves up to n units at promo and returns how many it got.
+func (a *promotionAllowance) take(promo *domain.Promotion, n int) int {
+	left := a.left[promo.ID]
+	if left >= 0 {
+		n = min(n, left)
+		a.left[promo.ID] = left - n
+	}
+	return n
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const reviewMaxComment = 2000
+
+// CreateReviewInput represents payload to review a purchased detail_trx line.
+type CreateReviewInput struct {
+	Rating  int
+	Comment string
+}
+
+// ReviewListResult wraps a paginated list of a product's reviews with its
+// rating summary. Distribution maps each rating (1–5) to its review count.
+type ReviewListResult struct {
+	Page         int
+	Limit        int
+	RatingAvg    float64
+	RatingCount  int
+	Distribution map[int]int64
+	Data         []domain.Review
+}
+
+// ReviewUsecase defines product review business logic.
+type ReviewUsecase interface {
+	Create(actor Actor, detailTrxID uint, in CreateReviewInput, photoFilenames []string) (*domain.Review, error)
+	GetByProduct(actor Actor, productID uint, rating, limit, page int) (*ReviewListResult, error)
+	Reply(actor Actor, reviewID uint, reply string) (*domain.Review, error)
+}
+
+type reviewUsecase struct {
+	reviewRepo  repository.ReviewRepository
+	trxRepo     repository.TrxRepository
+	productRepo repository.ProductRepository
+	access      *tokoAccess
+	audit       *auditor
+}
+
+// NewReviewUsecase creates a new ReviewUsecase.
+func NewReviewUsecase(reviewRepo repository.ReviewRepository, trxRepo repository.TrxRepository, productRepo repository.ProductRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository) ReviewUsecase {
+	return &reviewUsecase{
+		reviewRepo:  reviewRepo,
+		trxRepo:     trxRepo,
+		productRepo: productRepo,
+		access:      newTokoAccess(memberRepo),
+		audit:       newAuditor(auditRepo),
+	}
+}
+
+var (
+	// ErrReviewNotFound indicates review not found.
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrDetailTrxNotFound indicates the purchased line does not exist or belongs to another user.
+	ErrDetailTrxNotFound = errors.New("detail trx not found")
+	// ErrReviewNotAllowed indicates the line has not been shipped yet.
+	ErrReviewNotAllowed = errors.New("pesanan belum dikirim, belum bisa diulas")
+	// ErrReviewExists indicates the line already has a review.
+	ErrReviewExists = errors.New("pesanan ini sudah diulas")
+	// ErrInvalidReview indicates a rating outside 1–5 or a too long comment.
+	ErrInvalidReview = errors.New("rating harus 1 sampai 5 dan ulasan maksimal 2000 karakter")
+)
+
+// Create reviews a detail_trx line the actor bought. A line can be
+// reviewed once it has been shipped, and only once.
+func (uc *reviewUsecase) Create(actor Actor, detailTrxID uint, in CreateReviewInput, photoFilenames []string) (*domain.Review, error) {
+	comment := strings.TrimSpace(in.Comment)
+	if in.Rating < 1 || in.Rating > 5 || utf8.RuneCountInString(comment) > reviewMaxComment {
+		return nil, ErrInvalidReview
+	}
+
+	detail, err := uc.trxRepo.GetDetailForUser(actor.UserID, detailTrxID)
+	if err != nil {
+		return nil, err
+	}
+	if detail == nil {
+		return nil, ErrDetailTrxNotFound
+	}
+	if detail.ShippedAt == nil {
+		return nil, ErrReviewNotAllowed
+	}
+
+	review := &domain.Review{
+		DetailTrxID: detail.ID,
+		ProdukID:    detail.LogProduk.ProdukID,
+		TokoID:      detail.TokoID,
+		UserID:      actor.UserID,
+		Rating:      in.Rating,
+		Comment:     comment,
+		Photos:      strings.Join(photoFilenames, ","),
+	}
+	created, err := uc.reviewRepo.Create(review)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrReviewExists
+	}
+
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityReview, review.ID, nil, auditSnapshot(review))
+
+	return uc.get(review.ID)
+}
+
+// GetByProduct lists the reviews of a published product; members of its
+// toko may list them whatever the product's status.
+func (uc *reviewUsecase) GetByProduct(actor Actor, productID uint, rating, limit, page int) (*ReviewListResult, error) {
+	product, err := uc.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	visible, err := uc.access.canSee(actor, product, false)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrProductNotFound
+	}
+
+	reviews, err := uc.reviewRepo.GetAllByProduct(product.ID, rating, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	distribution, err := uc.reviewRepo.CountByRating(product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	return &ReviewListResult{
+		Page:         page,
+		Limit:        limit,
+		RatingAvg:    product.RatingAvg,
+		RatingCount:  product.RatingCount,
+		Distribution: distribution,
+		Data:         reviews,
+	}, nil
+}
+
+// Reply sets the toko's answer to a review of one of its products,
+// replacing any earlier reply.
+func (uc *reviewUsecase) Reply(actor Actor, reviewID uint, reply string) (*domain.Review, error) {
+	reply = strings.TrimSpace(reply)
+	if reply == "" || utf8.RuneCountInString(reply) > reviewMaxComment {
+		return nil, errors.New("balasan wajib diisi (maksimal 2000 karakter)")
+	}
+
+	review, err := uc.get(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	tokoID, err := actingToko(actor, review.TokoID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapProducts); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	before := auditSnapshot(review)
+	if err := uc.reviewRepo.UpdateReply(review.ID, reply, actor.UserID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	updated, err := uc.get(review.ID)
+	if err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityReview, review.ID, before, auditSnapshot(updated))
+
+	return updated, nil
+}
+
+func (uc *reviewUsecase) get(id uint) (*domain.Review, error) {
+	review, err := uc.reviewRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
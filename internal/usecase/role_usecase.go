@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// AssignRolesInput represents payload to replace a user's roles.
+type AssignRolesInput struct {
+	Roles []string `json:"roles"`
+}
+
+// RoleUsecase handles roles, permissions and role assignment.
+type RoleUsecase interface {
+	EnsureDefaults() error
+	GetAll() ([]domain.Role, error)
+	GetUserRoles(userID uint) (*domain.User, error)
+	AssignRoles(actor Actor, actorPermissions []string, userID uint, in AssignRolesInput) (*domain.User, error)
+}
+
+type roleUsecase struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+	audit    *auditor
+}
+
+// NewRoleUsecase creates a new RoleUsecase.
+func NewRoleUsecase(roleRepo repository.RoleRepository, userRepo repository.UserRepository, auditRepo repository.AuditLogRepository) RoleUsecase {
+	return &roleUsecase{roleRepo: roleRepo, userRepo: userRepo, audit: newAuditor(auditRepo)}
+}
+
+var (
+	// ErrUnknownRole indicates a role name that does not exist.
+	ErrUnknownRole = errors.New("role tidak dikenal")
+	// ErrRoleNotAssignable indicates the actor may not grant the role.
+	ErrRoleNotAssignable = errors.New("role super_admin hanya dapat diberikan oleh super admin")
+)
+
+// EnsureDefaults seeds the default roles and, the first time only, gives
+// users that predate roles the roles matching their is_admin flag.
+func (uc *roleUsecase) EnsureDefaults() error {
+	if err := uc.roleRepo.Seed(domain.DefaultRolePermissions); err != nil {
+		return err
+	}
+	return uc.roleRepo.BackfillUserRoles(domain.DefaultUserRoles, domain.RoleSuperAdmin)
+}
+
+func (uc *roleUsecase) GetAll() ([]domain.Role, error) {
+	return uc.roleRepo.GetAll()
+}
+
+func (uc *roleUsecase) GetUserRoles(userID uint) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// AssignRoles replaces the user's roles. The user's existing tokens are
+// revoked so new claims take effect on the next login.
+func (uc *roleUsecase) AssignRoles(actor Actor, actorPermissions []string, userID uint, in AssignRolesInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	names := make([]string, 0, len(in.Roles))
+	seen := make(map[string]bool)
+	for _, name := range in.Roles {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	roles, err := uc.roleRepo.GetByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(names) {
+		return nil, ErrUnknownRole
+	}
+
+	isSuperAdmin := false
+	for _, role := range roles {
+		if role.Name == domain.RoleSuperAdmin {
+			isSuperAdmin = true
+		}
+	}
+	if (isSuperAdmin || user.IsAdmin) && !domain.HasPermission(actorPermissions, domain.PermAll) {
+		return nil, ErrRoleNotAssignable
+	}
+
+	before := userRolesSnapshot(user)
+	if err := uc.roleRepo.SetUserRoles(user.ID, roles); err != nil {
+		return nil, err
+	}
+
+	user.IsAdmin = isSuperAdmin
+	user.SessionVersion++
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	user.Roles = roles
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, userRolesSnapshot(user))
+	return user, nil
+}
+
+// userRolesSnapshot is the user's audit snapshot plus the names of its
+// roles, which auditSnapshot leaves out as a relation.
+func userRolesSnapshot(user *domain.User) map[string]interface{} {
+	fields := auditSnapshot(user)
+	fields["Roles"] = user.RoleNames()
+	return fields
+}
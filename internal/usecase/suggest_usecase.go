@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	// maxSuggestions is the most suggestions returned, and kept per trie node.
+	maxSuggestions = 10
+	// maxSuggestKeyRunes is how deep names are indexed; longer prefixes
+	// only match names whose first maxSuggestKeyRunes runes match.
+	maxSuggestKeyRunes = 30
+)
+
+// Suggestion is a name offered while the user types a search query.
+// ID is the category or toko; it is 0 for product names, which are merged
+// across products sharing a name.
+type Suggestion struct {
+	Type string
+	ID   uint
+	Text string
+	Sold int
+}
+
+// SuggestUsecase defines search-as-you-type suggestions.
+type SuggestUsecase interface {
+	Suggest(prefix string, limit int) ([]Suggestion, error)
+	HandleEvent(e event.Event) error
+}
+
+type suggestUsecase struct {
+	repo            repository.SuggestionRepository
+	refreshInterval time.Duration
+
+	mu      sync.RWMutex
+	trie    *suggestTrie
+	builtAt time.Time
+	stale   bool
+}
+
+// NewSuggestUsecase creates a new SuggestUsecase. Suggestions are served
+// from an in-memory trie, rebuilt from the DB after refreshInterval or once
+// a product changes; the interval also picks up changes made through other
+// instances.
+func NewSuggestUsecase(repo repository.SuggestionRepository, refreshInterval time.Duration) SuggestUsecase {
+	return &suggestUsecase{repo: repo, refreshInterval: refreshInterval}
+}
+
+// Suggest returns the most sold product, category and toko names with a
+// word starting with prefix.
+func (uc *suggestUsecase) Suggest(prefix string, limit int) ([]Suggestion, error) {
+	key := normalizeSuggestText(prefix)
+	if key == "" {
+		return []Suggestion{}, nil
+	}
+	if limit <= 0 || limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	trie, err := uc.current()
+	if err != nil {
+		return nil, err
+	}
+	return trie.lookup(key, limit), nil
+}
+
+// HandleEvent marks the trie stale when a product changes; it is rebuilt
+// on the next request, so a burst of changes costs one rebuild.
+func (uc *suggestUsecase) HandleEvent(e event.Event) error {
+	switch e.Type {
+	case domain.EventProductCreated, domain.EventProductUpdated, domain.EventProductDeleted:
+		uc.mu.Lock()
+		uc.stale = true
+		uc.mu.Unlock()
+	}
+	return nil
+}
+
+// current returns the trie, rebuilding it first when stale or expired.
+func (uc *suggestUsecase) current() (*suggestTrie, error) {
+	uc.mu.RLock()
+	trie, fresh := uc.trie, uc.isFresh()
+	uc.mu.RUnlock()
+	if fresh {
+		return trie, nil
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	// Another request may have rebuilt it meanwhile
+	if uc.isFresh() {
+		return uc.trie, nil
+	}
+	sources, err := uc.repo.GetSources()
+	if err != nil {
+		return nil, err
+	}
+	uc.trie = buildSuggestTrie(sources)
+	uc.builtAt = time.Now()
+	uc.stale = false
+	return uc.trie, nil
+}
+
+// isFresh must be called with mu held.
+func (uc *suggestUsecase) isFresh() bool {
+	return uc.trie != nil && !uc.stale && time.Since(uc.builtAt) < uc.refreshInterval
+}
+
+type suggestNode struct {
+	children map[rune]*suggestNode
+	// top holds the indexes of the best entries below this node, best first.
+	top []int
+}
+
+type suggestTrie struct {
+	root    *suggestNode
+	entries []Suggestion
+}
+
+// buildSuggestTrie indexes every word suffix of each name ("sepatu lari",
+// "lari") so a prefix matches any word, not only the first one.
+func buildSuggestTrie(sources []repository.SuggestionSource) *suggestTrie {
+	t := &suggestTrie{root: &suggestNode{}}
+
+	products := make(map[string]int)
+	for _, src := range sources {
+		text := strings.TrimSpace(src.Text)
+		if text == "" {
+			continue
+		}
+		if src.Type == domain.SuggestTypeProduct {
+			key := normalizeSuggestText(text)
+			if i, ok := products[key]; ok {
+				t.entries[i].Sold += src.Sold
+				continue
+			}
+			products[key] = len(t.entries)
+			t.entries = append(t.entries, Suggestion{Type: src.Type, Text: text, Sold: src.Sold})
+			continue
+		}
+		t.entries = append(t.entries, Suggestion{Type: src.Type, ID: src.ID, Text: text, Sold: src.Sold})
+	}
+
+	sort.SliceStable(t.entries, func(i, j int) bool {
+		a, b := t.entries[i], t.entries[j]
+		if a.Sold != b.Sold {
+			return a.Sold > b.Sold
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		return a.Text < b.Text
+	})
+
+	// Entries are inserted best first, so each node's top list stays sorted
+	for i, entry := range t.entries {
+		words := strings.Fields(normalizeSuggestText(entry.Text))
+		for w := range words {
+			t.insert(strings.Join(words[w:], " "), i)
+		}
+	}
+	return t
+}
+
+func (t *suggestTrie) insert(key string, entry int) {
+	node := t.root
+	depth := 0
+	for _, r := range key {
+		if depth == maxSuggestKeyRunes {
+			break
+		}
+		depth++
+		child := node.children[r]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[rune]*suggestNode)
+			}
+			child = &suggestNode{}
+			node.children[r] = child
+		}
+		node = child
+		// The same entry reaches a node again through another of its words
+		if n := len(node.top); n < maxSuggestions && (n == 0 || node.top[n-1] != entry) {
+			node.top = append(node.top, entry)
+		}
+	}
+}
+
+func (t *suggestTrie) lookup(key string, limit int) []Suggestion {
+	node := t.root
+	depth := 0
+	for _, r := range key {
+		if depth == maxSuggestKeyRunes {
+			break
+		}
+		depth++
+		if node = node.children[r]; node == nil {
+			return []Suggestion{}
+		}
+	}
+
+	top := node.top
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	suggestions := make([]Suggestion, len(top))
+	for i, entry := range top {
+		suggestions[i] = t.entries[entry]
+	}
+	return suggestions
+}
+
+// normalizeSuggestText lowercases text and collapses its whitespace.
+func normalizeSuggestText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
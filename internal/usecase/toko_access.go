@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+var (
+	// ErrTokoNotFound indicates the user is not a member of the toko (or of any toko).
+	ErrTokoNotFound = errors.New("toko not found for user")
+	// ErrTokoAccessDenied indicates the user's toko role lacks the needed capability.
+	ErrTokoAccessDenied = errors.New("role toko tidak memiliki akses")
+)
+
+// tokoAccess resolves the toko a user acts for from toko_member.
+type tokoAccess struct {
+	memberRepo repository.TokoMemberRepository
+}
+
+func newTokoAccess(memberRepo repository.TokoMemberRepository) *tokoAccess {
+	return &tokoAccess{memberRepo: memberRepo}
+}
+
+// resolve returns the user's membership granting capability in tokoID.
+// When tokoID is 0 the first such membership is used, owned tokos first.
+func (a *tokoAccess) resolve(userID, tokoID uint, capability string) (*domain.TokoMember, error) {
+	if tokoID != 0 {
+		member, err := a.memberRepo.GetByTokoAndUser(tokoID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			return nil, ErrTokoNotFound
+		}
+		if !domain.TokoRoleAllows(member.Role, capability) {
+			return nil, ErrTokoAccessDenied
+		}
+		return member, nil
+	}
+
+	members, err := a.memberRepo.GetAllByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrTokoNotFound
+	}
+	for i := range members {
+		if domain.TokoRoleAllows(members[i].Role, capability) {
+			return &members[i], nil
+		}
+	}
+	return nil, ErrTokoAccessDenied
+}
+
+// canSee reports whether actor may see product: a published one, not
+// archived when onSale, or any product of a toko actor is a member of.
+func (a *tokoAccess) canSee(actor Actor, product *domain.Produk, onSale bool) (bool, error) {
+	if product.Status == domain.ProductStatusPublished && (!onSale || product.ArchivedAt == nil) {
+		return true, nil
+	}
+	if actor.UserID == 0 {
+		return false, nil
+	}
+	member, err := a.memberRepo.GetByTokoAndUser(product.TokoID, actor.UserID)
+	if err != nil {
+		return false, err
+	}
+	return member != nil, nil
+}
+
+// actingToko narrows tokoID to the toko the actor's API key is bound to.
+func actingToko(actor Actor, tokoID uint) (uint, error) {
+	if actor.TokoID == 0 {
+		return tokoID, nil
+	}
+	if tokoID != 0 && tokoID != actor.TokoID {
+		return 0, ErrTokoAccessDenied
+	}
+	return actor.TokoID, nil
+}
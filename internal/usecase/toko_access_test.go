@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// fakeMemberRepo holds the memberships of one toko, by user ID.
+type fakeMemberRepo struct {
+	repository.TokoMemberRepository
+	tokoID  uint
+	members map[uint]string
+}
+
+func (r *fakeMemberRepo) GetByTokoAndUser(tokoID, userID uint) (*domain.TokoMember, error) {
+	role, ok := r.members[userID]
+	if !ok || tokoID != r.tokoID {
+		return nil, nil
+	}
+	return &domain.TokoMember{TokoID: tokoID, UserID: userID, Role: role}, nil
+}
+
+func TestTokoAccessCanSee(t *testing.T) {
+	archived := time.Now()
+	access := newTokoAccess(&fakeMemberRepo{tokoID: 1, members: map[uint]string{7: domain.TokoRoleOrderHandler}})
+
+	tests := []struct {
+		name     string
+		userID   uint
+		status   string
+		archived bool
+		onSale   bool
+		want     bool
+	}{
+		{"published to anyone", 0, domain.ProductStatusPublished, false, true, true},
+		{"archived page to anyone", 0, domain.ProductStatusPublished, true, false, true},
+		{"archived not on sale", 9, domain.ProductStatusPublished, true, true, false},
+		{"draft to anonymous", 0, domain.ProductStatusDraft, false, false, false},
+		{"draft to another user", 9, domain.ProductStatusDraft, false, false, false},
+		{"draft to a member", 7, domain.ProductStatusDraft, false, false, true},
+		{"archived to a member on sale", 7, domain.ProductStatusPublished, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := &domain.Produk{ID: 3, TokoID: 1, Status: tt.status}
+			if tt.archived {
+				product.ArchivedAt = &archived
+			}
+			got, err := access.canSee(Actor{UserID: tt.userID}, product, tt.onSale)
+			if err != nil {
+				t.Fatalf("canSee: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("canSee = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
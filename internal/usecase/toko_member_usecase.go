@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/notifier"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+	"gorm.io/gorm"
+)
+
+const tokoInvitationTTL = 7 * 24 * time.Hour
+
+// InviteMemberInput represents payload to invite a user to a toko by no_telp or email.
+type InviteMemberInput struct {
+	NoTelp string `json:"no_telp"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// UpdateMemberInput represents payload to change a member's toko role.
+type UpdateMemberInput struct {
+	Role string `json:"role"`
+}
+
+// AcceptInvitationInput represents payload to accept a toko invitation.
+type AcceptInvitationInput struct {
+	Token string `json:"token"`
+}
+
+// TokoMemberUsecase handles toko staff membership and invitations.
+type TokoMemberUsecase interface {
+	EnsureOwners() error
+	GetMyMemberships(userID uint) ([]domain.TokoMember, error)
+	GetMembers(userID, tokoID uint) ([]domain.TokoMember, error)
+	Invite(userID, tokoID uint, in InviteMemberInput) (*domain.TokoInvitation, error)
+	AcceptInvitation(userID uint, in AcceptInvitationInput) (*domain.TokoMember, error)
+	UpdateMember(userID, tokoID, memberUserID uint, in UpdateMemberInput) (*domain.TokoMember, error)
+	RemoveMember(userID, tokoID, memberUserID uint) error
+}
+
+type tokoMemberUsecase struct {
+	memberRepo     repository.TokoMemberRepository
+	invitationRepo repository.TokoInvitationRepository
+	userRepo       repository.UserRepository
+	access         *tokoAccess
+	notifier       notifier.Notifier
+}
+
+// NewTokoMemberUsecase creates a new TokoMemberUsecase.
+func NewTokoMemberUsecase(memberRepo repository.TokoMemberRepository, invitationRepo repository.TokoInvitationRepository, userRepo repository.UserRepository, n notifier.Notifier) TokoMemberUsecase {
+	return &tokoMemberUsecase{
+		memberRepo:     memberRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		access:         newTokoAccess(memberRepo),
+		notifier:       n,
+	}
+}
+
+var (
+	// ErrInvalidTokoRole indicates an unknown or non-assignable toko role.
+	ErrInvalidTokoRole = errors.New("role harus admin, product_editor atau order_handler")
+	// ErrInviteTargetRequired indicates neither no_telp nor email was given.
+	ErrInviteTargetRequired = errors.New("no_telp atau email wajib diisi")
+	// ErrInvalidInvitation indicates a wrong, expired, used or misaddressed invitation.
+	ErrInvalidInvitation = errors.New("undangan tidak valid atau kadaluarsa")
+	// ErrMemberNotFound indicates the user is not a member of the toko.
+	ErrMemberNotFound = errors.New("member not found")
+	// ErrOwnerImmutable indicates the owner membership cannot be changed or removed.
+	ErrOwnerImmutable = errors.New("owner toko tidak dapat diubah atau dihapus")
+)
+
+// assignableTokoRole reports whether role may be granted through invitations
+// or role changes; ownership is never transferred this way.
+func assignableTokoRole(role string) bool {
+	return role != domain.TokoRoleOwner && domain.TokoRoleAllows(role, "")
+}
+
+// EnsureOwners gives every existing toko owner an owner membership.
+func (uc *tokoMemberUsecase) EnsureOwners() error {
+	return uc.memberRepo.BackfillOwners()
+}
+
+func (uc *tokoMemberUsecase) GetMyMemberships(userID uint) ([]domain.TokoMember, error) {
+	return uc.memberRepo.GetAllByUser(userID)
+}
+
+func (uc *tokoMemberUsecase) GetMembers(userID, tokoID uint) ([]domain.TokoMember, error) {
+	if _, err := uc.access.resolve(userID, tokoID, ""); err != nil {
+		return nil, err
+	}
+	return uc.memberRepo.GetAllByToko(tokoID)
+}
+
+func (uc *tokoMemberUsecase) Invite(userID, tokoID uint, in InviteMemberInput) (*domain.TokoInvitation, error) {
+	if !assignableTokoRole(in.Role) {
+		return nil, ErrInvalidTokoRole
+	}
+
+	channel, target := notifier.ChannelSMS, in.NoTelp
+	if target == "" {
+		channel, target = notifier.ChannelEmail, in.Email
+	}
+	if target == "" {
+		return nil, ErrInviteTargetRequired
+	}
+
+	member, err := uc.access.resolve(userID, tokoID, domain.TokoCapMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := helper.GenerateToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &domain.TokoInvitation{
+		TokoID:    member.TokoID,
+		InvitedBy: userID,
+		Channel:   channel,
+		Target:    target,
+		Role:      in.Role,
+		TokenHash: helper.HashToken(token),
+		ExpiresAt: time.Now().Add(tokoInvitationTTL),
+	}
+	if err := uc.invitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	if err := uc.notifier.Send(notifier.Message{
+		Channel: channel,
+		To:      target,
+		Subject: "Undangan toko " + member.Toko.NamaToko,
+		Body: fmt.Sprintf("Anda diundang menjadi %s di toko %s. Kode undangan: %s (berlaku 7 hari).",
+			in.Role, member.Toko.NamaToko, token),
+	}); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation joins the toko; the logged-in user's no_telp or email
+// must match the invitation target.
+func (uc *tokoMemberUsecase) AcceptInvitation(userID uint, in AcceptInvitationInput) (*domain.TokoMember, error) {
+	invitation, err := uc.invitationRepo.GetByTokenHash(helper.HashToken(in.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvalidInvitation
+	}
+
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if target, _ := targetFor(user, invitation.Channel); target != invitation.Target {
+		return nil, ErrInvalidInvitation
+	}
+
+	member, err := uc.memberRepo.GetByTokoAndUser(invitation.TokoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case member == nil:
+		member = &domain.TokoMember{TokoID: invitation.TokoID, UserID: userID, Role: invitation.Role}
+		if err := uc.memberRepo.Create(member); err != nil {
+			return nil, err
+		}
+	case member.Role == domain.TokoRoleOwner:
+		return nil, ErrOwnerImmutable
+	default:
+		member.Role = invitation.Role
+		if err := uc.memberRepo.Update(member); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	invitation.AcceptedAt = &now
+	invitation.AcceptedBy = &userID
+	if err := uc.invitationRepo.Update(invitation); err != nil {
+		return nil, err
+	}
+
+	member.Toko = invitation.Toko
+	return member, nil
+}
+
+func (uc *tokoMemberUsecase) UpdateMember(userID, tokoID, memberUserID uint, in UpdateMemberInput) (*domain.TokoMember, error) {
+	if !assignableTokoRole(in.Role) {
+		return nil, ErrInvalidTokoRole
+	}
+	if _, err := uc.access.resolve(userID, tokoID, domain.TokoCapMembers); err != nil {
+		return nil, err
+	}
+
+	member, err := uc.memberRepo.GetByTokoAndUser(tokoID, memberUserID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, ErrMemberNotFound
+	}
+	if member.Role == domain.TokoRoleOwner {
+		return nil, ErrOwnerImmutable
+	}
+
+	member.Role = in.Role
+	if err := uc.memberRepo.Update(member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member; members may also remove themselves.
+func (uc *tokoMemberUsecase) RemoveMember(userID, tokoID, memberUserID uint) error {
+	capability := domain.TokoCapMembers
+	if userID == memberUserID {
+		capability = ""
+	}
+	if _, err := uc.access.resolve(userID, tokoID, capability); err != nil {
+		return err
+	}
+
+	member, err := uc.memberRepo.GetByTokoAndUser(tokoID, memberUserID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return ErrMemberNotFound
+	}
+	if member.Role == domain.TokoRoleOwner {
+		return ErrOwnerImmutable
+	}
+
+	if err := uc.memberRepo.Delete(tokoID, memberUserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
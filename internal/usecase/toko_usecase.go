@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
 )
@@ -10,48 +12,71 @@ type TokoListResult struct {
 	Page  int           `json:"page"`
 	Limit int           `json:"limit"`
 	Data  []domain.Toko `json:"data"`
+	PageCursors
 }
 
 // UpdateTokoInput represents fields allowed to update store profile.
+// TokoID is optional; by default the user's own toko is updated.
 type UpdateTokoInput struct {
+	TokoID   uint   `json:"-"`
 	NamaToko string `json:"nama_toko"`
 	UrlFoto  string `json:"url_foto"`
 }
 
 // TokoUsecase defines toko-related business logic.
 type TokoUsecase interface {
-	GetAll(limit, page int, nama string) (*TokoListResult, error)
+	GetAll(limit, page int, nama, cursor string) (*TokoListResult, error)
 	GetByID(id uint) (*domain.Toko, error)
 	GetMyStore(userID uint) (*domain.Toko, error)
-	UpdateMyStore(userID uint, in UpdateTokoInput) (*domain.Toko, error)
+	UpdateMyStore(actor Actor, in UpdateTokoInput) (*domain.Toko, error)
 }
 
 type tokoUsecase struct {
 	tokoRepo repository.TokoRepository
+	access   *tokoAccess
+	audit    *auditor
 }
 
 // NewTokoUsecase creates a new TokoUsecase.
-func NewTokoUsecase(tokoRepo repository.TokoRepository) TokoUsecase {
-	return &tokoUsecase{tokoRepo: tokoRepo}
+func NewTokoUsecase(tokoRepo repository.TokoRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository) TokoUsecase {
+	return &tokoUsecase{tokoRepo: tokoRepo, access: newTokoAccess(memberRepo), audit: newAuditor(auditRepo)}
 }
 
-func (uc *tokoUsecase) GetAll(limit, page int, nama string) (*TokoListResult, error) {
+// GetAll lists tokos by ID. A cursor from a previous page replaces page.
+func (uc *tokoUsecase) GetAll(limit, page int, nama, cursor string) (*TokoListResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if page <= 0 {
 		page = 1
 	}
-
-	tokos, err := uc.tokoRepo.GetAll(limit, page, nama)
+	keyset, err := decodeKeyset("toko", cursor)
 	if err != nil {
 		return nil, err
 	}
 
+	var tokos []domain.Toko
+	var hasNext, hasPrev bool
+	if keyset != nil {
+		if tokos, err = uc.tokoRepo.GetAll(limit+1, 1, nama, keyset); err != nil {
+			return nil, err
+		}
+		tokos, hasNext, hasPrev = keysetPage(tokos, limit, keyset)
+	} else {
+		if tokos, err = uc.tokoRepo.GetAll(limit, page, nama, nil); err != nil {
+			return nil, err
+		}
+		// A full page may be followed by more
+		hasNext, hasPrev = len(tokos) == limit, page > 1
+	}
+
 	return &TokoListResult{
 		Page:  page,
 		Limit: limit,
 		Data:  tokos,
+		PageCursors: pageCursors("toko", tokos, hasNext, hasPrev, func(t *domain.Toko) []string {
+			return idKey(t.ID)
+		}),
 	}, nil
 }
 
@@ -59,18 +84,28 @@ func (uc *tokoUsecase) GetByID(id uint) (*domain.Toko, error) {
 	return uc.tokoRepo.GetByID(id)
 }
 
+// GetMyStore returns the toko the user belongs to, owned tokos first.
 func (uc *tokoUsecase) GetMyStore(userID uint) (*domain.Toko, error) {
-	return uc.tokoRepo.GetByUserID(userID)
+	member, err := uc.access.resolve(userID, 0, "")
+	if err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member.Toko, nil
 }
 
-func (uc *tokoUsecase) UpdateMyStore(userID uint, in UpdateTokoInput) (*domain.Toko, error) {
-	toko, err := uc.tokoRepo.GetByUserID(userID)
+func (uc *tokoUsecase) UpdateMyStore(actor Actor, in UpdateTokoInput) (*domain.Toko, error) {
+	member, err := uc.access.resolve(actor.UserID, in.TokoID, domain.TokoCapSettings)
 	if err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
-	if toko == nil {
-		return nil, nil
-	}
+	toko := &member.Toko
+	before := auditSnapshot(toko)
 
 	if in.NamaToko != "" {
 		toko.NamaToko = in.NamaToko
@@ -82,6 +117,7 @@ func (uc *tokoUsecase) UpdateMyStore(userID uint, in UpdateTokoInput) (*domain.T
 	if err := uc.tokoRepo.Update(toko); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityToko, toko.ID, before, auditSnapshot(toko))
 
 	return toko, nil
 }
@@ -7,12 +7,15 @@ import (
 	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
 )
 
 // TrxItemInput represents a single item in the transaction request.
+// VariantID is required for products sold per variant.
 type TrxItemInput struct {
 	ProductID uint `json:"product_id"`
+	VariantID uint `json:"variant_id"`
 	Kuantitas int  `json:"kuantitas"`
 }
 
@@ -23,22 +26,38 @@ type CreateTrxInput struct {
 	DetailTrx   []TrxItemInput `json:"detail_trx"`
 }
 
+// TrxListResult wraps a cursor-paginated trx list, newest first. Limit is
+// 0 when the whole list was returned.
+type TrxListResult struct {
+	Limit int          `json:"limit"`
+	Data  []domain.Trx `json:"data"`
+	PageCursors
+}
+
 // TrxUsecase defines transaction-related business logic.
 type TrxUsecase interface {
-	GetAll(userID uint) ([]domain.Trx, error)
+	GetAll(userID uint, limit int, cursor string) (*TrxListResult, error)
 	GetByID(userID, trxID uint) (*domain.Trx, error)
-	Create(userID uint, in CreateTrxInput) (*domain.Trx, error)
+	Create(actor Actor, in CreateTrxInput) (*domain.Trx, error)
+	GetTokoOrders(actor Actor, tokoID uint, limit int, cursor string) (*TrxListResult, error)
+	ShipTokoOrder(actor Actor, tokoID, trxID uint, noResi string) (*domain.Trx, error)
 }
 
 type trxUsecase struct {
 	trxRepo     repository.TrxRepository
 	alamatRepo  repository.AlamatRepository
 	productRepo repository.ProductRepository
+	userRepo    repository.UserRepository
+	promos      *promotions
+	access      *tokoAccess
+	audit       *auditor
+	verifyReq   VerificationRequirement
 }
 
-// NewTrxUsecase creates a new TrxUsecase.
-func NewTrxUsecase(trxRepo repository.TrxRepository, alamatRepo repository.AlamatRepository, productRepo repository.ProductRepository) TrxUsecase {
-	return &trxUsecase{trxRepo: trxRepo, alamatRepo: alamatRepo, productRepo: productRepo}
+// NewTrxUsecase creates a new TrxUsecase. verifyReq controls which contact
+// channels a buyer must have verified before checkout.
+func NewTrxUsecase(trxRepo repository.TrxRepository, alamatRepo repository.AlamatRepository, productRepo repository.ProductRepository, userRepo repository.UserRepository, promotionRepo repository.PromotionRepository, memberRepo repository.TokoMemberRepository, auditRepo repository.AuditLogRepository, verifyReq VerificationRequirement) TrxUsecase {
+	return &trxUsecase{trxRepo: trxRepo, alamatRepo: alamatRepo, productRepo: productRepo, userRepo: userRepo, promos: newPromotions(promotionRepo), access: newTokoAccess(memberRepo), audit: newAuditor(auditRepo), verifyReq: verifyReq}
 }
 
 var (
@@ -48,14 +67,31 @@ var (
 	ErrTrxAlamatNotFound = errors.New("alamat pengiriman not found")
 	// ErrTrxProductNotFound indicates one of the products in the trx was not found.
 	ErrTrxProductNotFound = errors.New("product not found")
+	// ErrTrxProductArchived indicates one of the products in the trx was taken off sale.
+	ErrTrxProductArchived = errors.New("produk sudah tidak dijual")
 	// ErrTrxInsufficientStock indicates product stock is insufficient.
-	ErrTrxInsufficientStock = errors.New("insufficient stock")
+	ErrTrxInsufficientStock = repository.ErrInsufficientStock
 	// ErrTrxEmptyDetail indicates empty detail_trx payload.
 	ErrTrxEmptyDetail = errors.New("detail_trx empty")
+	// ErrTrxAlreadyShipped indicates the toko's lines of the trx were already shipped.
+	ErrTrxAlreadyShipped = errors.New("pesanan sudah dikirim")
+	// ErrTrxVariantRequired indicates an item of a product sold per variant without variant_id.
+	ErrTrxVariantRequired = errors.New("variant_id wajib diisi untuk produk bervarian")
+	// ErrTrxVariantNotFound indicates a variant_id that is not a variant of the item's product.
+	ErrTrxVariantNotFound = errors.New("variant not found")
+	// ErrTrxPromotionEnded indicates a promotion that ended while the order was placed.
+	ErrTrxPromotionEnded = repository.ErrPromotionEnded
+	// ErrTrxPromotionSoldOut indicates more units than a promotion's sale stock has left.
+	ErrTrxPromotionSoldOut = repository.ErrPromotionSoldOut
+	// ErrTrxPromotionLimitReached indicates more units than a promotion allows one buyer.
+	ErrTrxPromotionLimitReached = repository.ErrPromotionLimitReached
 )
 
-func (uc *trxUsecase) GetAll(userID uint) ([]domain.Trx, error) {
-	return uc.trxRepo.GetAllByUser(userID)
+// GetAll lists the user's trx; all of them unless a limit or cursor is given.
+func (uc *trxUsecase) GetAll(userID uint, limit int, cursor string) (*TrxListResult, error) {
+	return listTrx("trx:user", limit, cursor, func(n int, keyset *repository.Keyset) ([]domain.Trx, error) {
+		return uc.trxRepo.GetAllByUser(userID, n, keyset)
+	})
 }
 
 func (uc *trxUsecase) GetByID(userID, trxID uint) (*domain.Trx, error) {
@@ -69,7 +105,7 @@ func (uc *trxUsecase) GetByID(userID, trxID uint) (*domain.Trx, error) {
 	return trx, nil
 }
 
-func (uc *trxUsecase) Create(userID uint, in CreateTrxInput) (*domain.Trx, error) {
+func (uc *trxUsecase) Create(actor Actor, in CreateTrxInput) (*domain.Trx, error) {
 	if in.MethodBayar == "" || in.AlamatKirim == 0 {
 		return nil, errors.New("method_bayar and alamat_kirim wajib diisi")
 	}
@@ -77,8 +113,19 @@ func (uc *trxUsecase) Create(userID uint, in CreateTrxInput) (*domain.Trx, error
 		return nil, ErrTrxEmptyDetail
 	}
 
+	user, err := uc.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if err := checkVerified(user, uc.verifyReq); err != nil {
+		return nil, err
+	}
+
 	// Ensure alamat pengiriman belongs to the user
-	alamat, err := uc.alamatRepo.GetByIDForUser(userID, in.AlamatKirim)
+	alamat, err := uc.alamatRepo.GetByIDForUser(actor.UserID, in.AlamatKirim)
 	if err != nil {
 		return nil, err
 	}
@@ -86,27 +133,118 @@ func (uc *trxUsecase) Create(userID uint, in CreateTrxInput) (*domain.Trx, error
 		return nil, ErrTrxAlamatNotFound
 	}
 
+	// A promotion can run out between pricing the order and placing it
+	// when others buy at it meanwhile; the order is then priced again
+	var trx *domain.Trx
+	for attempt := 1; ; attempt++ {
+		trx, err = uc.placeOrder(actor, alamat, in)
+		if err == nil {
+			break
+		}
+		if attempt == maxOrderAttempts || !(errors.Is(err, ErrTrxPromotionEnded) ||
+			errors.Is(err, ErrTrxPromotionSoldOut) || errors.Is(err, ErrTrxPromotionLimitReached)) {
+			return nil, err
+		}
+	}
+
+	// Reload the trx with all relations for response
+	created, err := uc.trxRepo.GetByIDForUser(actor.UserID, trx.ID)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, ErrTrxNotFound
+	}
+
+	return created, nil
+}
+
+// maxOrderAttempts is how often an order is priced before giving up on
+// promotions that keep running out under it.
+const maxOrderAttempts = 3
+
+// placeOrder prices the items of in and stores them as one trx. Units a
+// promotion's sale stock or the buyer's limit at it cannot cover are
+// priced at the next best promotion, and the rest at the normal price,
+// each as a line of its own.
+func (uc *trxUsecase) placeOrder(actor Actor, alamat *domain.Alamat, in CreateTrxInput) (*domain.Trx, error) {
 	var (
 		logs           []domain.LogProduk
 		details        []domain.DetailTrx
+		takes          []repository.StockTake
 		updatedProduct []*domain.Produk
+		usages         []domain.PromotionUsage
+		stockBefore    []map[string]interface{}
+		items          []event.TrxLineItem
 		totalHarga     int
+		err            error
 	)
+	// Items of the same product (e.g. two sizes of one shirt) share one
+	// loaded copy so their stock checks add up; the stock itself is taken
+	// in the DB, see CreateWithDetails.
+	loaded := make(map[uint]*domain.Produk)
+	loadedVariants := make(map[uint]*domain.ProdukVariant)
+	taken := make(map[uint]int)
+	running := make(map[uint][]domain.Promotion)
+	allowance := uc.promos.allowance(actor.UserID)
+	now := time.Now()
 
 	for _, item := range in.DetailTrx {
 		if item.ProductID == 0 || item.Kuantitas <= 0 {
 			return nil, errors.New("product_id dan kuantitas wajib diisi dan > 0")
 		}
 
-		produk, err := uc.productRepo.GetByID(item.ProductID)
-		if err != nil {
-			return nil, err
+		produk, ok := loaded[item.ProductID]
+		if !ok {
+			produk, err = uc.productRepo.GetByID(item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			if produk == nil || produk.Status != domain.ProductStatusPublished {
+				return nil, ErrTrxProductNotFound
+			}
+			if produk.ArchivedAt != nil {
+				return nil, ErrTrxProductArchived
+			}
+			if running[produk.ID], err = uc.promos.running(produk, now); err != nil {
+				return nil, err
+			}
+			if err := allowance.load(running[produk.ID]); err != nil {
+				return nil, err
+			}
+			loaded[produk.ID] = produk
+			stockBefore = append(stockBefore, auditSnapshot(produk))
+			updatedProduct = append(updatedProduct, produk)
 		}
-		if produk == nil {
-			return nil, ErrTrxProductNotFound
+
+		hargaReseller, harga := produk.HargaReseller, produk.HargaKonsumen
+		var variant *domain.ProdukVariant
+		if produk.HasVariants() {
+			if item.VariantID == 0 {
+				return nil, ErrTrxVariantRequired
+			}
+			if variant, ok = loadedVariants[item.VariantID]; !ok {
+				for i := range produk.Variants {
+					if produk.Variants[i].ID == item.VariantID {
+						variant = &produk.Variants[i]
+						break
+					}
+				}
+				if variant == nil {
+					return nil, ErrTrxVariantNotFound
+				}
+				loadedVariants[variant.ID] = variant
+			}
+			if variant.Stok < item.Kuantitas {
+				return nil, ErrTrxInsufficientStock
+			}
+			variant.Stok -= item.Kuantitas
+			hargaReseller, harga = variant.Prices(produk)
+		} else if item.VariantID != 0 {
+			return nil, ErrTrxVariantNotFound
 		}
 
-		hargaKonsumen, err := strconv.Atoi(produk.HargaKonsumen)
+		hargaNormal, err := strconv.Atoi(harga)
 		if err != nil {
 			return nil, fmt.Errorf("invalid harga_konsumen for product %d", produk.ID)
 		}
@@ -115,54 +253,210 @@ func (uc *trxUsecase) Create(userID uint, in CreateTrxInput) (*domain.Trx, error
 			return nil, ErrTrxInsufficientStock
 		}
 
-		lineTotal := hargaKonsumen * item.Kuantitas
-		totalHarga += lineTotal
-
-		// Prepare updated stock
 		produk.Stok = produk.Stok - item.Kuantitas
-		updatedProduct = append(updatedProduct, produk)
-
-		// Prepare log_produk snapshot
-		log := domain.LogProduk{
-			ProdukID:      produk.ID,
-			NamaProduk:    produk.NamaProduk,
-			Slug:          produk.Slug,
-			HargaReseller: produk.HargaReseller,
-			HargaKonsumen: produk.HargaKonsumen,
-			Deskripsi:     produk.Deskripsi,
-			TokoID:        produk.TokoID,
-			CategoryID:    produk.CategoryID,
+		taken[produk.ID] += item.Kuantitas
+		takes = append(takes, repository.StockTake{Product: produk, Variant: variant, Kuantitas: item.Kuantitas})
+
+		// addLine adds kuantitas units of the item at promo's price, or at
+		// the normal price when promo is nil
+		addLine := func(promo *domain.Promotion, kuantitas int) {
+			hargaKonsumen := hargaNormal
+			if promo != nil {
+				hargaKonsumen = promo.Price(hargaNormal)
+				usages = append(usages, domain.PromotionUsage{
+					PromotionID: promo.ID,
+					UserID:      actor.UserID,
+					ProdukID:    produk.ID,
+					Kuantitas:   kuantitas,
+				})
+			}
+			lineTotal := hargaKonsumen * kuantitas
+			totalHarga += lineTotal
+
+			// Prepare log_produk snapshot
+			log := domain.LogProduk{
+				ProdukID:      produk.ID,
+				NamaProduk:    produk.NamaProduk,
+				Slug:          produk.Slug,
+				HargaReseller: hargaReseller,
+				HargaKonsumen: strconv.Itoa(hargaKonsumen),
+				Deskripsi:     produk.Deskripsi,
+				TokoID:        produk.TokoID,
+				CategoryID:    produk.CategoryID,
+			}
+			lineItem := event.TrxLineItem{
+				ProductID:  produk.ID,
+				TokoID:     produk.TokoID,
+				NamaProduk: produk.NamaProduk,
+				Kuantitas:  kuantitas,
+				HargaTotal: lineTotal,
+			}
+			if promo != nil {
+				log.PromotionID = &promo.ID
+				log.HargaKonsumenNormal = harga
+			}
+			if variant != nil {
+				log.VariantID = &variant.ID
+				log.SKU = variant.SKU
+				log.VariantAttributes = variant.Attributes
+				lineItem.VariantID = variant.ID
+				lineItem.SKU = variant.SKU
+			}
+			logs = append(logs, log)
+
+			details = append(details, domain.DetailTrx{
+				TokoID:     produk.TokoID,
+				Kuantitas:  kuantitas,
+				HargaTotal: lineTotal,
+			})
+			items = append(items, lineItem)
 		}
-		logs = append(logs, log)
 
-		detail := domain.DetailTrx{
-			TokoID:     produk.TokoID,
-			Kuantitas:  item.Kuantitas,
-			HargaTotal: lineTotal,
+		// Running promotions price the units they still have for the
+		// buyer, best price at the line's own price first; the order
+		// claims those units
+		rest := item.Kuantitas
+		for _, promo := range rankPromotions(produk, hargaNormal, running[produk.ID], now) {
+			if rest == 0 {
+				break
+			}
+			if n := allowance.take(promo, rest); n > 0 {
+				addLine(promo, n)
+				rest -= n
+			}
+		}
+		if rest > 0 {
+			addLine(nil, rest)
 		}
-		details = append(details, detail)
 	}
 
 	trx := &domain.Trx{
-		UserID:             userID,
+		UserID:             actor.UserID,
 		AlamatPengirimanID: alamat.ID,
 		HargaTotal:         totalHarga,
 		KodeInvoice:        fmt.Sprintf("INV-%d", time.Now().Unix()),
 		MethodBayar:        in.MethodBayar,
 	}
 
-	if err := uc.trxRepo.CreateWithDetails(trx, logs, details, updatedProduct); err != nil {
+	events := func() ([]domain.OutboxEvent, error) {
+		var b event.Batch
+		b.Add(domain.EventTrxCreated, domain.AggregateTrx, trx.ID, event.TrxCreated{
+			TrxID:       trx.ID,
+			UserID:      trx.UserID,
+			KodeInvoice: trx.KodeInvoice,
+			MethodBayar: trx.MethodBayar,
+			HargaTotal:  trx.HargaTotal,
+			Alamat: event.TrxAlamat{
+				NamaPenerima: alamat.NamaPenerima,
+				NoTelp:       alamat.NoTelp,
+				DetailAlamat: alamat.DetailAlamat,
+			},
+			Items: items,
+		})
+		// built once the stock is taken, so Stok is what the DB holds
+		for _, produk := range updatedProduct {
+			b.Add(domain.EventStockChanged, domain.AggregateProduct, produk.ID, event.StockChanged{
+				ProductID:   produk.ID,
+				TokoID:      produk.TokoID,
+				NamaProduk:  produk.NamaProduk,
+				StokSebelum: produk.Stok + taken[produk.ID],
+				Stok:        produk.Stok,
+			})
+		}
+		return b.Events()
+	}
+
+	if err := uc.trxRepo.CreateWithDetails(trx, logs, details, takes, usages, events); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionCreate, AuditEntityTrx, trx.ID, nil, auditSnapshot(trx))
+	for i, produk := range updatedProduct {
+		uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityProduct, produk.ID, stockBefore[i], auditSnapshot(produk))
+	}
+	return trx, nil
+}
+
+// GetTokoOrders lists orders for the toko the user handles orders for.
+// tokoID 0 picks the user's first toko with order access.
+func (uc *trxUsecase) GetTokoOrders(actor Actor, tokoID uint, limit int, cursor string) (*TrxListResult, error) {
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
 		return nil, err
 	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapOrders)
+	if err != nil {
+		return nil, err
+	}
+	return listTrx("trx:toko", limit, cursor, func(n int, keyset *repository.Keyset) ([]domain.Trx, error) {
+		return uc.trxRepo.GetAllByToko(member.TokoID, n, keyset)
+	})
+}
 
-	// Reload the trx with all relations for response
-	created, err := uc.trxRepo.GetByIDForUser(userID, trx.ID)
+func listTrx(scope string, limit int, cursor string, fetch func(n int, keyset *repository.Keyset) ([]domain.Trx, error)) (*TrxListResult, error) {
+	trxs, limit, cursors, err := fetchKeysetPage(scope, limit, cursor, fetch, func(t *domain.Trx) []string {
+		return idKey(t.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
-	if created == nil {
+	return &TrxListResult{Limit: limit, Data: trxs, PageCursors: cursors}, nil
+}
+
+// ShipTokoOrder marks the toko's lines of a trx as shipped with the given
+// tracking number. tokoID 0 picks the user's first toko with order access.
+func (uc *trxUsecase) ShipTokoOrder(actor Actor, tokoID, trxID uint, noResi string) (*domain.Trx, error) {
+	if noResi == "" {
+		return nil, errors.New("no_resi wajib diisi")
+	}
+
+	tokoID, err := actingToko(actor, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	trx, err := uc.trxRepo.GetByIDForToko(member.TokoID, trxID)
+	if err != nil {
+		return nil, err
+	}
+	if trx == nil {
 		return nil, ErrTrxNotFound
 	}
 
-	return created, nil
+	events := func() ([]domain.OutboxEvent, error) {
+		var b event.Batch
+		b.Add(domain.EventOrderShipped, domain.AggregateTrx, trx.ID, event.OrderShipped{
+			TrxID:       trx.ID,
+			UserID:      trx.UserID,
+			TokoID:      member.TokoID,
+			KodeInvoice: trx.KodeInvoice,
+			NoResi:      noResi,
+		})
+		return b.Events()
+	}
+	shippedAt := time.Now()
+	updated, err := uc.trxRepo.MarkShipped(trx.ID, member.TokoID, noResi, shippedAt, events)
+	if err != nil {
+		return nil, err
+	}
+	if updated == 0 {
+		return nil, ErrTrxAlreadyShipped
+	}
+
+	shipped, err := uc.trxRepo.GetByIDForToko(member.TokoID, trx.ID)
+	if err != nil {
+		return nil, err
+	}
+	if shipped == nil {
+		return nil, ErrTrxNotFound
+	}
+	// Shipping changes the toko's detail lines, not the trx row itself
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityTrx, trx.ID,
+		map[string]interface{}{"TokoID": member.TokoID, "NoResi": "", "ShippedAt": nil},
+		map[string]interface{}{"TokoID": member.TokoID, "NoResi": noResi, "ShippedAt": shippedAt})
+
+	return shipped, nil
 }
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+// fakePromotionRepo keeps promotions and what the buyer bought at them in
+// memory. Every running promotion is returned for any product; pricing
+// checks which ones cover it.
+type fakePromotionRepo struct {
+	repository.PromotionRepository
+	promos map[uint]*domain.Promotion
+	bought map[uint]int
+}
+
+func (r *fakePromotionRepo) GetRunningFor(products []*domain.Produk, now time.Time) ([]domain.Promotion, error) {
+	var running []domain.Promotion
+	for _, p := range r.promos {
+		if p.Running(now) {
+			running = append(running, *p)
+		}
+	}
+	// by ID, as promotions of the same price rank in the order given
+	slices.SortFunc(running, func(a, b domain.Promotion) int { return int(a.ID) - int(b.ID) })
+	return running, nil
+}
+
+func (r *fakePromotionRepo) GetBoughtByUser(userID uint, promotionIDs []uint) (map[uint]int, error) {
+	bought := make(map[uint]int)
+	for _, id := range promotionIDs {
+		bought[id] = r.bought[id]
+	}
+	return bought, nil
+}
+
+// fakeCheckoutRepo stores placed orders and claims their promotion units
+// from promos like claimPromotion does, all or nothing. othersBuy runs
+// before every claim, as another buyer's order placed meanwhile.
+type fakeCheckoutRepo struct {
+	repository.TrxRepository
+	promos    *fakePromotionRepo
+	othersBuy func(promos map[uint]*domain.Promotion)
+	attempts  int
+	logs      []domain.LogProduk
+	details   []domain.DetailTrx
+	trx       *domain.Trx
+}
+
+func (r *fakeCheckoutRepo) CreateWithDetails(trx *domain.Trx, logs []domain.LogProduk, details []domain.DetailTrx, takes []repository.StockTake, usages []domain.PromotionUsage, events repository.OutboxFunc) error {
+	r.attempts++
+	if r.othersBuy != nil {
+		r.othersBuy(r.promos.promos)
+	}
+
+	claimed := make(map[uint]int)
+	for _, u := range usages {
+		claimed[u.PromotionID] += u.Kuantitas
+	}
+	now := time.Now()
+	for id, n := range claimed {
+		p := r.promos.promos[id]
+		switch {
+		case now.Before(p.StartAt) || !now.Before(p.EndAt):
+			return repository.ErrPromotionEnded
+		case p.SaleStock > 0 && p.Sold+n > p.SaleStock:
+			return repository.ErrPromotionSoldOut
+		case p.MaxPerUser > 0 && r.promos.bought[id]+n > p.MaxPerUser:
+			return repository.ErrPromotionLimitReached
+		}
+	}
+	for id, n := range claimed {
+		r.promos.promos[id].Sold += n
+		r.promos.bought[id] += n
+	}
+
+	trx.ID = uint(r.attempts)
+	r.trx, r.logs, r.details = trx, logs, details
+	return nil
+}
+
+func (r *fakeCheckoutRepo) GetByIDForUser(userID, trxID uint) (*domain.Trx, error) {
+	if r.trx == nil || r.trx.ID != trxID {
+		return nil, nil
+	}
+	return r.trx, nil
+}
+
+// fakeCatalogRepo serves copies of products, as loading them does.
+type fakeCatalogRepo struct {
+	repository.ProductRepository
+	products map[uint]domain.Produk
+}
+
+func (r *fakeCatalogRepo) GetByID(id uint) (*domain.Produk, error) {
+	p, ok := r.products[id]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+type fakeBuyerRepo struct {
+	repository.UserRepository
+}
+
+func (fakeBuyerRepo) FindByID(id uint) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+type fakeAlamatRepo struct {
+	repository.AlamatRepository
+}
+
+func (fakeAlamatRepo) GetByIDForUser(userID, id uint) (*domain.Alamat, error) {
+	return &domain.Alamat{ID: id, UserID: userID}, nil
+}
+
+type fakeAuditRepo struct {
+	repository.AuditLogRepository
+}
+
+func (fakeAuditRepo) Create(entry *domain.AuditLog) error { return nil }
+
+// checkoutLine is a priced line of an order; promotion 0 is the normal price.
+type checkoutLine struct {
+	promotion uint
+	kuantitas int
+	harga     int
+}
+
+func TestCheckoutPricing(t *testing.T) {
+	const buyer = 5
+	produkID, categoryID := uint(1), uint(2)
+	now := time.Now()
+	period := func(p domain.Promotion) *domain.Promotion {
+		p.TokoID = 1
+		p.StartAt, p.EndAt = now.Add(-time.Hour), now.Add(time.Hour)
+		return &p
+	}
+	// flashSale sells 2 more units at 6000; categorySale 20% off, 3 per buyer
+	flashSale := func() *domain.Promotion {
+		return period(domain.Promotion{ID: 1, ProdukID: &produkID, Type: domain.PromotionTypePrice, Value: 6000, FlashSale: true, SaleStock: 5, Sold: 3})
+	}
+	categorySale := func() *domain.Promotion {
+		return period(domain.Promotion{ID: 2, CategoryID: &categoryID, Type: domain.PromotionTypePercent, Value: 20, MaxPerUser: 3})
+	}
+
+	tests := []struct {
+		name         string
+		promos       []*domain.Promotion
+		bought       map[uint]int
+		othersBuy    func(promos map[uint]*domain.Promotion)
+		kuantitas    int
+		want         []checkoutLine
+		wantTotal    int
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:      "no promotion",
+			kuantitas: 2,
+			want:      []checkoutLine{{0, 2, 10000}},
+			wantTotal: 20000, wantAttempts: 1,
+		},
+		{
+			name:      "best promotion first",
+			promos:    []*domain.Promotion{categorySale(), flashSale()},
+			kuantitas: 1,
+			want:      []checkoutLine{{1, 1, 6000}},
+			wantTotal: 6000, wantAttempts: 1,
+		},
+		{
+			name:      "sale stock caps the flash sale",
+			promos:    []*domain.Promotion{flashSale(), categorySale()},
+			kuantitas: 3,
+			want:      []checkoutLine{{1, 2, 6000}, {2, 1, 8000}},
+			wantTotal: 20000, wantAttempts: 1,
+		},
+		{
+			name:      "per-user limit leaves the rest at the normal price",
+			promos:    []*domain.Promotion{flashSale(), categorySale()},
+			bought:    map[uint]int{2: 1},
+			kuantitas: 6,
+			want:      []checkoutLine{{1, 2, 6000}, {2, 2, 8000}, {0, 2, 10000}},
+			wantTotal: 48000, wantAttempts: 1,
+		},
+		{
+			name:      "per-user limit already reached",
+			promos:    []*domain.Promotion{categorySale()},
+			bought:    map[uint]int{2: 3},
+			kuantitas: 1,
+			want:      []checkoutLine{{0, 1, 10000}},
+			wantTotal: 10000, wantAttempts: 1,
+		},
+		{
+			name:   "flash sale sold out meanwhile is priced again",
+			promos: []*domain.Promotion{flashSale(), categorySale()},
+			othersBuy: func(promos map[uint]*domain.Promotion) {
+				promos[1].Sold = promos[1].SaleStock
+			},
+			kuantitas: 3,
+			want:      []checkoutLine{{2, 3, 8000}},
+			wantTotal: 24000, wantAttempts: 2,
+		},
+		{
+			name:   "flash sale ended meanwhile is priced again",
+			promos: []*domain.Promotion{flashSale()},
+			othersBuy: func(promos map[uint]*domain.Promotion) {
+				promos[1].EndAt = now
+			},
+			kuantitas: 1,
+			want:      []checkoutLine{{0, 1, 10000}},
+			wantTotal: 10000, wantAttempts: 2,
+		},
+		{
+			name: "gives up on a flash sale that keeps selling out",
+			promos: []*domain.Promotion{period(domain.Promotion{
+				ID: 1, ProdukID: &produkID, Type: domain.PromotionTypePrice, Value: 6000, SaleStock: 10, Sold: 3,
+			})},
+			othersBuy: func(promos map[uint]*domain.Promotion) {
+				promos[1].Sold++
+			},
+			kuantitas:    8,
+			wantAttempts: maxOrderAttempts,
+			wantErr:      ErrTrxPromotionSoldOut,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promos := &fakePromotionRepo{promos: make(map[uint]*domain.Promotion), bought: make(map[uint]int)}
+			for _, p := range tt.promos {
+				promos.promos[p.ID] = p
+			}
+			for id, n := range tt.bought {
+				promos.bought[id] = n
+			}
+			trxs := &fakeCheckoutRepo{promos: promos, othersBuy: tt.othersBuy}
+			uc := NewTrxUsecase(trxs, fakeAlamatRepo{}, &fakeCatalogRepo{products: map[uint]domain.Produk{
+				produkID: {ID: produkID, TokoID: 1, CategoryID: categoryID, NamaProduk: "Kopi", HargaReseller: "8000", HargaKonsumen: "10000", Stok: 100, Status: domain.ProductStatusPublished},
+			}}, fakeBuyerRepo{}, promos, nil, fakeAuditRepo{}, VerifyNone)
+
+			trx, err := uc.Create(Actor{UserID: buyer}, CreateTrxInput{
+				MethodBayar: "transfer",
+				AlamatKirim: 1,
+				DetailTrx:   []TrxItemInput{{ProductID: produkID, Kuantitas: tt.kuantitas}},
+			})
+			if trxs.attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", trxs.attempts, tt.wantAttempts)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			if trx.HargaTotal != tt.wantTotal {
+				t.Errorf("harga_total = %d, want %d", trx.HargaTotal, tt.wantTotal)
+			}
+			var got []checkoutLine
+			for i, log := range trxs.logs {
+				line := checkoutLine{kuantitas: trxs.details[i].Kuantitas}
+				line.harga, _ = strconv.Atoi(log.HargaKonsumen)
+				if log.PromotionID != nil {
+					line.promotion = *log.PromotionID
+				}
+				got = append(got, line)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("lines = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
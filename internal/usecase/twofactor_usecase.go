@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+// TOTPEnrollment is returned when a user starts 2FA enrollment.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	// QRPNG is the otpauth URI rendered as a base64 PNG data URL.
+	QRPNG string `json:"qr_png"`
+}
+
+// TOTPConfirmation is returned once 2FA is enabled.
+type TOTPConfirmation struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	// Token is a fresh session token marked as MFA; tokens issued before
+	// 2FA was enabled no longer work.
+	Token string `json:"token"`
+}
+
+// ConfirmTOTPInput represents payload to confirm 2FA enrollment.
+type ConfirmTOTPInput struct {
+	Code string `json:"code"`
+}
+
+// DisableTOTPInput represents payload to turn off 2FA.
+type DisableTOTPInput struct {
+	KataSandi string `json:"kata_sandi"`
+	Code      string `json:"code"`
+}
+
+// TwoFactorUsecase handles TOTP enrollment for the logged-in user.
+type TwoFactorUsecase interface {
+	Enroll(userID uint) (*TOTPEnrollment, error)
+	Confirm(actor Actor, in ConfirmTOTPInput) (*TOTPConfirmation, error)
+	Disable(actor Actor, in DisableTOTPInput) (string, error)
+}
+
+type twoFactorUsecase struct {
+	userRepo     repository.UserRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	audit        *auditor
+	issuer       string
+}
+
+// NewTwoFactorUsecase creates a new TwoFactorUsecase. issuer is the name
+// shown in authenticator apps.
+func NewTwoFactorUsecase(userRepo repository.UserRepository, recoveryRepo repository.RecoveryCodeRepository, auditRepo repository.AuditLogRepository, issuer string) TwoFactorUsecase {
+	return &twoFactorUsecase{userRepo: userRepo, recoveryRepo: recoveryRepo, audit: newAuditor(auditRepo), issuer: issuer}
+}
+
+var (
+	// ErrTOTPAlreadyEnabled indicates 2FA is already active for the user.
+	ErrTOTPAlreadyEnabled = errors.New("2fa sudah aktif")
+	// ErrTOTPNotEnrolled indicates there is no pending or active enrollment.
+	ErrTOTPNotEnrolled = errors.New("2fa belum didaftarkan")
+)
+
+func (uc *twoFactorUsecase) findUser(userID uint) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Enroll generates a new pending secret; 2FA stays off until Confirm.
+func (uc *twoFactorUsecase) Enroll(userID uint) (*TOTPEnrollment, error) {
+	user, err := uc.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := helper.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	uri := helper.TOTPURI(uc.issuer, user.Email, secret)
+	png, err := helper.QRCodePNG(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRPNG:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm turns 2FA on once code matches the pending secret. The caller
+// gets recovery codes and a new session token; every other session of the
+// user is revoked.
+func (uc *twoFactorUsecase) Confirm(actor Actor, in ConfirmTOTPInput) (*TOTPConfirmation, error) {
+	user, err := uc.findUser(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	ok, err := acceptTOTP(uc.userRepo, user, in.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]domain.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := helper.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, domain.RecoveryCode{
+			UserID:   user.ID,
+			CodeHash: helper.HashToken(code),
+		})
+	}
+	if err := uc.recoveryRepo.ReplaceForUser(user.ID, rows); err != nil {
+		return nil, err
+	}
+
+	before := auditSnapshot(user)
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	// revoke sessions that logged in without the second factor
+	user.SessionVersion++
+	if err := uc.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
+
+	token, err := sessionToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPConfirmation{RecoveryCodes: codes, Token: token}, nil
+}
+
+// Disable turns 2FA off and returns a new session token for the caller;
+// every other session of the user is revoked.
+func (uc *twoFactorUsecase) Disable(actor Actor, in DisableTOTPInput) (string, error) {
+	user, err := uc.findUser(actor.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user.TOTPEnabledAt == nil {
+		return "", ErrTOTPNotEnrolled
+	}
+	if err := helper.CheckPasswordHash(user.KataSandi, in.KataSandi); err != nil {
+		return "", ErrWrongPassword
+	}
+	ok, err := acceptTOTP(uc.userRepo, user, in.Code)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrInvalidTOTP
+	}
+
+	if err := uc.recoveryRepo.DeleteByUser(user.ID); err != nil {
+		return "", err
+	}
+
+	before := auditSnapshot(user)
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.SessionVersion++
+	if err := uc.userRepo.Update(user); err != nil {
+		return "", err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
+
+	return sessionToken(user)
+}
+
+// acceptTOTP validates code for user and records its time step as used, so
+// the same code is not accepted a second time.
+func acceptTOTP(userRepo repository.UserRepository, user *domain.User, code string) (bool, error) {
+	step, ok := helper.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return false, nil
+	}
+	if err := userRepo.MarkTOTPStep(user.ID, step); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	// keep the loaded row in step so a later Update does not undo the mark
+	user.TOTPLastStep = step
+	return true, nil
+}
+
+// normalizeRecoveryCode makes recovery codes case-insensitive.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
@@ -2,36 +2,51 @@ package usecase
 
 import (
 	"errors"
+	"time"
 
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
 	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
 )
 
 // UpdateUserInput represents payload to update user profile.
 type UpdateUserInput struct {
-	Nama       string `json:"nama"`
+	Nama         string `json:"nama"`
 	TanggalLahir string `json:"tanggal_Lahir"`
-	Tentang    string `json:"tentang"`
-	Pekerjaan  string `json:"pekerjaan"`
+	Tentang      string `json:"tentang"`
+	Pekerjaan    string `json:"pekerjaan"`
+}
+
+// ChangePasswordInput represents payload to change the current password.
+type ChangePasswordInput struct {
+	KataSandiLama string `json:"kata_sandi_lama"`
+	KataSandiBaru string `json:"kata_sandi_baru"`
 }
 
 // UserUsecase handles business logic related to user account.
 type UserUsecase interface {
 	GetProfile(userID uint) (*domain.User, error)
-	UpdateProfile(userID uint, in UpdateUserInput) (*domain.User, error)
+	UpdateProfile(actor Actor, in UpdateUserInput) (*domain.User, error)
+	ChangePassword(actor Actor, in ChangePasswordInput) (string, error)
 }
 
 type userUsecase struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	apiKeyRepo repository.APIKeyRepository
+	audit      *auditor
 }
 
 // NewUserUsecase creates a new UserUsecase.
-func NewUserUsecase(userRepo repository.UserRepository) UserUsecase {
-	return &userUsecase{userRepo: userRepo}
+func NewUserUsecase(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository, auditRepo repository.AuditLogRepository) UserUsecase {
+	return &userUsecase{userRepo: userRepo, apiKeyRepo: apiKeyRepo, audit: newAuditor(auditRepo)}
 }
 
-// ErrUserNotFound is returned when user is not found.
-var ErrUserNotFound = errors.New("user not found")
+var (
+	// ErrUserNotFound is returned when user is not found.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrWrongPassword is returned when the current password does not match.
+	ErrWrongPassword = errors.New("kata sandi lama salah")
+)
 
 func (uc *userUsecase) GetProfile(userID uint) (*domain.User, error) {
 	user, err := uc.userRepo.FindByID(userID)
@@ -44,14 +59,15 @@ func (uc *userUsecase) GetProfile(userID uint) (*domain.User, error) {
 	return user, nil
 }
 
-func (uc *userUsecase) UpdateProfile(userID uint, in UpdateUserInput) (*domain.User, error) {
-	user, err := uc.userRepo.FindByID(userID)
+func (uc *userUsecase) UpdateProfile(actor Actor, in UpdateUserInput) (*domain.User, error) {
+	user, err := uc.userRepo.FindByID(actor.UserID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
+	before := auditSnapshot(user)
 
 	if in.Nama != "" {
 		user.Nama = in.Nama
@@ -67,6 +83,48 @@ func (uc *userUsecase) UpdateProfile(userID uint, in UpdateUserInput) (*domain.U
 	if err := uc.userRepo.Update(user); err != nil {
 		return nil, err
 	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
 
 	return user, nil
 }
+
+// ChangePassword verifies the current password, stores the new one and
+// returns a fresh token; tokens issued before the change and every API key
+// of the user are revoked.
+func (uc *userUsecase) ChangePassword(actor Actor, in ChangePasswordInput) (string, error) {
+	if in.KataSandiLama == "" || in.KataSandiBaru == "" {
+		return "", errors.New("kata_sandi_lama dan kata_sandi_baru wajib diisi")
+	}
+
+	user, err := uc.userRepo.FindByID(actor.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+
+	if err := helper.CheckPasswordHash(user.KataSandi, in.KataSandiLama); err != nil {
+		return "", ErrWrongPassword
+	}
+
+	hashedPassword, err := helper.HashPassword(in.KataSandiBaru)
+	if err != nil {
+		return "", err
+	}
+
+	if err := uc.apiKeyRepo.RevokeAllByUser(user.ID, time.Now()); err != nil {
+		return "", err
+	}
+
+	before := auditSnapshot(user)
+	user.KataSandi = hashedPassword
+	user.SessionVersion++
+
+	if err := uc.userRepo.Update(user); err != nil {
+		return "", err
+	}
+	uc.audit.record(actor, domain.AuditActionUpdate, AuditEntityUser, user.ID, before, auditSnapshot(user))
+
+	return sessionToken(user)
+}
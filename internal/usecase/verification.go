@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+// VerificationRequirement describes which contact channels a user must have
+// verified before an action (checkout, product creation) is allowed.
+type VerificationRequirement string
+
+const (
+	VerifyNone  VerificationRequirement = "none"
+	VerifyEmail VerificationRequirement = "email"
+	VerifyPhone VerificationRequirement = "phone"
+	VerifyAny   VerificationRequirement = "any"
+	VerifyAll   VerificationRequirement = "all"
+)
+
+// ErrAccountNotVerified indicates the action needs a verified email and/or no_telp.
+var ErrAccountNotVerified = errors.New("email atau no_telp belum diverifikasi")
+
+// checkVerified reports ErrAccountNotVerified if user does not satisfy req.
+// An unknown req is never satisfied.
+func checkVerified(user *domain.User, req VerificationRequirement) error {
+	emailOK := user.EmailVerifiedAt != nil
+	phoneOK := user.NoTelpVerifiedAt != nil
+
+	var ok bool
+	switch req {
+	case VerifyEmail:
+		ok = emailOK
+	case VerifyPhone:
+		ok = phoneOK
+	case VerifyAny:
+		ok = emailOK || phoneOK
+	case VerifyAll:
+		ok = emailOK && phoneOK
+	case VerifyNone:
+		ok = true
+	}
+
+	if !ok {
+		return ErrAccountNotVerified
+	}
+	return nil
+}
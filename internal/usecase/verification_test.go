@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func TestCheckVerified(t *testing.T) {
+	now := time.Now()
+	none := &domain.User{}
+	email := &domain.User{EmailVerifiedAt: &now}
+	phone := &domain.User{NoTelpVerifiedAt: &now}
+	both := &domain.User{EmailVerifiedAt: &now, NoTelpVerifiedAt: &now}
+
+	tests := []struct {
+		name string
+		user *domain.User
+		req  VerificationRequirement
+		want bool
+	}{
+		{"none required", none, VerifyNone, true},
+		{"email verified", email, VerifyEmail, true},
+		{"email missing", phone, VerifyEmail, false},
+		{"phone verified", phone, VerifyPhone, true},
+		{"phone missing", email, VerifyPhone, false},
+		{"any with one", email, VerifyAny, true},
+		{"any with none", none, VerifyAny, false},
+		{"all with both", both, VerifyAll, true},
+		{"all with one", phone, VerifyAll, false},
+		{"unknown requirement", both, "emial", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVerified(tt.user, tt.req)
+			if got := err == nil; got != tt.want {
+				t.Errorf("checkVerified(%s) = %v, want ok %v", tt.req, err, tt.want)
+			}
+			if err != nil && !errors.Is(err, ErrAccountNotVerified) {
+				t.Errorf("checkVerified(%s) = %v, want ErrAccountNotVerified", tt.req, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,579 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/event"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/helper"
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/repository"
+)
+
+const (
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	// webhookLease keeps a claimed delivery from being sent twice while in flight.
+	webhookLease     = time.Minute
+	webhookBatchSize = 50
+	webhookTimeout   = 10 * time.Second
+)
+
+// CreateWebhookInput represents payload to register a webhook endpoint.
+// An empty Events list subscribes to every event.
+type CreateWebhookInput struct {
+	TokoID uint     `json:"toko_id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// UpdateWebhookInput represents payload to change a webhook endpoint.
+type UpdateWebhookInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// CreatedWebhook carries a new endpoint; Secret is shown to the user only once.
+type CreatedWebhook struct {
+	Endpoint *domain.WebhookEndpoint
+	Secret   string
+}
+
+// webhookPayload is the signed JSON body sent to endpoints. ID is stable
+// across retries and redeliveries so receivers can deduplicate; for
+// domain events it is derived from the outbox event ID.
+type webhookPayload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	TokoID    uint        `json:"toko_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// TrxWebhookData is the data of a trx.created event, limited to the
+// receiving toko's lines.
+type TrxWebhookData struct {
+	TrxID       uint                 `json:"trx_id"`
+	KodeInvoice string               `json:"kode_invoice"`
+	MethodBayar string               `json:"method_bayar"`
+	HargaTotal  int                  `json:"harga_total"`
+	AlamatKirim TrxWebhookAlamat     `json:"alamat_kirim"`
+	Items       []TrxWebhookLineItem `json:"items"`
+}
+
+// TrxWebhookAlamat is the shipping address in a trx.created event.
+type TrxWebhookAlamat struct {
+	NamaPenerima string `json:"nama_penerima"`
+	NoTelp       string `json:"no_telp"`
+	DetailAlamat string `json:"detail_alamat"`
+}
+
+// TrxWebhookLineItem is one purchased product in a trx.created event.
+type TrxWebhookLineItem struct {
+	ProductID  uint   `json:"product_id"`
+	NamaProduk string `json:"nama_produk"`
+	Kuantitas  int    `json:"kuantitas"`
+	HargaTotal int    `json:"harga_total"`
+}
+
+// OrderShippedWebhookData is the data of an order.shipped event.
+type OrderShippedWebhookData struct {
+	TrxID       uint   `json:"trx_id"`
+	KodeInvoice string `json:"kode_invoice"`
+	NoResi      string `json:"no_resi"`
+}
+
+// StockWebhookData is the data of a stock.decreased event.
+type StockWebhookData struct {
+	ProductID   uint   `json:"product_id"`
+	NamaProduk  string `json:"nama_produk"`
+	StokSebelum int    `json:"stok_sebelum"`
+	Stok        int    `json:"stok"`
+}
+
+// WebhookUsecase manages toko webhook endpoints and delivers queued events.
+// HandleEvent subscribes it to domain events on the event bus.
+type WebhookUsecase interface {
+	HandleEvent(e event.Event) error
+	GetAll(userID, tokoID uint) ([]domain.WebhookEndpoint, error)
+	Create(actor Actor, in CreateWebhookInput) (*CreatedWebhook, error)
+	Update(actor Actor, id uint, in UpdateWebhookInput) (*domain.WebhookEndpoint, error)
+	Delete(actor Actor, id uint) error
+	Ping(userID, id uint) (*domain.WebhookDelivery, error)
+	GetDeliveries(userID, endpointID uint, limit, page int) ([]domain.WebhookDelivery, error)
+	Redeliver(userID, deliveryID uint) (*domain.WebhookDelivery, error)
+	DispatchDue() (int, error)
+}
+
+type webhookUsecase struct {
+	endpointRepo repository.WebhookEndpointRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	access       *tokoAccess
+	client       *http.Client
+	allowPrivate bool
+}
+
+// NewWebhookUsecase creates a new WebhookUsecase. Endpoints on loopback,
+// private and other non-public addresses are refused unless allowPrivate,
+// which is meant for local testing with cmd/webhook-receiver.
+func NewWebhookUsecase(endpointRepo repository.WebhookEndpointRepository, deliveryRepo repository.WebhookDeliveryRepository, memberRepo repository.TokoMemberRepository, allowPrivate bool) WebhookUsecase {
+	return &webhookUsecase{
+		endpointRepo: endpointRepo,
+		deliveryRepo: deliveryRepo,
+		access:       newTokoAccess(memberRepo),
+		client:       helper.NewWebhookClient(webhookTimeout, allowPrivate),
+		allowPrivate: allowPrivate,
+	}
+}
+
+var (
+	// ErrWebhookNotFound indicates the endpoint or delivery does not exist or belongs to another toko.
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrInvalidWebhookURL indicates a URL that is not absolute http(s).
+	ErrInvalidWebhookURL = errors.New("url harus berupa http atau https")
+	// ErrInvalidWebhookEvent indicates an unknown event in the subscription list.
+	ErrInvalidWebhookEvent = errors.New("event tidak dikenal")
+	// ErrWebhookURLNotPublic indicates a URL whose host does not resolve to public addresses only.
+	ErrWebhookURLNotPublic = errors.New("url harus mengarah ke alamat publik")
+)
+
+// validateWebhookURL checks that raw is absolute http(s) and, unless
+// private targets are allowed, that its host resolves to public addresses.
+func (uc *webhookUsecase) validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if uc.allowPrivate {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := helper.CheckWebhookHost(ctx, u.Hostname()); err != nil {
+		return ErrWebhookURLNotPublic
+	}
+	return nil
+}
+
+// webhookEventList validates events and joins them for storage.
+func webhookEventList(events []string) (string, error) {
+	if len(events) == 0 {
+		return strings.Join(domain.WebhookEvents, ","), nil
+	}
+	seen := make(map[string]bool)
+	var list []string
+	for _, ev := range events {
+		known := false
+		for _, k := range domain.WebhookEvents {
+			if ev == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", ErrInvalidWebhookEvent
+		}
+		if !seen[ev] {
+			seen[ev] = true
+			list = append(list, ev)
+		}
+	}
+	return strings.Join(list, ","), nil
+}
+
+// webhookBackoff returns the wait before retry number attempts+1.
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return d
+}
+
+func (uc *webhookUsecase) GetAll(userID, tokoID uint) ([]domain.WebhookEndpoint, error) {
+	member, err := uc.access.resolve(userID, tokoID, domain.TokoCapSettings)
+	if err != nil {
+		return nil, err
+	}
+	return uc.endpointRepo.GetAllByToko(member.TokoID)
+}
+
+func (uc *webhookUsecase) Create(actor Actor, in CreateWebhookInput) (*CreatedWebhook, error) {
+	if err := uc.validateWebhookURL(in.URL); err != nil {
+		return nil, err
+	}
+	events, err := webhookEventList(in.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	tokoID, err := actingToko(actor, in.TokoID)
+	if err != nil {
+		return nil, err
+	}
+	member, err := uc.access.resolve(actor.UserID, tokoID, domain.TokoCapSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := helper.GenerateToken(24)
+	if err != nil {
+		return nil, err
+	}
+	secret = "whsec_" + secret
+
+	endpoint := &domain.WebhookEndpoint{
+		TokoID:    member.TokoID,
+		URL:       in.URL,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedBy: actor.UserID,
+	}
+	if err := uc.endpointRepo.Create(endpoint); err != nil {
+		return nil, err
+	}
+
+	return &CreatedWebhook{Endpoint: endpoint, Secret: secret}, nil
+}
+
+func (uc *webhookUsecase) Update(actor Actor, id uint, in UpdateWebhookInput) (*domain.WebhookEndpoint, error) {
+	endpoint, err := uc.getManagedEndpoint(actor.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.URL != "" {
+		if err := uc.validateWebhookURL(in.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = in.URL
+	}
+	if in.Events != nil {
+		events, err := webhookEventList(in.Events)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Events = events
+	}
+	if in.Active != nil {
+		endpoint.Active = *in.Active
+	}
+
+	if err := uc.endpointRepo.Update(endpoint); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (uc *webhookUsecase) Delete(actor Actor, id uint) error {
+	endpoint, err := uc.getManagedEndpoint(actor.UserID, id)
+	if err != nil {
+		return err
+	}
+	return uc.endpointRepo.Delete(endpoint.ID)
+}
+
+// Ping queues a test event to the endpoint, even when it is inactive.
+func (uc *webhookUsecase) Ping(userID, id uint) (*domain.WebhookDelivery, error) {
+	endpoint, err := uc.getManagedEndpoint(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	pingID, err := helper.GenerateToken(12)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := uc.buildDeliveries([]domain.WebhookEndpoint{*endpoint}, endpoint.TokoID, domain.WebhookEventPing, "evt_"+pingID, webhookPing{Message: "pong"})
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.deliveryRepo.CreateMany(deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// webhookPing is the data of a ping event.
+type webhookPing struct {
+	Message string `json:"message"`
+}
+
+func (uc *webhookUsecase) GetDeliveries(userID, endpointID uint, limit, page int) ([]domain.WebhookDelivery, error) {
+	endpoint, err := uc.getManagedEndpoint(userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.deliveryRepo.GetAllByEndpoint(endpoint.ID, limit, page)
+}
+
+// Redeliver queues a copy of a past delivery; the original log row is kept.
+func (uc *webhookUsecase) Redeliver(userID, deliveryID uint) (*domain.WebhookDelivery, error) {
+	original, err := uc.deliveryRepo.GetByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, ErrWebhookNotFound
+	}
+	if _, err := uc.getManagedEndpoint(userID, original.EndpointID); err != nil {
+		return nil, err
+	}
+
+	deliveries := []domain.WebhookDelivery{{
+		EndpointID:    original.EndpointID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        domain.WebhookStatusPending,
+		NextAttemptAt: time.Now(),
+	}}
+	if err := uc.deliveryRepo.CreateMany(deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// HandleEvent turns domain events into webhook deliveries: trx.created goes
+// to each toko in the trx with only its own lines, order.shipped to the
+// shipping toko, and stock.changed is sent as stock.decreased when the stock
+// went down. Other events are ignored.
+func (uc *webhookUsecase) HandleEvent(e event.Event) error {
+	payloadID := fmt.Sprintf("evt_%d", e.ID)
+
+	switch e.Type {
+	case domain.EventTrxCreated:
+		var trx event.TrxCreated
+		if err := e.Decode(&trx); err != nil {
+			return err
+		}
+		byToko := make(map[uint]*TrxWebhookData)
+		var tokoIDs []uint
+		for _, item := range trx.Items {
+			data, ok := byToko[item.TokoID]
+			if !ok {
+				data = &TrxWebhookData{
+					TrxID:       trx.TrxID,
+					KodeInvoice: trx.KodeInvoice,
+					MethodBayar: trx.MethodBayar,
+					AlamatKirim: TrxWebhookAlamat{
+						NamaPenerima: trx.Alamat.NamaPenerima,
+						NoTelp:       trx.Alamat.NoTelp,
+						DetailAlamat: trx.Alamat.DetailAlamat,
+					},
+				}
+				byToko[item.TokoID] = data
+				tokoIDs = append(tokoIDs, item.TokoID)
+			}
+			data.HargaTotal += item.HargaTotal
+			data.Items = append(data.Items, TrxWebhookLineItem{
+				ProductID:  item.ProductID,
+				NamaProduk: item.NamaProduk,
+				Kuantitas:  item.Kuantitas,
+				HargaTotal: item.HargaTotal,
+			})
+		}
+		for _, tokoID := range tokoIDs {
+			if err := uc.publish(tokoID, domain.WebhookEventTrxCreated, payloadID, byToko[tokoID]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case domain.EventOrderShipped:
+		var shipped event.OrderShipped
+		if err := e.Decode(&shipped); err != nil {
+			return err
+		}
+		return uc.publish(shipped.TokoID, domain.WebhookEventOrderShipped, payloadID, OrderShippedWebhookData{
+			TrxID:       shipped.TrxID,
+			KodeInvoice: shipped.KodeInvoice,
+			NoResi:      shipped.NoResi,
+		})
+
+	case domain.EventStockChanged:
+		var sc event.StockChanged
+		if err := e.Decode(&sc); err != nil {
+			return err
+		}
+		if sc.Stok >= sc.StokSebelum {
+			return nil
+		}
+		return uc.publish(sc.TokoID, domain.WebhookEventStockDecreased, payloadID, StockWebhookData{
+			ProductID:   sc.ProductID,
+			NamaProduk:  sc.NamaProduk,
+			StokSebelum: sc.StokSebelum,
+			Stok:        sc.Stok,
+		})
+	}
+	return nil
+}
+
+// publish queues a webhook event for every active endpoint of the toko
+// subscribed to it.
+func (uc *webhookUsecase) publish(tokoID uint, webhookEvent, payloadID string, data interface{}) error {
+	endpoints, err := uc.endpointRepo.GetActiveByToko(tokoID)
+	if err != nil {
+		return err
+	}
+
+	var subscribed []domain.WebhookEndpoint
+	for _, ep := range endpoints {
+		if ep.Subscribed(webhookEvent) {
+			subscribed = append(subscribed, ep)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	deliveries, err := uc.buildDeliveries(subscribed, tokoID, webhookEvent, payloadID, data)
+	if err != nil {
+		return err
+	}
+	return uc.deliveryRepo.CreateMany(deliveries)
+}
+
+func (uc *webhookUsecase) buildDeliveries(endpoints []domain.WebhookEndpoint, tokoID uint, webhookEvent, payloadID string, data interface{}) ([]domain.WebhookDelivery, error) {
+	body, err := json.Marshal(webhookPayload{
+		ID:        payloadID,
+		Event:     webhookEvent,
+		TokoID:    tokoID,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deliveries := make([]domain.WebhookDelivery, 0, len(endpoints))
+	for _, ep := range endpoints {
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			EndpointID:    ep.ID,
+			Event:         webhookEvent,
+			Payload:       string(body),
+			Status:        domain.WebhookStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	return deliveries, nil
+}
+
+// DispatchDue sends pending deliveries whose time has come and returns how
+// many were attempted. Failures are rescheduled with exponential backoff
+// until webhookMaxAttempts, after which the delivery is marked failed.
+// Deliveries to an inactive endpoint are marked failed unsent, except pings.
+func (uc *webhookUsecase) DispatchDue() (int, error) {
+	due, err := uc.deliveryRepo.GetDue(time.Now(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range due {
+		d := &due[i]
+		claimed, err := uc.deliveryRepo.Claim(d, time.Now().Add(webhookLease))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if d.Endpoint.Active || d.Event == domain.WebhookEventPing {
+			uc.attempt(d)
+			sent++
+		} else {
+			// the endpoint was deactivated or deleted after this was queued
+			d.Status = domain.WebhookStatusFailed
+			d.LastError = "endpoint tidak aktif"
+		}
+		if err := uc.deliveryRepo.Update(d); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// attempt sends one delivery and records the outcome on it.
+func (uc *webhookUsecase) attempt(d *domain.WebhookDelivery) {
+	d.Attempts++
+	statusCode, err := uc.send(d)
+	d.LastStatusCode = statusCode
+
+	if err == nil {
+		now := time.Now()
+		d.Status = domain.WebhookStatusSucceeded
+		d.DeliveredAt = &now
+		d.LastError = ""
+		return
+	}
+
+	d.LastError = err.Error()
+	if len(d.LastError) > 500 {
+		d.LastError = d.LastError[:500]
+	}
+	if d.Attempts >= webhookMaxAttempts {
+		d.Status = domain.WebhookStatusFailed
+		log.Printf("webhook: delivery %d to endpoint %d failed after %d attempts: %v", d.ID, d.EndpointID, d.Attempts, err)
+		return
+	}
+	d.NextAttemptAt = time.Now().Add(webhookBackoff(d.Attempts))
+}
+
+func (uc *webhookUsecase) send(d *domain.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequest(http.MethodPost, d.Endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Evermos-Webhook/1.0")
+	req.Header.Set("X-Evermos-Event", d.Event)
+	req.Header.Set("X-Evermos-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(helper.WebhookSignatureHeader, helper.SignWebhook(d.Endpoint.Secret, time.Now(), body))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// getManagedEndpoint loads an endpoint of a toko where the user may manage settings.
+func (uc *webhookUsecase) getManagedEndpoint(userID, id uint) (*domain.WebhookEndpoint, error) {
+	endpoint, err := uc.endpointRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == nil {
+		return nil, ErrWebhookNotFound
+	}
+	if _, err := uc.access.resolve(userID, endpoint.TokoID, domain.TokoCapSettings); err != nil {
+		if errors.Is(err, ErrTokoNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chandraRGB/MiniProject-GustiChandraMiftahulMunir/internal/domain"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{10, 4*time.Hour + 16*time.Minute},
+		{11, webhookMaxBackoff},
+		{50, webhookMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// fakeWebhookDeliveryRepo keeps deliveries in memory for DispatchDue.
+type fakeWebhookDeliveryRepo struct {
+	deliveries []domain.WebhookDelivery
+}
+
+func (r *fakeWebhookDeliveryRepo) CreateMany(deliveries []domain.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func (r *fakeWebhookDeliveryRepo) GetByID(id uint) (*domain.WebhookDelivery, error) {
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == id {
+			return &r.deliveries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeWebhookDeliveryRepo) GetAllByEndpoint(endpointID uint, limit, page int) ([]domain.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (r *fakeWebhookDeliveryRepo) GetDue(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var due []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == domain.WebhookStatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeWebhookDeliveryRepo) Claim(delivery *domain.WebhookDelivery, leaseUntil time.Time) (bool, error) {
+	delivery.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+func (r *fakeWebhookDeliveryRepo) Update(delivery *domain.WebhookDelivery) error {
+	for i := range r.deliveries {
+		if r.deliveries[i].ID == delivery.ID {
+			r.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+func TestWebhookDispatchDue(t *testing.T) {
+	var requests atomic.Int32
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	endpoint := func(url string, active bool) domain.WebhookEndpoint {
+		return domain.WebhookEndpoint{ID: 1, URL: url, Secret: "s3cret", Active: active}
+	}
+
+	tests := []struct {
+		name         string
+		endpoint     domain.WebhookEndpoint
+		event        string
+		attempts     int
+		wantStatus   string
+		wantAttempts int
+		wantRequests int32
+		wantBackoff  time.Duration
+	}{
+		{"delivered", endpoint(ok.URL, true), domain.WebhookEventTrxCreated, 0, domain.WebhookStatusSucceeded, 1, 1, 0},
+		{"failure is retried with backoff", endpoint(failing.URL, true), domain.WebhookEventTrxCreated, 2, domain.WebhookStatusPending, 3, 1, 2 * time.Minute},
+		{"last attempt fails the delivery", endpoint(failing.URL, true), domain.WebhookEventTrxCreated, webhookMaxAttempts - 1, domain.WebhookStatusFailed, webhookMaxAttempts, 1, 0},
+		{"inactive endpoint is not sent to", endpoint(ok.URL, false), domain.WebhookEventTrxCreated, 1, domain.WebhookStatusFailed, 1, 0, 0},
+		{"deleted endpoint is not sent to", domain.WebhookEndpoint{}, domain.WebhookEventOrderShipped, 0, domain.WebhookStatusFailed, 0, 0, 0},
+		{"ping reaches an inactive endpoint", endpoint(ok.URL, false), domain.WebhookEventPing, 0, domain.WebhookStatusSucceeded, 1, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests.Store(0)
+			repo := &fakeWebhookDeliveryRepo{}
+			_ = repo.CreateMany([]domain.WebhookDelivery{{
+				ID:            7,
+				EndpointID:    tt.endpoint.ID,
+				Event:         tt.event,
+				Payload:       `{"id":"evt_1"}`,
+				Status:        domain.WebhookStatusPending,
+				Attempts:      tt.attempts,
+				NextAttemptAt: time.Now().Add(-time.Second),
+				Endpoint:      tt.endpoint,
+			}})
+			uc := NewWebhookUsecase(nil, repo, nil, true)
+
+			before := time.Now()
+			if _, err := uc.DispatchDue(); err != nil {
+				t.Fatalf("DispatchDue: %v", err)
+			}
+
+			d := repo.deliveries[0]
+			if d.Status != tt.wantStatus || d.Attempts != tt.wantAttempts {
+				t.Errorf("status, attempts = %s, %d, want %s, %d", d.Status, d.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if tt.wantBackoff > 0 {
+				if wait := d.NextAttemptAt.Sub(before); wait < tt.wantBackoff || wait > tt.wantBackoff+time.Minute {
+					t.Errorf("next attempt in %v, want %v", wait, tt.wantBackoff)
+				}
+			}
+		})
+	}
+}